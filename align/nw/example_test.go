@@ -38,7 +38,7 @@ func ExampleAligner_Align() {
 		{-4, -4, -4, -4, 0},
 	}
 
-	needle := &Aligner{Matrix: nwm, LookUp: LookUpN, GapChar: '-'}
+	needle := &Aligner{Matrix: nwm, GapChar: '-'}
 	if nwa, err := needle.Align(nwsa, nwsb); err == nil {
 		fmt.Printf("%s\n%s\n", nwa[0].Seq, nwa[1].Seq)
 	}
@@ -17,40 +17,12 @@ package nw
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/seq"
 	"github.com/kortschak/BioGo/util"
 )
 
-// Default character table lookups.
-var LookUpN, LookUpR, LookUpP util.CTL
-
-func init() {
-	m := make(map[int]int)
-
-	for i, v := range bio.N {
-		m[int(v)] = i % (len(bio.N) / 2)
-	}
-
-	LookUpN = *util.NewCTL(m)
-
-	m = make(map[int]int)
-
-	for i, v := range bio.R {
-		m[int(v)] = i % (len(bio.R) / 2)
-	}
-
-	LookUpR = *util.NewCTL(m)
-
-	m = make(map[int]int)
-
-	for i, v := range bio.P {
-		m[int(v)] = i % (len(bio.P) / 2)
-	}
-
-	LookUpP = *util.NewCTL(m)
-}
-
 const (
 	diag = iota
 	up
@@ -70,17 +42,21 @@ func maxIndex(a []int) (d int) {
 
 // Needleman-Wunsch aligner type.
 // Matrix is a square scoring matrix with the last column and last row specifying gap penalties.
-// GapChar is the character used to fill gaps. LookUp is used to translate sequance values into
-// positions in the scoring matrix.
+// GapChar is the character used to fill gaps. Sequence letters are translated into positions
+// in the scoring matrix by the letter indices of the sequences' alphabet, so the matrix must
+// have one row and column for each letter of the alphabet, or for each letter preceding the
+// nucleotide ambiguity codes of an alphabet such as alphabet.DNAredundant, in which case ambiguity
+// codes are treated as invalid letters.
 // Currently gap opening is not considered.
 type Aligner struct {
 	Matrix  [][]int
 	GapChar byte
-	LookUp  util.CTL
 }
 
-// Method to align two sequences using the Smith-Waterman algorithm. Returns an alignment or an error
-// if the scoring matrix is not square.
+// Method to align two sequences using the Needleman-Wunsch algorithm. Returns an alignment or an error
+// if the scoring matrix is not square. A circular reference is first rotated to the origin of the best
+// semi-global alignment of the query to the unrolled reference; the Offset of the aligned reference
+// gives the position of the rotated origin.
 func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err error) {
 	gap := len(self.Matrix) - 1
 	for _, row := range self.Matrix {
@@ -88,7 +64,27 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 			return nil, bio.NewError("Scoring matrix is not square.", 0, self.Matrix)
 		}
 	}
-	r, c := reference.Len()+1, query.Len()+1
+	a := reference.GetAlphabet()
+	if query.GetAlphabet() != a {
+		return nil, bio.NewError("Sequence alphabets do not match.", 0, reference, query)
+	}
+	if a.Len() != gap && alphabet.Unambiguous(a) != gap {
+		return nil, bio.NewError("Scoring matrix size does not match alphabet.", 0, self.Matrix, a)
+	}
+	index := a.LetterIndex()
+	for i, v := range index {
+		if v >= gap {
+			index[i] = -1
+		}
+	}
+
+	// A circular reference is rotated to begin where the query is best placed.
+	rs, origin := reference.Seq, 0
+	if n := reference.Len(); reference.Circular && n > 1 {
+		origin = self.origin(reference.Seq, query.Seq, index, gap)
+		rs = append(append(make([]byte, 0, n), rs[origin:]...), rs[:origin]...)
+	}
+	r, c := len(rs)+1, query.Len()+1
 	table := make([][]int, r)
 	for i := range table {
 		table[i] = make([]int, c)
@@ -98,7 +94,7 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 
 	for i := 1; i < r; i++ {
 		for j := 1; j < c; j++ {
-			if rVal, qVal := self.LookUp.ValueToCode[reference.Seq[i-1]], self.LookUp.ValueToCode[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
+			if rVal, qVal := index[rs[i-1]], index[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
 				continue
 			} else {
 				scores[diag] = table[i-1][j-1] + self.Matrix[rVal][qVal]
@@ -109,12 +105,12 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 		}
 	}
 
-	refAln := &seq.Seq{ID: reference.ID, Seq: make([]byte, 0, reference.Len())}
-	queryAln := &seq.Seq{ID: query.ID, Seq: make([]byte, 0, query.Len())}
+	refAln := &seq.Seq{ID: reference.ID, Seq: make([]byte, 0, reference.Len()), Alphabet: reference.Alphabet}
+	queryAln := &seq.Seq{ID: query.ID, Seq: make([]byte, 0, query.Len()), Alphabet: query.Alphabet}
 
 	i, j := r-1, c-1
 	for i > 0 && j > 0 {
-		if rVal, qVal := self.LookUp.ValueToCode[reference.Seq[i-1]], self.LookUp.ValueToCode[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
+		if rVal, qVal := index[rs[i-1]], index[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
 			continue
 		} else {
 			scores[diag] = table[i-1][j-1] + self.Matrix[rVal][qVal]
@@ -124,11 +120,11 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 			case diag:
 				i--
 				j--
-				refAln.Seq = append(refAln.Seq, reference.Seq[i])
+				refAln.Seq = append(refAln.Seq, rs[i])
 				queryAln.Seq = append(queryAln.Seq, query.Seq[j])
 			case up:
 				i--
-				refAln.Seq = append(refAln.Seq, reference.Seq[i])
+				refAln.Seq = append(refAln.Seq, rs[i])
 				queryAln.Seq = append(queryAln.Seq, self.GapChar)
 			case left:
 				j--
@@ -139,7 +135,7 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 	}
 
 	for ; i > 0; i-- {
-		refAln.Seq = append(refAln.Seq, reference.Seq[i-1])
+		refAln.Seq = append(refAln.Seq, rs[i-1])
 		queryAln.Seq = append(queryAln.Seq, self.GapChar)
 	}
 	for ; j > 0; j-- {
@@ -147,6 +143,9 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 		queryAln.Seq = append(queryAln.Seq, query.Seq[j-1])
 	}
 
+	refAln.Offset = reference.Offset + origin
+	queryAln.Offset = query.Offset
+
 	for i, j := 0, len(refAln.Seq)-1; i < j; i, j = i+1, j-1 {
 		refAln.Seq[i], refAln.Seq[j] = refAln.Seq[j], refAln.Seq[i]
 	}
@@ -158,3 +157,55 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 
 	return
 }
+
+// Return the index in the circular reference at which the best alignment of the whole query to the
+// unrolled reference begins.
+func (self *Aligner) origin(reference, query []byte, index []int, gap int) int {
+	n := len(reference)
+	unrolled := append(append(make([]byte, 0, 2*n-1), reference...), reference[:n-1]...)
+	r, c := len(unrolled)+1, len(query)+1
+	table := make([][]int, r)
+	from := make([][]int, r) // Reference index at which the alignment ending at each cell begins.
+	for i := range table {
+		table[i] = make([]int, c)
+		from[i] = make([]int, c)
+		from[i][0] = i
+	}
+	for j := 1; j < c; j++ {
+		if qVal := index[query[j-1]]; qVal >= 0 {
+			table[0][j] = table[0][j-1] + self.Matrix[gap][qVal]
+		}
+	}
+
+	var scores [3]int
+	for i := 1; i < r; i++ {
+		for j := 1; j < c; j++ {
+			if rVal, qVal := index[unrolled[i-1]], index[query[j-1]]; rVal < 0 || qVal < 0 {
+				from[i][j] = i
+			} else {
+				scores[diag] = table[i-1][j-1] + self.Matrix[rVal][qVal]
+				scores[up] = table[i-1][j] + self.Matrix[rVal][gap]
+				scores[left] = table[i][j-1] + self.Matrix[gap][qVal]
+				d := maxIndex(scores[:])
+				switch d {
+				case diag:
+					from[i][j] = from[i-1][j-1]
+				case up:
+					from[i][j] = from[i-1][j]
+				case left:
+					from[i][j] = from[i][j-1]
+				}
+				table[i][j] = scores[d]
+			}
+		}
+	}
+
+	best := 0
+	for i := 1; i < r; i++ {
+		if table[i][c-1] > table[best][c-1] {
+			best = i
+		}
+	}
+
+	return from[best][c-1] % n
+}
@@ -17,6 +17,7 @@ package nw
 
 import (
 	"github.com/kortschak/BioGo/io/seqio/fasta"
+	"github.com/kortschak/BioGo/seq"
 	check "launchpad.net/gocheck"
 	"testing"
 )
@@ -31,6 +32,31 @@ var _ = check.Suite(&S{})
 func (s *S) TestXXX(c *check.C) {
 }
 
+func (s *S) TestAlignCircular(c *check.C) {
+	nwm := [][]int{
+		{2, -1, -1, -1, -2},
+		{-1, 2, -1, -1, -2},
+		{-1, -1, 2, -1, -2},
+		{-1, -1, -1, 2, -2},
+		{-2, -2, -2, -2, 0},
+	}
+	needle := &Aligner{Matrix: nwm, GapChar: '-'}
+
+	ref := &seq.Seq{Seq: []byte("TTCACGAAGGATC"), Offset: 5, Circular: true}
+	query := &seq.Seq{Seq: []byte("GGATCTTCACGAA")}
+	aln, err := needle.Align(ref, query)
+	c.Assert(err, check.IsNil)
+	c.Check(string(aln[0].Seq), check.Equals, "GGATCTTCACGAA")
+	c.Check(string(aln[1].Seq), check.Equals, "GGATCTTCACGAA")
+	c.Check(aln[0].Offset, check.Equals, 13)
+
+	ref.Circular = false
+	aln, err = needle.Align(ref, query)
+	c.Assert(err, check.IsNil)
+	c.Check(aln[0].Offset, check.Equals, 5)
+	c.Check(string(aln[0].Seq) != string(aln[1].Seq), check.Equals, true)
+}
+
 func BenchmarkAlign(b *testing.B) {
 	b.StopTimer()
 	if r, err := fasta.NewReaderName("../testdata/crsp.fa"); err != nil {
@@ -47,7 +73,7 @@ func BenchmarkAlign(b *testing.B) {
 			{-4, -4, -4, -4, 0},
 		}
 
-		needle := &Aligner{Matrix: nwm, LookUp: LookUpN, GapChar: '-'}
+		needle := &Aligner{Matrix: nwm, GapChar: '-'}
 		b.StartTimer()
 		for i := 0; i < b.N; i++ {
 			needle.Align(nwsa, nwsb)
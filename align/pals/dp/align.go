@@ -53,6 +53,7 @@ func (self *Aligner) AlignTraps(trapezoids filter.Trapezoids) (segments DPHits)
 	dp := &kernel{
 		target:     self.target,
 		query:      self.query,
+		valid:      self.query.GetAlphabet().ValidLetters(),
 		trapezoids: trapezoids,
 		covered:    covered,
 		minLen:     self.minHitLength,
@@ -78,7 +78,7 @@ func (s *S) TestAlignment(c *check.C) {
 			{-1, -1, -1, -1, 0},
 		}
 
-		smith := &sw.Aligner{Matrix: swm, LookUp: sw.LookUpN, GapChar: '-'}
+		smith := &sw.Aligner{Matrix: swm, GapChar: '-'}
 		swa, _ := smith.Align(sa, sb)
 		c.Logf("a: %s\nb: %s\n", swa[0], swa[1])
 	}
@@ -28,6 +28,7 @@ const (
 // A kernel handles the actual dp alignment process.
 type kernel struct {
 	target, query *seq.Seq
+	valid         []bool // Letters of the query alphabet.
 	minLen        int
 	maxDiff       float64
 	lowEnd        DPHit
@@ -217,7 +218,7 @@ func (self *kernel) traceForward(mid, low, high int) {
 			temp = cost
 			cost = score
 			score = thatVector.at(j)
-			if self.query.Seq[i] == self.target.Seq[j-1] && lookUp.ValueToCode[self.query.Seq[i]] >= 0 {
+			if self.query.Seq[i] == self.target.Seq[j-1] && self.valid[self.query.Seq[i]] {
 				cost += MatchCost
 			}
 
@@ -241,7 +242,7 @@ func (self *kernel) traceForward(mid, low, high int) {
 		if j <= self.target.Len() {
 			var ratchet int
 
-			if self.query.Seq[i] == self.target.Seq[j-1] && lookUp.ValueToCode[self.query.Seq[i]] >= 0 {
+			if self.query.Seq[i] == self.target.Seq[j-1] && self.valid[self.query.Seq[i]] {
 				score += MatchCost
 			}
 
@@ -367,7 +368,7 @@ func (self *kernel) traceReverse(top, low, high, bottom, xfactor int) {
 			temp = cost
 			cost = score
 			score = thatVector.at(j)
-			if self.query.Seq[i] == self.target.Seq[j] && lookUp.ValueToCode[self.query.Seq[i]] >= 0 {
+			if self.query.Seq[i] == self.target.Seq[j] && self.valid[self.query.Seq[i]] {
 				cost += MatchCost
 			}
 
@@ -391,7 +392,7 @@ func (self *kernel) traceReverse(top, low, high, bottom, xfactor int) {
 		if j >= 0 {
 			var ratchet int
 
-			if self.query.Seq[i] == self.target.Seq[j] && lookUp.ValueToCode[self.query.Seq[i]] >= 0 {
+			if self.query.Seq[i] == self.target.Seq[j] && self.valid[self.query.Seq[i]] {
 				score += MatchCost
 			}
 
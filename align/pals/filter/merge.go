@@ -28,6 +28,7 @@ const (
 // A Merger aggregates and clips an ordered set of trapezoids.
 type Merger struct {
 	target, query              *seq.Seq
+	targetValid, queryValid    []bool // Letters of the target and query alphabets.
 	filterParams               *Params
 	leftPadding, bottomPadding int
 	binWidth                   int
@@ -57,6 +58,8 @@ func NewMerger(index *kmerindex.Index, query *seq.Seq, filterParams *Params, sel
 		target:         index.Seq,
 		filterParams:   filterParams,
 		query:          query,
+		targetValid:    index.Seq.GetAlphabet().ValidLetters(),
+		queryValid:     query.GetAlphabet().ValidLetters(),
 		selfComparison: selfCompare,
 		bottomPadding:  index.GetK() + 2,
 		leftPadding:    leftPadding,
@@ -163,7 +166,7 @@ func (self *Merger) clipVertical() {
 
 		i := 0
 		for i = lagPosition; i < lastPosition; i++ {
-			if lookUp.ValueToCode[self.query.Seq[i]] >= 0 {
+			if self.queryValid[self.query.Seq[i]] {
 				if i-lagPosition >= MaxIGap {
 					if lagPosition-base.Bottom > 0 {
 						if self.freeTraps == nil {
@@ -210,7 +213,7 @@ func (self *Merger) clipTrapezoids() {
 		lagClip := aBottom
 		i := 0
 		for i = lagPosition; i < lastPosition; i++ {
-			if lookUp.ValueToCode[self.target.Seq[i]] >= 0 {
+			if self.targetValid[self.target.Seq[i]] {
 				if i-lagPosition >= MaxIGap {
 					if lagPosition > lagClip {
 						if self.freeTraps == nil {
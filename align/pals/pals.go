@@ -52,6 +52,7 @@ type PALS struct {
 	index         *kmerindex.Index
 	FilterParams  *filter.Params
 	DPParams      *dp.Params
+	SkipMasked    bool // Ignore kmers containing soft-masked (lower case) letters when filtering.
 	log           Logger
 	timer         *util.Timer
 	tubeOffset    int
@@ -224,7 +225,11 @@ func (self *PALS) MemRequired(filterParams *filter.Params) uintptr {
 // Build the kmerindex for filtering.
 func (self *PALS) BuildIndex() (err error) {
 	self.notify("Indexing")
-	index, err := kmerindex.New(self.FilterParams.WordSize, self.target)
+	newIndex := kmerindex.New
+	if self.SkipMasked {
+		newIndex = kmerindex.NewMasked
+	}
+	index, err := newIndex(self.FilterParams.WordSize, self.target)
 	if err != nil {
 		return
 	} else {
@@ -35,7 +35,7 @@ func ExampleAligner_Align() {
 		{-1, -1, -1, -1, 0},
 	}
 
-	smith := &Aligner{Matrix: swm, LookUp: LookUpN, GapChar: '-'}
+	smith := &Aligner{Matrix: swm, GapChar: '-'}
 	if swa, err := smith.Align(swsa, swsb); err == nil {
 		fmt.Printf("%s\n%s\n", swa[0].Seq, swa[1].Seq)
 	}
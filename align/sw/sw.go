@@ -17,40 +17,12 @@ package sw
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/seq"
 	"github.com/kortschak/BioGo/util"
 )
 
-// Default character table lookups.
-var LookUpN, LookUpR, LookUpP util.CTL
-
-func init() {
-	m := make(map[int]int)
-
-	for i, v := range bio.N {
-		m[int(v)] = i % (len(bio.N) / 2)
-	}
-
-	LookUpN = *util.NewCTL(m)
-
-	m = make(map[int]int)
-
-	for i, v := range bio.R {
-		m[int(v)] = i % (len(bio.R) / 2)
-	}
-
-	LookUpR = *util.NewCTL(m)
-
-	m = make(map[int]int)
-
-	for i, v := range bio.P {
-		m[int(v)] = i % (len(bio.P) / 2)
-	}
-
-	LookUpP = *util.NewCTL(m)
-}
-
 const (
 	diag = iota
 	up
@@ -70,17 +42,20 @@ func maxIndex(a []int) (d int) {
 
 // Smith-Waterman aligner type.
 // Matrix is a square scoring matrix with the last column and last row specifying gap penalties.
-// GapChar is the character used to fill gaps. LookUp is used to translate sequance values into
-// positions in the scoring matrix.
+// GapChar is the character used to fill gaps. Sequence letters are translated into positions
+// in the scoring matrix by the letter indices of the sequences' alphabet, so the matrix must
+// have one row and column for each letter of the alphabet, or for each letter preceding the
+// nucleotide ambiguity codes of an alphabet such as alphabet.DNAredundant, in which case ambiguity
+// codes are treated as invalid letters.
 // Currently gap opening is not considered.
 type Aligner struct {
 	Matrix  [][]int
 	GapChar byte
-	LookUp  util.CTL
 }
 
 // Method to align two sequences using the Smith-Waterman algorithm. Returns an alignment or an error
-// if the scoring matrix is not square.
+// if the scoring matrix is not square. The Offsets of the aligned sequences give the positions at
+// which the alignment starts. Local alignments with a circular reference may span its origin.
 func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err error) {
 	gap := len(self.Matrix) - 1
 	for _, row := range self.Matrix {
@@ -88,7 +63,26 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 			return nil, bio.NewError("Scoring matrix is not square.", 0, self.Matrix)
 		}
 	}
-	r, c := reference.Len()+1, query.Len()+1
+	a := reference.GetAlphabet()
+	if query.GetAlphabet() != a {
+		return nil, bio.NewError("Sequence alphabets do not match.", 0, reference, query)
+	}
+	if a.Len() != gap && alphabet.Unambiguous(a) != gap {
+		return nil, bio.NewError("Scoring matrix size does not match alphabet.", 0, self.Matrix, a)
+	}
+	index := a.LetterIndex()
+	for i, v := range index {
+		if v >= gap {
+			index[i] = -1
+		}
+	}
+
+	// A circular reference is unrolled so that local alignments may span its origin.
+	rs := reference.Seq
+	if n := reference.Len(); reference.Circular && n > 1 {
+		rs = append(append(make([]byte, 0, 2*n-1), rs...), rs[:n-1]...)
+	}
+	r, c := len(rs)+1, query.Len()+1
 	table := make([][]int, r)
 	for i := range table {
 		table[i] = make([]int, c)
@@ -102,7 +96,7 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 
 	for i := 1; i < r; i++ {
 		for j := 1; j < c; j++ {
-			if rVal, qVal := self.LookUp.ValueToCode[reference.Seq[i-1]], self.LookUp.ValueToCode[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
+			if rVal, qVal := index[rs[i-1]], index[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
 				continue
 			} else {
 				scores[diag] = table[i-1][j-1] + self.Matrix[rVal][qVal]
@@ -120,11 +114,12 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 		}
 	}
 
-	refAln := &seq.Seq{ID: reference.ID, Seq: make([]byte, 0, reference.Len())}
-	queryAln := &seq.Seq{ID: query.ID, Seq: make([]byte, 0, query.Len())}
+	refAln := &seq.Seq{ID: reference.ID, Seq: make([]byte, 0, reference.Len()), Alphabet: reference.Alphabet}
+	queryAln := &seq.Seq{ID: query.ID, Seq: make([]byte, 0, query.Len()), Alphabet: query.Alphabet}
 
-	for i, j := maxI, maxJ; table[i][j] != 0 && i > 0 && j > 0; {
-		if rVal, qVal := self.LookUp.ValueToCode[reference.Seq[i-1]], self.LookUp.ValueToCode[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
+	i, j := maxI, maxJ
+	for table[i][j] != 0 && i > 0 && j > 0 {
+		if rVal, qVal := index[rs[i-1]], index[query.Seq[j-1]]; rVal < 0 || qVal < 0 {
 			continue
 		} else {
 			scores[diag] = table[i-1][j-1] + self.Matrix[rVal][qVal]
@@ -134,11 +129,11 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 			case diag:
 				i--
 				j--
-				refAln.Seq = append(refAln.Seq, reference.Seq[i])
+				refAln.Seq = append(refAln.Seq, rs[i])
 				queryAln.Seq = append(queryAln.Seq, query.Seq[j])
 			case up:
 				i--
-				refAln.Seq = append(refAln.Seq, reference.Seq[i])
+				refAln.Seq = append(refAln.Seq, rs[i])
 				queryAln.Seq = append(queryAln.Seq, self.GapChar)
 			case left:
 				j--
@@ -148,6 +143,11 @@ func (self *Aligner) Align(reference, query *seq.Seq) (aln seq.Alignment, err er
 		}
 	}
 
+	if n := reference.Len(); n > 0 {
+		refAln.Offset = reference.Offset + i%n
+	}
+	queryAln.Offset = query.Offset + j
+
 	for i, j := 0, len(refAln.Seq)-1; i < j; i, j = i+1, j-1 {
 		refAln.Seq[i], refAln.Seq[j] = refAln.Seq[j], refAln.Seq[i]
 	}
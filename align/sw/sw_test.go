@@ -16,7 +16,9 @@ package sw
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/io/seqio/fasta"
+	"github.com/kortschak/BioGo/seq"
 	check "launchpad.net/gocheck"
 	"testing"
 )
@@ -28,7 +30,75 @@ type S struct{}
 
 var _ = check.Suite(&S{})
 
-func (s *S) TestXXX(c *check.C) {
+func (s *S) TestAlignAlphabet(c *check.C) {
+	// A methylation-aware alphabet with letters in the order a, c, g, m, t.
+	meth, err := alphabet.NewGeneric("acgtm", !alphabet.CaseSensitive)
+	c.Assert(err, check.IsNil)
+
+	swm := [][]int{
+		{2, -1, -1, -1, -1, -1},
+		{-1, 2, -1, 1, -1, -1},
+		{-1, -1, 2, -1, -1, -1},
+		{-1, 1, -1, 2, -1, -1},
+		{-1, -1, -1, -1, 2, -1},
+		{-1, -1, -1, -1, -1, 0},
+	}
+	smith := &Aligner{Matrix: swm, GapChar: '-'}
+
+	swsa := &seq.Seq{Seq: []byte("TTAmGTAmGA"), Alphabet: meth}
+	swsb := &seq.Seq{Seq: []byte("AmGTACGA"), Alphabet: meth}
+	swa, err := smith.Align(swsa, swsb)
+	c.Assert(err, check.IsNil)
+	c.Check(string(swa[0].Seq), check.Equals, "AmGTAmGA")
+	c.Check(string(swa[1].Seq), check.Equals, "AmGTACGA")
+	c.Check(swa[0].Alphabet, check.Equals, alphabet.Alphabet(meth))
+
+	_, err = smith.Align(swsa, &seq.Seq{Seq: []byte("AGTACGA")})
+	c.Check(err, check.NotNil)
+	_, err = smith.Align(&seq.Seq{Seq: []byte("AGTACGA")}, &seq.Seq{Seq: []byte("AGTACGA")})
+	c.Check(err, check.NotNil)
+
+	// Ambiguity codes of a redundant alphabet are not scored.
+	smith.Matrix = [][]int{
+		{2, -1, -1, -1, -1},
+		{-1, 2, -1, -1, -1},
+		{-1, -1, 2, -1, -1},
+		{-1, -1, -1, 2, -1},
+		{-1, -1, -1, -1, 0},
+	}
+	swsa = &seq.Seq{Seq: []byte("NNAGTACGAR"), Alphabet: alphabet.DNAredundant}
+	swsb = &seq.Seq{Seq: []byte("AGTACGA"), Alphabet: alphabet.DNAredundant}
+	swa, err = smith.Align(swsa, swsb)
+	c.Assert(err, check.IsNil)
+	c.Check(string(swa[0].Seq), check.Equals, "AGTACGA")
+	c.Check(swa[0].Offset, check.Equals, 2)
+	_, err = smith.Align(&seq.Seq{Seq: []byte("MAL"), Alphabet: alphabet.Protein}, &seq.Seq{Seq: []byte("MAL"), Alphabet: alphabet.Protein})
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestAlignCircular(c *check.C) {
+	swm := [][]int{
+		{2, -1, -1, -1, -1},
+		{-1, 2, -1, -1, -1},
+		{-1, -1, 2, -1, -1},
+		{-1, -1, -1, 2, -1},
+		{-1, -1, -1, -1, -1},
+	}
+	smith := &Aligner{Matrix: swm, GapChar: '-'}
+
+	ref := &seq.Seq{Seq: []byte("GGATTCACGTTCCAGT"), Offset: 10}
+	query := &seq.Seq{Seq: []byte("CCAGTGGATT")}
+	aln, err := smith.Align(ref, query)
+	c.Assert(err, check.IsNil)
+	c.Check(string(aln[0].Seq), check.Equals, "CCAGT")
+
+	ref.Circular = true
+	aln, err = smith.Align(ref, query)
+	c.Assert(err, check.IsNil)
+	c.Check(string(aln[0].Seq), check.Equals, "CCAGTGGATT")
+	c.Check(string(aln[1].Seq), check.Equals, "CCAGTGGATT")
+	c.Check(aln[0].Offset, check.Equals, 21)
+	c.Check(aln[1].Offset, check.Equals, 0)
 }
 
 func BenchmarkAlign(b *testing.B) {
@@ -47,7 +117,7 @@ func BenchmarkAlign(b *testing.B) {
 			{-1, -1, -1, -1, 0},
 		}
 
-		smith := &Aligner{Matrix: swm, LookUp: LookUpN, GapChar: '-'}
+		smith := &Aligner{Matrix: swm, GapChar: '-'}
 		b.StartTimer()
 		for i := 0; i < b.N; i++ {
 			smith.Align(swsa, swsb)
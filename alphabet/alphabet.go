@@ -28,17 +28,21 @@ const (
 )
 
 var (
-	N        = "acgt"
-	Npairing = [2]string{"acgtnxACGTNX-", "tgcanxTGCANX-"}
-	R        = "acgu"
-	Rpairing = [2]string{"acgunxACGUNX-", "ugcanxUGCANX-"}
-	P        = "abcdefghijklmnpqrstvxyz*"
+	N          = "acgt"
+	Nredundant = "acgtrykmswbdhvn" // IUPAC nucleotide codes.
+	Npairing   = [2]string{"acgtrykmswbdhvnxACGTRYKMSWBDHVNX-.", "tgcayrmkswvhdbnxTGCAYRMKSWVHDBNX-."}
+	R          = "acgu"
+	Rredundant = "acgurykmswbdhvn"
+	Rpairing   = [2]string{"acgurykmswbdhvnxACGURYKMSWBDHVNX-.", "ugcayrmkswvhdbnxUGCAYRMKSWVHDBNX-."}
+	P          = "abcdefghijklmnpqrstvxyz*"
 )
 
 var (
-	DNA     *Deoxyribonucleic
-	RNA     *Ribonucleic
-	Protein *Peptide
+	DNA          *Deoxyribonucleic
+	DNAredundant *Deoxyribonucleic
+	RNA          *Ribonucleic
+	RNAredundant *Ribonucleic
+	Protein      *Peptide
 )
 
 func init() {
@@ -55,13 +59,21 @@ func Init() (err error) {
 	} else if DNA, err = NewDeoxyribonucleic(N, pairing, !CaseSensitive); err != nil {
 		return
 
+	} else if DNAredundant, err = NewDeoxyribonucleic(Nredundant, pairing, !CaseSensitive); err != nil {
+		return
 	}
+	// The redundant alphabets keep the definition order of their letters so that the bases
+	// have the same indices as in the unambiguous alphabets.
+	DNAredundant.setLetters(Nredundant)
 	if pairing, err = NewPairing(Rpairing[0], Rpairing[1]); err != nil {
 		return
 	} else if RNA, err = NewRibonucleic(R, pairing, !CaseSensitive); err != nil {
 		return
 
+	} else if RNAredundant, err = NewRibonucleic(Rredundant, pairing, !CaseSensitive); err != nil {
+		return
 	}
+	RNAredundant.setLetters(Rredundant)
 	if Protein, err = NewPeptide(P, !CaseSensitive); err != nil {
 		return
 	}
@@ -74,6 +86,8 @@ type Alphabet interface {
 	IsValid(byte) bool
 	AllValid([]byte) (bool, int)
 	Len() int
+	IndexOf(byte) int
+	Letter(int) byte
 	ValidLetters() []bool
 	LetterIndex() []int
 	String() string
@@ -124,21 +138,27 @@ func NewGeneric(letters string, caseSensitive bool) (a *Generic, err error) {
 		}
 		a.letters = string(let)
 	}
+	a.setLetters(a.letters)
+
+	return
+}
+
+// Set the letters of the alphabet, with index values reflecting the order of letters.
+func (self *Generic) setLetters(letters string) {
+	self.letters = letters
 
-	for i := range a.index {
-		a.index[i] = -1
+	for i := range self.index {
+		self.index[i] = -1
 	}
 
-	for i, l := range a.letters {
-		a.valid[l] = true
-		a.index[l] = i
-		if !caseSensitive {
-			a.valid[unicode.ToUpper(l)] = true
-			a.index[unicode.ToUpper(l)] = i
+	for i, l := range self.letters {
+		self.valid[l] = true
+		self.index[l] = i
+		if !self.caseSensitive {
+			self.valid[unicode.ToUpper(l)] = true
+			self.index[unicode.ToUpper(l)] = i
 		}
 	}
-
-	return
 }
 
 // Return the number of distinct valid letters in the alphabet.
@@ -170,6 +190,11 @@ func (self *Generic) IndexOf(n byte) int {
 	return self.index[n]
 }
 
+// Return the letter with index i.
+func (self *Generic) Letter(i int) byte {
+	return self.letters[i]
+}
+
 // Return a copy of the internal []bool indicating valid letters.
 func (self *Generic) ValidLetters() (v []bool) {
 	v = make([]bool, 256)
@@ -245,6 +270,52 @@ func (self *Pairing) ComplementTable() (t []byte) {
 	return
 }
 
+// Base sets of IUPAC nucleotide codes as bit masks of A, C, G and T or U.
+var baseSets = func() (m [256]byte) {
+	for code, b := range map[byte]byte{
+		'A': 1, 'C': 2, 'G': 4, 'T': 8, 'U': 8,
+		'R': 5, 'Y': 10, 'S': 6, 'W': 9, 'K': 12, 'M': 3,
+		'B': 14, 'D': 13, 'H': 11, 'V': 7, 'N': 15,
+	} {
+		m[code], m[code+'a'-'A'] = b, b
+	}
+	return
+}()
+
+// Return the set of bases represented by the IUPAC nucleotide code l as a bit mask of A (1), C (2),
+// G (4) and T or U (8), or 0 if l is not a nucleotide code.
+func BaseSet(l byte) byte { return baseSets[l] }
+
+// Return the index of the base represented by the unambiguous nucleotide code l in the order
+// A, C, G and T or U, or -1 if l is not an unambiguous nucleotide code.
+func BaseIndex(l byte) int {
+	switch BaseSet(l) {
+	case 1:
+		return 0
+	case 2:
+		return 1
+	case 4:
+		return 2
+	case 8:
+		return 3
+	}
+	return -1
+}
+
+// Return the base set complementary to the base set m.
+func ComplementSet(m byte) byte { return m&1<<3 | m&2<<1 | m&4>>1 | m&8>>3 }
+
+// Return the number of letters of a that precede a trailing run of IUPAC nucleotide ambiguity
+// codes, so that the unambiguous letters of DNAredundant and RNAredundant number four.
+func Unambiguous(a Alphabet) (n int) {
+	for n = a.Len(); n > 0; n-- {
+		if m := BaseSet(a.Letter(n - 1)); m&(m-1) == 0 {
+			break
+		}
+	}
+	return
+}
+
 // The Nucleic type incorporates a Generic alphabet with the capacity to return a complement.
 type Nucleic struct {
 	*Generic
@@ -35,11 +35,11 @@ func (s *S) TestInterfaces(c *check.C) {
 		comp  Complementable
 	)
 
-	for _, a := range []interface{}{DNA, RNA, Protein} {
+	for _, a := range []interface{}{DNA, DNAredundant, RNA, RNAredundant, Protein} {
 		c.Check(a, check.Implements, &alpha)
 	}
 
-	for _, a := range []interface{}{DNA, RNA} {
+	for _, a := range []interface{}{DNA, DNAredundant, RNA, RNAredundant} {
 		c.Check(a, check.Implements, &comp)
 	}
 
@@ -52,7 +52,7 @@ type testAlphabets struct {
 }
 
 func (s *S) TestIsValid(c *check.C) {
-	for _, t := range []testAlphabets{{N, DNA}, {R, RNA}, {P, Protein}} {
+	for _, t := range []testAlphabets{{N, DNA}, {Nredundant, DNAredundant}, {R, RNA}, {Rredundant, RNAredundant}, {P, Protein}} {
 		for i := 0; i < 256; i++ {
 			c.Check(t.alphabet.IsValid(byte(i)), check.Equals, strings.ContainsRune(t.letters, unicode.ToUpper(rune(i))) || strings.ContainsRune(t.letters, unicode.ToLower(rune(i))))
 		}
@@ -60,7 +60,7 @@ func (s *S) TestIsValid(c *check.C) {
 }
 
 func (s *S) TestComplementOf(c *check.C) {
-	for _, t := range []testAlphabets{{N, DNA}, {R, RNA}} {
+	for _, t := range []testAlphabets{{N, DNA}, {Nredundant, DNAredundant}, {R, RNA}, {Rredundant, RNAredundant}} {
 		for i := 0; i < 256; i++ {
 			if sc, ok := t.alphabet.(Complementable).ComplementOf(byte(i)); ok {
 				dc, ok := t.alphabet.(Complementable).ComplementOf(sc)
@@ -72,7 +72,7 @@ func (s *S) TestComplementOf(c *check.C) {
 }
 
 func (s *S) TestComplementDirect(c *check.C) {
-	for _, t := range []testAlphabets{{N, DNA}, {R, RNA}} {
+	for _, t := range []testAlphabets{{N, DNA}, {Nredundant, DNAredundant}, {R, RNA}, {Rredundant, RNAredundant}} {
 		complement := t.alphabet.(Complementable).ComplementTable()
 		for i := 0; i < 256; i++ {
 			if sc := complement[i]; sc <= unicode.MaxASCII {
@@ -86,6 +86,50 @@ func (s *S) TestComplementDirect(c *check.C) {
 	}
 }
 
+func (s *S) TestComplementRedundant(c *check.C) {
+	for _, t := range []struct {
+		alphabet   Complementable
+		letters    string
+		complement string
+	}{
+		{DNAredundant, "acgtrykmswbdhvn-.ACGTRYKMSWBDHVN", "tgcayrmkswvhdbn-.TGCAYRMKSWVHDBN"},
+		{RNAredundant, "acgurykmswbdhvn-.ACGURYKMSWBDHVN", "ugcayrmkswvhdbn-.UGCAYRMKSWVHDBN"},
+	} {
+		for i := range t.letters {
+			l, ok := t.alphabet.ComplementOf(t.letters[i])
+			c.Check(ok, check.Equals, true)
+			c.Check(l, check.Equals, t.complement[i])
+		}
+	}
+}
+
+func (s *S) TestBaseSet(c *check.C) {
+	c.Check(BaseSet('A')|BaseSet('C')|BaseSet('G')|BaseSet('T'), check.Equals, BaseSet('N'))
+	c.Check(BaseSet('u'), check.Equals, BaseSet('T'))
+	c.Check(BaseSet('-'), check.Equals, byte(0))
+	c.Check(BaseSet('X'), check.Equals, byte(0))
+	for _, a := range []Complementable{DNAredundant, RNAredundant} {
+		for _, l := range []byte(Nredundant + "uU" + strings.ToUpper(Nredundant)) {
+			if !a.IsValid(l) {
+				continue
+			}
+			p, _ := a.ComplementOf(l)
+			c.Check(ComplementSet(BaseSet(l)), check.Equals, BaseSet(p), check.Commentf("%c", l))
+		}
+	}
+}
+
+func (s *S) TestBaseIndex(c *check.C) {
+	for i, l := range []byte("acgt") {
+		c.Check(BaseIndex(l), check.Equals, DNA.IndexOf(l))
+		c.Check(BaseIndex(byte(unicode.ToUpper(rune(l)))), check.Equals, i)
+	}
+	c.Check(BaseIndex('U'), check.Equals, BaseIndex('T'))
+	for _, l := range []byte("RNn-X") {
+		c.Check(BaseIndex(l), check.Equals, -1, check.Commentf("%c", l))
+	}
+}
+
 func (s *S) TestString(c *check.C) {
 	e := [...]string{"acgtACGT", "acguACGU", "*abcdefghijklmnpqrstvxyz*ABCDEFGHIJKLMNPQRSTVXYZ"}
 	for i, t := range []testAlphabets{{N, DNA}, {R, RNA}, {P, Protein}} {
@@ -93,6 +137,30 @@ func (s *S) TestString(c *check.C) {
 	}
 }
 
+func (s *S) TestLetterIndex(c *check.C) {
+	for _, a := range []Alphabet{DNA, DNAredundant, RNA, RNAredundant, Protein} {
+		for i := 0; i < a.Len(); i++ {
+			l := a.Letter(i)
+			c.Check(a.IndexOf(l), check.Equals, i)
+			c.Check(a.IndexOf(byte(unicode.ToUpper(rune(l)))), check.Equals, i)
+		}
+	}
+	c.Check(DNA.IndexOf('n'), check.Equals, -1)
+	c.Check(string([]byte{DNA.Letter(0), DNA.Letter(1), DNA.Letter(2), DNA.Letter(3)}), check.Equals, "acgt")
+	for _, t := range []struct {
+		a       Alphabet
+		letters string
+	}{
+		{DNAredundant, Nredundant},
+		{RNAredundant, Rredundant},
+	} {
+		c.Check(t.a.Len(), check.Equals, len(t.letters))
+		for i := range t.letters {
+			c.Check(t.a.Letter(i), check.Equals, t.letters[i])
+		}
+	}
+}
+
 func (s *S) TestRangeCheck(c *check.C) {
 	var err error
 	_, err = NewGeneric(string([]rune{256}), !CaseSensitive)
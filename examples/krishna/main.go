@@ -30,6 +30,7 @@ var (
 	targetName    string
 	selfCompare   bool
 	sameStrand    bool
+	softMask      bool
 	outFile       string
 	maxK          int
 	minHitLen     int
@@ -54,6 +55,7 @@ func init() {
 	flag.StringVar(&targetName, "target", "", "Filename for target sequence.")
 	flag.BoolVar(&selfCompare, "self", false, "Is this a self comparison?")
 	flag.BoolVar(&sameStrand, "same", false, "Only compare same strand")
+	flag.BoolVar(&softMask, "softmask", false, "Ignore soft-masked (lower case) regions when filtering.")
 
 	flag.StringVar(&outFile, "out", "", "File to send output to.")
 
@@ -170,6 +172,7 @@ func main() {
 		logger.Fatalf("Error: %v", err)
 	}
 	pa := pals.New(target, query, selfCompare, m, threads, tubeOffset, mem, logger)
+	pa.SkipMasked = softMask
 
 	if err = pa.Optimise(minHitLen, minId); err != nil {
 		logger.Fatalf("Error: %v", err)
@@ -7,6 +7,10 @@ import (
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/io/alignio"
 	"github.com/kortschak/BioGo/io/featio/gff"
+	"github.com/kortschak/BioGo/io/motifio"
+	"github.com/kortschak/BioGo/io/motifio/jaspar"
+	"github.com/kortschak/BioGo/io/motifio/meme"
+	"github.com/kortschak/BioGo/io/motifio/transfac"
 	"github.com/kortschak/BioGo/io/seqio/fasta"
 	"github.com/kortschak/BioGo/pwm"
 	"github.com/kortschak/BioGo/seq"
@@ -28,6 +32,7 @@ func main() {
 	inName := flag.String("in", "", "Filename for input. Defaults to stdin.")
 	matName := flag.String("mat", "", "Filename for matrix/alignment input.")
 	num := flag.Bool("num", false, "Use numerical description rather than sequence.")
+	format := flag.String("format", "", "Motif file format for matrix input: jaspar, pfm, meme or transfac.")
 	outName := flag.String("out", "", "Filename for output. Defaults to stdout.")
 	precision := flag.Int("prec", 6, "Precision for floating point output.")
 	minScore := flag.Float64("score", 0.9, "Minimum score for a hit.")
@@ -57,7 +62,35 @@ func main() {
 
 	matrix := [][]float64{}
 
-	if *num {
+	if *format != "" {
+		var mr motifio.Reader
+		switch *format {
+		case "jaspar":
+			mr, e = jaspar.NewReaderName(*matName, jaspar.Jaspar)
+		case "pfm":
+			mr, e = jaspar.NewReaderName(*matName, jaspar.Pfm)
+		case "meme":
+			mr, e = meme.NewReaderName(*matName)
+		case "transfac":
+			mr, e = transfac.NewReaderName(*matName)
+		default:
+			fmt.Fprintf(os.Stderr, "Error: unknown motif format %q.\n", *format)
+			os.Exit(0)
+		}
+		if e != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v.\n", e)
+			os.Exit(0)
+		}
+		defer mr.Close()
+
+		if motif, err := mr.Read(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v.\n", err)
+			os.Exit(0)
+		} else if matrix, err = motif.Nucleic(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v.\n", err)
+			os.Exit(0)
+		}
+	} else if *num {
 		if mf, e = os.Open(*matName); e != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v.\n", e)
 			os.Exit(0)
@@ -100,18 +133,9 @@ func main() {
 		}
 		defer min.Close()
 
-		for i := 0; i < align.Len(); i++ {
-			matrix[i] = make([]float64, 4)
-			if col, err := align.Column(i, 0); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v.\n", e)
-				os.Exit(0)
-			} else {
-				for _, v := range col {
-					if base := pwm.LookUp.ValueToCode[v]; base >= 0 {
-						matrix[i][base]++
-					}
-				}
-			}
+		if matrix, e = pwm.CountsOf(align); e != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v.\n", e)
+			os.Exit(0)
 		}
 	}
 
@@ -26,6 +26,12 @@ var (
 	defaultID        string = "nil"
 )
 
+// Truncation flags.
+const (
+	TruncatedStart = 1 << iota // The feature has been truncated at its Start.
+	TruncatedEnd               // The feature has been truncated at its End.
+)
+
 // Feature type
 type Feature struct {
 	ID          string
@@ -41,6 +47,7 @@ type Feature struct {
 	Frame       int8
 	Strand      int8
 	Moltype     bio.Moltype
+	Truncated   int8 // Truncation flags.
 	Meta        interface{}
 }
 
@@ -0,0 +1,115 @@
+package logo
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"math"
+)
+
+// A point in the unit square with y increasing upwards.
+type point struct{ x, y float64 }
+
+// A ring is a closed polygon.
+type ring []point
+
+// A shape is a set of rings filled using the even-odd rule.
+type shape []ring
+
+// A glyph is the union of its shapes.
+type glyph []shape
+
+// Glyph outlines for nucleotide letters. Letters without a glyph are drawn as solid blocks.
+var glyphs = map[byte]glyph{
+	'A': {
+		{
+			{{0, 0}, {0.4, 1}, {0.6, 1}, {1, 0}, {0.78, 0}, {0.68, 0.28}, {0.32, 0.28}, {0.22, 0}},
+			{{0.38, 0.44}, {0.62, 0.44}, {0.5, 0.78}},
+		},
+	},
+	'C': {
+		{arc(0.5, 0.5, 0.5, 0.5, 0.3, 0.32, 40, 320)},
+	},
+	'G': {
+		{arc(0.5, 0.5, 0.5, 0.5, 0.3, 0.32, 40, 350)},
+		{{{0.55, 0.36}, {1, 0.36}, {1, 0.5}, {0.55, 0.5}}},
+		{{{0.82, 0.1}, {1, 0.1}, {1, 0.5}, {0.82, 0.5}}},
+	},
+	'T': {
+		{{{0, 1}, {1, 1}, {1, 0.82}, {0.6, 0.82}, {0.6, 0}, {0.4, 0}, {0.4, 0.82}, {0, 0.82}}},
+	},
+	'U': {
+		{{{0, 0.4}, {0.2, 0.4}, {0.2, 1}, {0, 1}}},
+		{{{0.8, 0.4}, {1, 0.4}, {1, 1}, {0.8, 1}}},
+		{arc(0.5, 0.4, 0.5, 0.4, 0.3, 0.22, 180, 360)},
+	},
+}
+
+var block = glyph{{{{0, 0}, {1, 0}, {1, 1}, {0, 1}}}}
+
+// Return the glyph for letter l.
+func glyphOf(l byte) glyph {
+	if g, ok := glyphs[l]; ok {
+		return g
+	}
+	return block
+}
+
+// Return a ring describing an elliptical band centred on (cx, cy) with outer radii rx and ry
+// and inner radii ix and iy, between angles a0 and a1 degrees measured anticlockwise from the
+// positive x axis.
+func arc(cx, cy, rx, ry, ix, iy, a0, a1 float64) (r ring) {
+	const steps = 32
+	for i := 0; i <= steps; i++ {
+		a := (a0 + (a1-a0)*float64(i)/steps) * math.Pi / 180
+		r = append(r, point{cx + rx*math.Cos(a), cy + ry*math.Sin(a)})
+	}
+	for i := steps; i >= 0; i-- {
+		a := (a0 + (a1-a0)*float64(i)/steps) * math.Pi / 180
+		r = append(r, point{cx + ix*math.Cos(a), cy + iy*math.Sin(a)})
+	}
+	return
+}
+
+// Return whether p lies within the ring.
+func (self ring) contains(p point) (in bool) {
+	for i, j := 0, len(self)-1; i < len(self); j, i = i, i+1 {
+		a, b := self[i], self[j]
+		if (a.y > p.y) != (b.y > p.y) && p.x < (b.x-a.x)*(p.y-a.y)/(b.y-a.y)+a.x {
+			in = !in
+		}
+	}
+	return
+}
+
+// Return whether p lies within the shape.
+func (self shape) contains(p point) (in bool) {
+	for _, r := range self {
+		if r.contains(p) {
+			in = !in
+		}
+	}
+	return
+}
+
+// Return whether p lies within the glyph.
+func (self glyph) contains(p point) bool {
+	for _, s := range self {
+		if s.contains(p) {
+			return true
+		}
+	}
+	return false
+}
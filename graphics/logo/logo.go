@@ -0,0 +1,393 @@
+// Package to render sequence logos from position weight matrices and alignments
+package logo
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"fmt"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/graphics/color"
+	"github.com/kortschak/BioGo/pwm"
+	"github.com/kortschak/BioGo/seq"
+	"image"
+	imagecolor "image/color"
+	"image/png"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Logo types.
+const (
+	Bits        Type = iota // Stack heights reflect information content.
+	Probability             // Stack heights are one and letter heights reflect frequency.
+)
+
+type Type int8
+
+// A Scheme maps upper case letters to colors. imagecolor.Color is an alias to the core library
+// image/color package to avoid a name conflict.
+type Scheme map[byte]imagecolor.Color
+
+var (
+	// Nucleotide colors nucleotides in the conventional manner.
+	Nucleotide = Scheme{
+		'A': color.HSVA{H: 120, S: 1, V: 0.7, A: 1},
+		'C': color.HSVA{H: 220, S: 1, V: 0.9, A: 1},
+		'G': color.HSVA{H: 40, S: 1, V: 1, A: 1},
+		'T': color.HSVA{H: 0, S: 1, V: 0.85, A: 1},
+		'U': color.HSVA{H: 0, S: 1, V: 0.85, A: 1},
+	}
+
+	// Protein colors amino acids by their chemical properties.
+	Protein = func() (s Scheme) {
+		s = make(Scheme)
+		for _, g := range []struct {
+			letters string
+			color   color.HSVA
+		}{
+			{"GSTYC", color.HSVA{H: 120, S: 1, V: 0.7, A: 1}},  // Polar.
+			{"NQ", color.HSVA{H: 300, S: 0.6, V: 0.7, A: 1}},   // Neutral.
+			{"KRH", color.HSVA{H: 220, S: 1, V: 0.9, A: 1}},    // Basic.
+			{"DE", color.HSVA{H: 0, S: 1, V: 0.85, A: 1}},      // Acidic.
+			{"AVLIPWFM", color.HSVA{H: 0, S: 0, V: 0.1, A: 1}}, // Hydrophobic.
+		} {
+			for i := range g.letters {
+				s[g.letters[i]] = g.color
+			}
+		}
+		return
+	}()
+
+	DefaultColor imagecolor.Color = color.HSVA{H: 0, S: 0, V: 0.5, A: 1} // Color of letters not in a Scheme.
+)
+
+// A Logo holds the letter frequencies at each position of a motif or alignment.
+type Logo struct {
+	Letters    string      // Letters in the order of the columns of Freqs.
+	Freqs      [][]float64 // Letter frequencies at each position.
+	Counts     []float64   // Number of observations at each position, nil or zero if not known.
+	Type       Type
+	Scheme     Scheme
+	ErrorBars  bool             // Draw error bars on Bits logos where Counts is known.
+	Background imagecolor.Color // Background color, nil for none in SVG and white in PNG.
+}
+
+// Return a new Logo with the given letters and frequencies.
+func New(letters string, freqs [][]float64, counts []float64) (l *Logo, err error) {
+	if len(letters) < 2 {
+		return nil, bio.NewError("Too few letters", 0, letters)
+	}
+	for i, row := range freqs {
+		if len(row) != len(letters) {
+			return nil, bio.NewError("Frequency row length does not match letters", 0, i, letters)
+		}
+	}
+	if counts != nil && len(counts) != len(freqs) {
+		return nil, bio.NewError("Counts length does not match frequencies", 0, counts)
+	}
+
+	return &Logo{
+		Letters: letters,
+		Freqs:   freqs,
+		Counts:  counts,
+		Type:    Bits,
+		Scheme:  Nucleotide,
+	}, nil
+}
+
+// Return a new Logo from the probability matrix of a PWM.
+func FromPWM(m *pwm.PWM) (l *Logo, err error) {
+	probs := m.Probs()
+	if probs == nil {
+		return nil, bio.NewError("PWM has no probability matrix", 0, m)
+	}
+	return New(string(bio.N[:4]), probs, nil)
+}
+
+// Return a new Logo from a Motif. Counts are taken from the matrix if it holds counts.
+func FromMotif(m *pwm.Motif) (l *Logo, err error) {
+	var counts []float64
+	if m.Type == pwm.Counts {
+		counts = make([]float64, len(m.Matrix))
+		for i, row := range m.Matrix {
+			for _, c := range row {
+				counts[i] += c
+			}
+		}
+	}
+	return New(strings.ToUpper(m.Letters), m.Probs(), counts)
+}
+
+// Return a new Logo from the columns of an alignment. Letters are counted without regard to
+// case and characters not in letters, including gaps, are ignored.
+func FromAlignment(a seq.Alignment, letters string) (l *Logo, err error) {
+	if len(a) == 0 {
+		return nil, bio.NewError("Empty alignment", 0, a)
+	}
+	letters = strings.ToUpper(letters)
+	var index [256]int
+	for i := range index {
+		index[i] = -1
+	}
+	for i := range letters {
+		index[letters[i]] = i
+		index[strings.ToLower(letters[i : i+1])[0]] = i
+	}
+
+	start := a.Start()
+	freqs := make([][]float64, a.Len())
+	counts := make([]float64, a.Len())
+	for i := range freqs {
+		freqs[i] = make([]float64, len(letters))
+	}
+	for _, s := range a {
+		for i, c := range s.Seq {
+			if j := index[c]; j >= 0 {
+				freqs[s.Offset-start+i][j]++
+				counts[s.Offset-start+i]++
+			}
+		}
+	}
+	for i, row := range freqs {
+		for j := range row {
+			if counts[i] > 0 {
+				row[j] /= counts[i]
+			}
+		}
+	}
+
+	return New(letters, freqs, counts)
+}
+
+// Return the maximum stack height of the Logo.
+func (self *Logo) Max() float64 {
+	if self.Type == Probability {
+		return 1
+	}
+	return math.Log2(float64(len(self.Letters)))
+}
+
+// Return the information content in bits of position i and an estimate of its standard error.
+// If the number of observations at the position is known, the information content is corrected
+// for small sample size by the approximation of Schneider et al. (1986).
+func (self *Logo) InformationContent(i int) (ic, se float64) {
+	var h, h2 float64
+	for _, f := range self.Freqs[i] {
+		if f > 0 {
+			l := math.Log2(f)
+			h -= f * l
+			h2 += f * l * l
+		}
+	}
+	ic = math.Log2(float64(len(self.Letters))) - h
+	if self.Counts != nil && self.Counts[i] > 0 {
+		n := self.Counts[i]
+		ic -= float64(len(self.Letters)-1) / (2 * math.Ln2 * n)
+		se = math.Sqrt(math.Max(0, h2-h*h) / n)
+	}
+
+	return math.Max(0, ic), se
+}
+
+// A box is a letter placed in a stack, with extents in stack height units.
+type box struct {
+	letter byte
+	bottom float64
+	top    float64
+}
+
+type byHeight []box
+
+func (self byHeight) Len() int { return len(self) }
+func (self byHeight) Less(i, j int) bool {
+	return self[i].top-self[i].bottom < self[j].top-self[j].bottom
+}
+func (self byHeight) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+// Return the letter boxes of position i, with the tallest letter on top, and the half length
+// of the position's error bar.
+func (self *Logo) stack(i int) (boxes []box, bar float64) {
+	scale := 1.
+	if self.Type == Bits {
+		var se float64
+		scale, se = self.InformationContent(i)
+		if self.ErrorBars {
+			bar = 2 * se
+		}
+	}
+	for j, f := range self.Freqs[i] {
+		if h := f * scale; h > 0 {
+			l := self.Letters[j]
+			if 'a' <= l && l <= 'z' {
+				l -= 'a' - 'A'
+			}
+			boxes = append(boxes, box{letter: l, top: h})
+		}
+	}
+	sort.Stable(byHeight(boxes))
+	var y float64
+	for j := range boxes {
+		h := boxes[j].top
+		boxes[j].bottom, boxes[j].top = y, y+h
+		y += h
+	}
+
+	return
+}
+
+func (self *Logo) color(l byte) imagecolor.Color {
+	if c, ok := self.Scheme[l]; ok {
+		return c
+	}
+	return DefaultColor
+}
+
+// Return an image of the Logo with the given dimensions.
+func (self *Logo) Image(width, height int) (img *image.RGBA, err error) {
+	if width < len(self.Freqs) || height < 1 {
+		return nil, bio.NewError("Image too small", 0, width, height)
+	}
+
+	img = image.NewRGBA(image.Rect(0, 0, width, height))
+	bg := self.Background
+	if bg == nil {
+		bg = imagecolor.White
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, bg)
+		}
+	}
+
+	var (
+		colWidth = float64(width) / float64(len(self.Freqs))
+		yScale   = float64(height) / self.Max()
+	)
+	for i := range self.Freqs {
+		boxes, bar := self.stack(i)
+		x0, x1 := float64(i)*colWidth, float64(i+1)*colWidth
+		for _, b := range boxes {
+			var (
+				g      = glyphOf(b.letter)
+				c      = self.color(b.letter)
+				y0, y1 = float64(height) - b.top*yScale, float64(height) - b.bottom*yScale
+			)
+			for py := int(y0); py < int(math.Ceil(y1)) && py < height; py++ {
+				for px := int(x0); px < int(math.Ceil(x1)) && px < width; px++ {
+					p := point{
+						x: (float64(px) + 0.5 - x0) / (x1 - x0),
+						y: 1 - (float64(py)+0.5-y0)/(y1-y0),
+					}
+					if p.x >= 0 && p.x <= 1 && p.y >= 0 && p.y <= 1 && g.contains(p) {
+						img.Set(px, py, c)
+					}
+				}
+			}
+		}
+		if bar > 0 {
+			var top float64
+			if len(boxes) > 0 {
+				top = boxes[len(boxes)-1].top
+			}
+			cx := int((x0 + x1) / 2)
+			hi, lo := float64(height)-(top+bar)*yScale, float64(height)-(top-bar)*yScale
+			for py := int(math.Max(0, hi)); py <= int(lo) && py < height; py++ {
+				img.Set(cx, py, imagecolor.Black)
+			}
+			for px := int(x0 + colWidth/4); px <= int(x1-colWidth/4) && px < width; px++ {
+				for _, py := range []float64{hi, lo} {
+					if py >= 0 && int(py) < height {
+						img.Set(px, int(py), imagecolor.Black)
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// Write the Logo as a PNG image with the given dimensions.
+func (self *Logo) WritePNG(w io.Writer, width, height int) (err error) {
+	var img *image.RGBA
+	if img, err = self.Image(width, height); err != nil {
+		return
+	}
+	return png.Encode(w, img)
+}
+
+func hex(c imagecolor.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// Write the Logo as an SVG image with the given dimensions, returning the number of bytes
+// written and any error.
+func (self *Logo) WriteSVG(w io.Writer, width, height int) (n int, err error) {
+	if width < 1 || height < 1 {
+		return 0, bio.NewError("Image too small", 0, width, height)
+	}
+
+	b := []byte(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		width, height, width, height))
+	if self.Background != nil {
+		b = append(b, fmt.Sprintf(`<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, hex(self.Background))...)
+	}
+
+	var (
+		colWidth = float64(width) / float64(len(self.Freqs))
+		yScale   = float64(height) / self.Max()
+	)
+	for i := range self.Freqs {
+		boxes, bar := self.stack(i)
+		x0 := float64(i) * colWidth
+		for _, bx := range boxes {
+			y0, h := float64(height)-bx.top*yScale, (bx.top-bx.bottom)*yScale
+			for _, s := range glyphOf(bx.letter) {
+				b = append(b, `<path fill-rule="evenodd" fill="`+hex(self.color(bx.letter))+`" d="`...)
+				for _, r := range s {
+					for k, p := range r {
+						if k == 0 {
+							b = append(b, 'M')
+						} else {
+							b = append(b, 'L')
+						}
+						b = append(b, fmt.Sprintf("%.2f %.2f ", x0+p.x*colWidth, y0+(1-p.y)*h)...)
+					}
+					b = append(b, 'Z')
+				}
+				b = append(b, "\"/>\n"...)
+			}
+		}
+		if bar > 0 {
+			var top float64
+			if len(boxes) > 0 {
+				top = boxes[len(boxes)-1].top
+			}
+			cx := x0 + colWidth/2
+			hi, lo := float64(height)-(top+bar)*yScale, float64(height)-(top-bar)*yScale
+			b = append(b, fmt.Sprintf(`<path stroke="black" fill="none" d="M%.2f %.2f L%.2f %.2f M%.2f %.2f L%.2f %.2f M%.2f %.2f L%.2f %.2f"/>`+"\n",
+				cx, hi, cx, lo,
+				x0+colWidth/4, hi, x0+3*colWidth/4, hi,
+				x0+colWidth/4, lo, x0+3*colWidth/4, lo)...)
+		}
+	}
+	b = append(b, "</svg>\n"...)
+
+	return w.Write(b)
+}
@@ -0,0 +1,138 @@
+package logo
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"github.com/kortschak/BioGo/pwm"
+	"github.com/kortschak/BioGo/seq"
+	imagecolor "image/color"
+	"image/png"
+	check "launchpad.net/gocheck"
+	"math"
+	"strings"
+	"testing"
+)
+
+// Checkers
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+func (s *S) TestFromAlignment(c *check.C) {
+	a := seq.Alignment{
+		seq.New("a", []byte("AACG-"), nil),
+		seq.New("b", []byte("aCCGT"), nil),
+		seq.New("c", []byte("ATCGT"), nil),
+		seq.New("d", []byte("AGCTT"), nil),
+	}
+	l, err := FromAlignment(a, "acgt")
+	c.Assert(err, check.IsNil)
+	c.Check(l.Letters, check.Equals, "ACGT")
+	c.Check(l.Counts, check.DeepEquals, []float64{4, 4, 4, 4, 3})
+	c.Check(l.Freqs[0], check.DeepEquals, []float64{1, 0, 0, 0})
+	c.Check(l.Freqs[1], check.DeepEquals, []float64{0.25, 0.25, 0.25, 0.25})
+
+	ic, se := l.InformationContent(0)
+	c.Check(math.Abs(ic-(2-3/(8*math.Ln2))) < 1e-12, check.Equals, true)
+	c.Check(se, check.Equals, 0.)
+	ic, _ = l.InformationContent(1)
+	c.Check(ic, check.Equals, 0.)
+	_, se = l.InformationContent(3)
+	c.Check(se > 0, check.Equals, true)
+
+	l.Counts = nil
+	ic, _ = l.InformationContent(0)
+	c.Check(ic, check.Equals, 2.)
+}
+
+func (s *S) TestStack(c *check.C) {
+	l, err := New("ACGT", [][]float64{{0.5, 0.25, 0, 0.25}}, nil)
+	c.Assert(err, check.IsNil)
+	boxes, bar := l.stack(0)
+	c.Check(bar, check.Equals, 0.)
+	c.Assert(len(boxes), check.Equals, 3)
+	c.Check(boxes[2].letter, check.Equals, byte('A'))
+	c.Check(boxes[2].top, check.Equals, 0.5)
+	c.Check(boxes[2].bottom, check.Equals, 0.25)
+
+	l.Type = Probability
+	boxes, _ = l.stack(0)
+	c.Check(boxes[2].top, check.Equals, 1.)
+	c.Check(l.Max(), check.Equals, 1.)
+
+	_, err = New("ACGT", [][]float64{{1, 0, 0}}, nil)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestRender(c *check.C) {
+	m, err := pwm.NewFromCounts([][]float64{{10, 0, 0, 0}, {0, 0, 0, 10}, {5, 5, 0, 0}}, 0, nil)
+	c.Assert(err, check.IsNil)
+	l, err := FromPWM(m)
+	c.Assert(err, check.IsNil)
+	l.ErrorBars = true
+
+	img, err := l.Image(90, 60)
+	c.Assert(err, check.IsNil)
+	c.Check(img.Bounds().Dx(), check.Equals, 90)
+	// The left leg of the A in the first column.
+	r, g, b, _ := img.At(3, 58).RGBA()
+	er, eg, eb, _ := imagecolor.RGBAModel.Convert(Nucleotide['A']).RGBA()
+	c.Check([]uint32{r, g, b}, check.DeepEquals, []uint32{er, eg, eb})
+	// The centre of the A's crossbar hole is background.
+	r, g, b, _ = img.At(15, 20).RGBA()
+	c.Check([]uint32{r, g, b}, check.DeepEquals, []uint32{0xffff, 0xffff, 0xffff})
+	// The top of the T in the second column.
+	r, g, b, _ = img.At(45, 1).RGBA()
+	er, eg, eb, _ = imagecolor.RGBAModel.Convert(Nucleotide['T']).RGBA()
+	c.Check([]uint32{r, g, b}, check.DeepEquals, []uint32{er, eg, eb})
+
+	var buf bytes.Buffer
+	c.Assert(l.WritePNG(&buf, 90, 60), check.IsNil)
+	p, err := png.Decode(&buf)
+	c.Assert(err, check.IsNil)
+	c.Check(p.Bounds(), check.Equals, img.Bounds())
+
+	buf.Reset()
+	_, err = l.WriteSVG(&buf, 90, 60)
+	c.Assert(err, check.IsNil)
+	svg := buf.String()
+	c.Check(strings.HasPrefix(svg, "<svg "), check.Equals, true)
+	c.Check(strings.HasSuffix(svg, "</svg>\n"), check.Equals, true)
+	c.Check(strings.Count(svg, `fill="`+hex(Nucleotide['A'])+`"`), check.Equals, 2)
+	c.Check(strings.Count(svg, `fill="`+hex(Nucleotide['C'])+`"`), check.Equals, 1)
+	c.Check(strings.Contains(svg, "stroke"), check.Equals, false)
+
+	_, err = l.Image(2, 60)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestErrorBars(c *check.C) {
+	l, err := New("ACGT", [][]float64{{0.75, 0.25, 0, 0}}, []float64{4})
+	c.Assert(err, check.IsNil)
+	l.ErrorBars = true
+	_, bar := l.stack(0)
+	h := -0.75*math.Log2(0.75) - 0.25*math.Log2(0.25)
+	h2 := 0.75*math.Log2(0.75)*math.Log2(0.75) + 0.25*4
+	c.Check(math.Abs(bar-2*math.Sqrt((h2-h*h)/4)) < 1e-12, check.Equals, true)
+
+	var buf bytes.Buffer
+	_, err = l.WriteSVG(&buf, 20, 40)
+	c.Assert(err, check.IsNil)
+	c.Check(strings.Contains(buf.String(), "stroke"), check.Equals, true)
+}
@@ -20,10 +20,13 @@ package kmerindex
 
 import (
 	"fmt"
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/seq"
+	"github.com/kortschak/BioGo/seq/packed"
 	"github.com/kortschak/BioGo/util"
 	"math"
+	"unicode"
 )
 
 var Debug = false // Set Debug to true to prevent recovering from panics in ForEachKmer f Eval function.
@@ -38,29 +41,55 @@ type Index struct {
 	finger  []Kmer
 	pos     []int
 	Seq     *seq.Seq
+	packed  *packed.Seq
 	k       int
 	kMask   Kmer
+	lookUp  []int
+	scan    []int // Letter index used to read kmers from sequences.
+	masked  bool
+	letters [4]byte
 	indexed bool
 }
 
 var (
-	lookUp     util.CTL
 	MinKmerLen = 4 // default minimum
-)
 
-func init() {
-	m := make(map[int]int)
+	defaultLookUp  = letterIndex(alphabet.DNA)
+	defaultLetters = lettersOf(alphabet.DNA)
+)
 
-	for i, v := range bio.N {
-		m[int(v)] = i % 4
+// Return the first four letters of an alphabet in index order, in upper case if the alphabet
+// does not distinguish case.
+func lettersOf(a alphabet.Alphabet) (l [4]byte) {
+	for i := range l {
+		l[i] = a.Letter(i)
+		if u := byte(unicode.ToUpper(rune(l[i]))); a.IndexOf(u) == i {
+			l[i] = u
+		}
 	}
-
-	lookUp = *util.NewCTL(m)
+	return
 }
 
-// Create a new Kmer Index with a word size k based on sequence
+// Create a new Kmer Index with a word size k based on sequence. Letters are packed into Kmers
+// by their index in the sequence's alphabet, which must have four letters, or four letters
+// followed by nucleotide ambiguity codes as for alphabet.DNAredundant. Letters not in the alphabet
+// and ambiguity codes break kmers. Kmers spanning the origin of a circular sequence are indexed.
 func New(k int, sequence *seq.Seq) (i *Index, err error) {
+	return newIndex(k, sequence, false)
+}
+
+// Create a new Kmer Index as New, but skipping soft-masked kmers. Kmers containing lower case
+// letters whose case is not significant in the sequence's alphabet are neither indexed nor
+// passed to the Eval function by ForEachKmerOf and ForEachKmerOfPacked.
+func NewMasked(k int, sequence *seq.Seq) (i *Index, err error) {
+	return newIndex(k, sequence, true)
+}
+
+func newIndex(k int, sequence *seq.Seq, skipMasked bool) (i *Index, err error) {
+	a := sequence.GetAlphabet()
 	switch {
+	case a.Len() != 4 && alphabet.Unambiguous(a) != 4:
+		return nil, bio.NewError("sequence alphabet does not have four letters", 0, a)
 	case k > MaxKmerLen:
 		return nil, bio.NewError("k greater than MaxKmerLen", 0, k, MaxKmerLen)
 	case k < MinKmerLen:
@@ -74,20 +103,95 @@ func New(k int, sequence *seq.Seq) (i *Index, err error) {
 		k:       k,
 		kMask:   Kmer(util.Pow4(k) - 1),
 		Seq:     sequence,
+		lookUp:  letterIndex(a),
+		letters: lettersOf(a),
 		indexed: false,
 	}
+	i.scan = i.lookUp
+	if skipMasked {
+		i.scan, i.masked = maskedLookUp(a), true
+	}
 
 	i.buildKmerTable()
 
 	return
 }
 
+// Return a letter index for the first four letters of a. Letters with a higher index, such
+// as the ambiguity codes of a redundant alphabet, map to -1.
+func letterIndex(a alphabet.Alphabet) (l []int) {
+	l = a.LetterIndex()
+	for c, i := range l {
+		if i >= 4 {
+			l[c] = -1
+		}
+	}
+	return
+}
+
+// Return a letter index for a that excludes soft-masked letters.
+func maskedLookUp(a alphabet.Alphabet) (l []int) {
+	l = letterIndex(a)
+	for c := range l {
+		if seq.IsSoftMasked(a, byte(c)) {
+			l[c] = -1
+		}
+	}
+	return
+}
+
+// Create a new Kmer Index with a word size k based on the packed sequence. Kmers are taken
+// directly from the packed representation without unpacking the sequence, so a sequence mapped
+// from disk is not copied. The Seq field of the returned Index is nil.
+func NewPacked(k int, sequence *packed.Seq) (i *Index, err error) {
+	a := sequence.Alphabet()
+	switch {
+	case k > MaxKmerLen:
+		return nil, bio.NewError("k greater than MaxKmerLen", 0, k, MaxKmerLen)
+	case k < MinKmerLen:
+		return nil, bio.NewError("k less than MinKmerLen", 0, k, MinKmerLen)
+	case k+1 > sequence.Len():
+		return nil, bio.NewError("sequence shorter than k+1-mer length", 0, k+1, sequence.Len())
+	}
+
+	i = &Index{
+		finger:  make([]Kmer, util.Pow4(k)+1),
+		k:       k,
+		kMask:   Kmer(util.Pow4(k) - 1),
+		packed:  sequence,
+		lookUp:  letterIndex(a),
+		letters: lettersOf(a),
+		indexed: false,
+	}
+	i.scan = i.lookUp
+
+	i.buildKmerTable()
+
+	return
+}
+
+// Return the length of the indexed sequence.
+func (self *Index) seqLen() int {
+	if self.packed != nil {
+		return self.packed.Len()
+	}
+	return self.Seq.Len()
+}
+
+// Apply f to all the kmers of the indexed sequence.
+func (self *Index) forEachKmer(f Eval) error {
+	if self.packed != nil {
+		return self.ForEachKmerOfPacked(self.packed, 0, self.packed.Len(), f)
+	}
+	return self.ForEachKmerOf(self.Seq, 0, self.Seq.Len(), f)
+}
+
 // Build the table of Kmer frequencies - called by New
 func (self *Index) buildKmerTable() {
 	incrementFinger := func(index *Index, _, kmer int) {
 		index.finger[kmer]++
 	}
-	self.ForEachKmerOf(self.Seq, 0, self.Seq.Len(), incrementFinger)
+	self.forEachKmer(incrementFinger)
 }
 
 // Build the Kmer position table destructively replacing Kmer frequencies
@@ -101,8 +205,12 @@ func (self *Index) Build() {
 		index.pos[index.finger[kmer]] = position
 		index.finger[kmer]++
 	}
-	self.pos = make([]int, self.Seq.Len()-self.k+1)
-	self.ForEachKmerOf(self.Seq, 0, self.Seq.Len(), locatePositions)
+	n := self.seqLen() - self.k + 1
+	if self.Seq != nil && self.Seq.Circular {
+		n = self.Seq.Len()
+	}
+	self.pos = make([]int, n)
+	self.forEachKmer(locatePositions)
 
 	self.indexed = true
 }
@@ -174,7 +282,7 @@ func (self *Index) NormalisedKmerFrequencies() (map[Kmer]float64, bool) {
 
 	m := map[Kmer]float64{}
 
-	l := float64(self.Seq.Len())
+	l := float64(self.seqLen())
 	for i, f := range self.finger {
 		if f > 0 {
 			m[Kmer(i)] = float64(f) / l
@@ -223,7 +331,9 @@ func (self *Index) StringKmerIndex() (map[string][]int, bool) {
 // errors should be handled through a panic which will be recovered by ForEachKmerOf
 type Eval func(index *Index, j, kmer int)
 
-// Applies the f Eval func to all kmers in s from start to end. Returns any panic raised by f as an error.
+// Applies the f Eval func to all kmers in s from start to end. Letters of s are packed using the
+// alphabet of the index. If s is circular and end is the length of s, kmers spanning the origin
+// are included. Returns any panic raised by f as an error.
 func (self *Index) ForEachKmerOf(s *seq.Seq, start, end int, f Eval) (err error) {
 	defer func() {
 		if !Debug {
@@ -241,10 +351,21 @@ func (self *Index) ForEachKmerOf(s *seq.Seq, start, end int, f Eval) (err error)
 	high := 0
 	var currentBase int
 
+	n, stop := s.Len(), end
+	if s.Circular && end == n {
+		stop = end + self.k - 1
+	}
+	at := func(i int) byte {
+		if i >= n {
+			i -= n
+		}
+		return s.Seq[i]
+	}
+
 	// Preload the first k-1 bases of the first well defined k-mer or set high to the next position
 	basePosition := start
 	for ; basePosition < start+self.k-1; basePosition++ {
-		currentBase = lookUp.ValueToCode[s.Seq[basePosition]]
+		currentBase = self.scan[at(basePosition)]
 		if currentBase >= 0 {
 			kmer = (kmer << 2) | Kmer(currentBase)
 		} else {
@@ -254,8 +375,8 @@ func (self *Index) ForEachKmerOf(s *seq.Seq, start, end int, f Eval) (err error)
 	}
 
 	// Call f(position, kmer) for each of the next well defined k-mers
-	for position := basePosition - self.k + 1; basePosition < end; position++ {
-		currentBase = lookUp.ValueToCode[s.Seq[basePosition]]
+	for position := basePosition - self.k + 1; basePosition < stop; position++ {
+		currentBase = self.scan[at(basePosition)]
 		basePosition++
 		if currentBase >= 0 {
 			kmer = ((kmer << 2) | Kmer(currentBase)) & self.kMask
@@ -271,16 +392,52 @@ func (self *Index) ForEachKmerOf(s *seq.Seq, start, end int, f Eval) (err error)
 	return
 }
 
+// Applies the f Eval func to all kmers in the packed sequence s from start to end. Kmers are taken
+// directly from the packed representation, so the alphabet of s must have the same letter order as
+// the alphabet of the index. Returns any panic raised by f as an error.
+func (self *Index) ForEachKmerOfPacked(s *packed.Seq, start, end int, f Eval) (err error) {
+	for i, l := range self.letters {
+		if s.Alphabet().IndexOf(l) != i {
+			return bio.NewError("packed sequence alphabet does not match index", 0, s.Alphabet())
+		}
+	}
+
+	defer func() {
+		if !Debug {
+			if r := recover(); r != nil {
+				var ok bool
+				err, ok = r.(error)
+				if !ok {
+					err = bio.NewError(fmt.Sprintf("pkg: %v", r), 1, r)
+				}
+			}
+		}
+	}()
+
+	kmers := s.Kmers
+	if self.masked {
+		kmers = s.UnmaskedKmers
+	}
+	return kmers(self.k, start, end, func(position int, kmer uint64) {
+		f(self, position, int(kmer))
+	})
+}
+
 // Return the Kmer length of the Index.
 func (self *Index) GetK() int {
 	return self.k
 }
 
-// Returns a pointer to the indexed seq.Seq.
+// Returns a pointer to the indexed seq.Seq, or nil if the Index was created by NewPacked.
 func (self *Index) GetSeq() *seq.Seq {
 	return self.Seq
 }
 
+// Returns a pointer to the indexed packed.Seq, or nil if the Index was created by New.
+func (self *Index) GetPacked() *packed.Seq {
+	return self.packed
+}
+
 // Returns the value of the finger slice at p. This signifies the absolute kmer frequency of the Kmer(p)
 // if called before Build() and points to the relevant position lookup if called after.
 func (self *Index) FingerAt(p int) int {
@@ -293,19 +450,23 @@ func (self *Index) PosAt(p int) int {
 	return self.pos[p]
 }
 
-// Convert a Kmer into a string of bases
+// Convert a Kmer into a string of letters of the index's alphabet
 func (self *Index) Stringify(kmer Kmer) string {
-	return Stringify(self.k, kmer)
+	return stringify(self.letters, self.k, kmer)
 }
 
 // Convert a string of bases into a len k Kmer, returns an error if string length does not match k
 func KmerOf(k int, kmertext string) (kmer Kmer, err error) {
+	return kmerOf(defaultLookUp, k, kmertext)
+}
+
+func kmerOf(lookUp []int, k int, kmertext string) (kmer Kmer, err error) {
 	if len(kmertext) != k {
 		return 0, bio.NewError("Sequence length does not match Kmer length", 0, k, kmertext)
 	}
 
-	for _, v := range kmertext {
-		x := lookUp.ValueToCode[v]
+	for i := 0; i < len(kmertext); i++ {
+		x := lookUp[kmertext[i]]
 		if x < 0 {
 			return 0, bio.NewError("Kmer contains illegal character", 0, kmertext)
 		}
@@ -332,10 +493,14 @@ func GCof(k int, kmer Kmer) float64 {
 
 // Convert a Kmer into a string of bases
 func Stringify(k int, kmer Kmer) string {
+	return stringify(defaultLetters, k, kmer)
+}
+
+func stringify(letters [4]byte, k int, kmer Kmer) string {
 	kmertext := make([]byte, k)
 
 	for i := k - 1; i >= 0; i, kmer = i-1, kmer>>2 {
-		kmertext[i] = bio.N[kmer&3]
+		kmertext[i] = letters[kmer&3]
 	}
 
 	return string(kmertext)
@@ -355,21 +520,10 @@ func ComplementOf(k int, kmer Kmer) (c Kmer) {
 	return
 }
 
-// Convert a string of bases into a Kmer, returns an error if string length does not match word length
+// Convert a string of letters of the index's alphabet into a Kmer, returns an error if string
+// length does not match word length
 func (self *Index) KmerOf(kmertext string) (kmer Kmer, err error) {
-	if len(kmertext) != self.k {
-		return 0, bio.NewError("Sequence length does not match Kmer length", 0, self.k, kmertext)
-	}
-
-	for _, v := range kmertext {
-		x := lookUp.ValueToCode[v]
-		if x < 0 {
-			return 0, bio.NewError("Kmer contains illegal character", 0, kmertext)
-		}
-		kmer = (kmer << 2) | Kmer(x)
-	}
-
-	return
+	return kmerOf(self.lookUp, self.k, kmertext)
 }
 
 // Return the Euclidian distance between two sequences measured by abolsolute kmer frequencies.
@@ -411,7 +565,7 @@ func (self *Index) Check() (ok bool, found int) {
 		}
 	}
 
-	if err := self.ForEachKmerOf(self.Seq, 0, self.Seq.Len(), f); err != nil {
+	if err := self.forEachKmer(f); err != nil {
 		ok = false
 	}
 
@@ -16,7 +16,9 @@ package kmerindex
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/seq"
+	"github.com/kortschak/BioGo/seq/packed"
 	"github.com/kortschak/BioGo/util"
 	check "launchpad.net/gocheck"
 	"math/rand"
@@ -129,6 +131,102 @@ func (s *S) TestKmerPositionsString(c *check.C) {
 	}
 }
 
+func (s *S) TestKmerAlphabet(c *check.C) {
+	_, err := New(4, &seq.Seq{Seq: []byte("ACDEFGHIKLMNPQRSTVWY"), Alphabet: alphabet.Protein})
+	c.Check(err, check.NotNil)
+
+	// A case sensitive alphabet indexing methylated cytosine in place of thymine.
+	meth, err := alphabet.NewGeneric("ACGm", alphabet.CaseSensitive)
+	c.Assert(err, check.IsNil)
+	i, err := New(4, &seq.Seq{Seq: []byte("ACGmACGTmCGA"), Alphabet: meth})
+	c.Assert(err, check.IsNil)
+	i.Build()
+	pos, ok := i.StringKmerIndex()
+	c.Check(ok, check.Equals, true)
+	c.Check(pos, check.DeepEquals, map[string][]int{
+		"ACGm": {0}, "CGmA": {1}, "GmAC": {2}, "mACG": {3}, "mCGA": {8},
+	})
+	kmer, err := i.KmerOf("mACG")
+	c.Check(err, check.IsNil)
+	c.Check(kmer, check.Equals, Kmer(3<<6|0<<4|1<<2|2))
+	_, err = i.KmerOf("TACG")
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestKmerPacked(c *check.C) {
+	sq := &seq.Seq{Seq: append([]byte("ACGTNNacgtRACGTACGGT"), s.Seq.Seq[:100]...)}
+	p, err := packed.Pack(sq)
+	c.Assert(err, check.IsNil)
+	for k := MinKmerLen; k <= 8; k++ {
+		i, err := New(k, sq)
+		c.Assert(err, check.IsNil)
+		type hit struct{ pos, kmer int }
+		var want, got []hit
+		err = i.ForEachKmerOf(sq, 0, sq.Len(), func(_ *Index, pos, kmer int) { want = append(want, hit{pos, kmer}) })
+		c.Check(err, check.IsNil)
+		err = i.ForEachKmerOfPacked(p, 0, p.Len(), func(_ *Index, pos, kmer int) { got = append(got, hit{pos, kmer}) })
+		c.Check(err, check.IsNil)
+		c.Check(got, check.DeepEquals, want)
+
+		pi, err := NewPacked(k, p)
+		c.Assert(err, check.IsNil)
+		c.Check(pi.GetSeq(), check.IsNil)
+		c.Check(pi.GetPacked(), check.Equals, p)
+		f, _ := i.KmerFrequencies()
+		pf, _ := pi.KmerFrequencies()
+		c.Check(pf, check.DeepEquals, f)
+		i.Build()
+		pi.Build()
+		ok, _ := pi.Check()
+		c.Check(ok, check.Equals, true)
+		c.Check(pi.Finger(), check.DeepEquals, i.Finger())
+	}
+}
+
+func (s *S) TestKmerMasked(c *check.C) {
+	sq := &seq.Seq{Seq: []byte("ACGTAcgtaACGTACGTT")}
+	i, err := NewMasked(4, sq)
+	c.Assert(err, check.IsNil)
+	i.Build()
+	pos, ok := i.StringKmerIndex()
+	c.Check(ok, check.Equals, true)
+	c.Check(pos, check.DeepEquals, map[string][]int{
+		"ACGT": {0, 9, 13}, "CGTA": {1, 10}, "GTAC": {11}, "TACG": {12}, "CGTT": {14},
+	})
+	kmer, err := i.KmerOf("acgt")
+	c.Check(err, check.IsNil)
+	c.Check(i.Stringify(kmer), check.Equals, "ACGT")
+
+	p, err := packed.Pack(sq)
+	c.Assert(err, check.IsNil)
+	var want, got []int
+	i.ForEachKmerOf(sq, 0, sq.Len(), func(_ *Index, pos, _ int) { want = append(want, pos) })
+	i.ForEachKmerOfPacked(p, 0, p.Len(), func(_ *Index, pos, _ int) { got = append(got, pos) })
+	c.Check(got, check.DeepEquals, want)
+	c.Check(want, check.DeepEquals, []int{0, 1, 9, 10, 11, 12, 13, 14})
+}
+
+func (s *S) TestKmerCircular(c *check.C) {
+	sq := &seq.Seq{Seq: []byte("CGTAAAAAAAC")}
+	i, err := New(4, sq)
+	c.Assert(err, check.IsNil)
+	i.Build()
+	pos, _ := i.StringKmerIndex()
+	c.Check(pos["CCGT"], check.IsNil)
+	c.Check(len(i.pos), check.Equals, sq.Len()-3)
+
+	sq.Circular = true
+	i, err = New(4, sq)
+	c.Assert(err, check.IsNil)
+	i.Build()
+	ok, n := i.Check()
+	c.Check(ok, check.Equals, true)
+	c.Check(n, check.Equals, sq.Len())
+	pos, _ = i.StringKmerIndex()
+	c.Check(pos["CCGT"], check.DeepEquals, []int{10})
+	c.Check(pos["ACCG"], check.DeepEquals, []int{9})
+}
+
 func (s *S) TestKmerKmerUtilities(c *check.C) {
 	for k := MinKmerLen; k <= 8; k++ { // again not testing all exhaustively
 		for kmer := Kmer(0); uint(kmer) <= util.Pow4(k)-1; kmer++ {
@@ -0,0 +1,170 @@
+// Package to read and write bedGraph files
+package bedgraph
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// bedGraph format reader type. Track, browser and comment lines are skipped.
+type Reader struct {
+	f    io.ReadCloser
+	r    *bufio.Reader
+	line int
+}
+
+// Returns a new bedGraph format reader using f.
+func NewReader(f io.ReadCloser) *Reader {
+	return &Reader{
+		f: f,
+		r: bufio.NewReader(f),
+	}
+}
+
+// Returns a new bedGraph reader using a filename.
+func NewReaderName(name string) (r *Reader, err error) {
+	var f *os.File
+	if f, err = os.Open(name); err != nil {
+		return
+	}
+	return NewReader(f), nil
+}
+
+// Read a single feature and return it or an error. The data value is held in the Score field.
+func (self *Reader) Read() (f *feat.Feature, err error) {
+	var line string
+	for {
+		if line, err = self.r.ReadString('\n'); err != nil {
+			return
+		}
+		self.line++
+		line = strings.TrimSpace(line)
+		if len(line) > 0 && !strings.HasPrefix(line, "#") &&
+			!strings.HasPrefix(line, "track") && !strings.HasPrefix(line, "browser") {
+			break
+		}
+	}
+
+	elems := strings.Fields(line)
+	if len(elems) != 4 {
+		return nil, bio.NewError(fmt.Sprintf("Wrong number of fields on line %d", self.line), 0, line)
+	}
+
+	f = &feat.Feature{
+		ID:       elems[0] + ":" + elems[1] + ".." + elems[2],
+		Location: elems[0],
+		Frame:    -1,
+		Moltype:  bio.DNA,
+	}
+	if f.Start, err = strconv.Atoi(elems[1]); err != nil {
+		return nil, bio.NewError(fmt.Sprintf("Failed to parse start on line %d", self.line), 0, err)
+	}
+	if f.End, err = strconv.Atoi(elems[2]); err != nil {
+		return nil, bio.NewError(fmt.Sprintf("Failed to parse end on line %d", self.line), 0, err)
+	}
+	if f.Score, err = strconv.ParseFloat(elems[3], 64); err != nil {
+		return nil, bio.NewError(fmt.Sprintf("Failed to parse value on line %d", self.line), 0, err)
+	}
+
+	return
+}
+
+// Return the current line number
+func (self *Reader) Line() int { return self.line }
+
+// Rewind the reader.
+func (self *Reader) Rewind() (err error) {
+	if s, ok := self.f.(io.Seeker); ok {
+		if _, err = s.Seek(0, 0); err == nil {
+			self.r = bufio.NewReader(self.f)
+			self.line = 0
+		}
+	} else {
+		err = bio.NewError("Not a Seeker", 0, self)
+	}
+
+	return
+}
+
+// Close the reader.
+func (self *Reader) Close() (err error) {
+	return self.f.Close()
+}
+
+// bedGraph format writer type.
+type Writer struct {
+	f           io.WriteCloser
+	w           *bufio.Writer
+	FloatFormat byte
+	Precision   int
+}
+
+// Returns a new bedGraph format writer using f.
+func NewWriter(f io.WriteCloser) *Writer {
+	return &Writer{
+		f:           f,
+		w:           bufio.NewWriter(f),
+		FloatFormat: bio.FloatFormat,
+		Precision:   bio.Precision,
+	}
+}
+
+// Returns a new bedGraph format writer using a filename, truncating any existing file.
+// If appending is required use NewWriter and os.OpenFile.
+func NewWriterName(name string) (w *Writer, err error) {
+	var f *os.File
+	if f, err = os.Create(name); err != nil {
+		return
+	}
+	return NewWriter(f), nil
+}
+
+// Write a track definition line with the given name and description.
+func (self *Writer) WriteTrack(name, description string) (n int, err error) {
+	return self.w.WriteString(fmt.Sprintf("track type=bedGraph name=%q description=%q\n", name, description))
+}
+
+// Write a single feature, using its Score as the data value, and return the number of bytes
+// written and any error.
+func (self *Writer) Write(f *feat.Feature) (n int, err error) {
+	return self.w.WriteString(self.Stringify(f) + "\n")
+}
+
+// Convert a feature to a string.
+func (self *Writer) Stringify(f *feat.Feature) string {
+	return strings.Join([]string{
+		f.Location,
+		strconv.Itoa(f.Start),
+		strconv.Itoa(f.End),
+		strconv.FormatFloat(f.Score, self.FloatFormat, self.Precision, 64),
+	}, "\t")
+}
+
+// Close the writer, flushing any unwritten data.
+func (self *Writer) Close() (err error) {
+	if err = self.w.Flush(); err != nil {
+		return
+	}
+	return self.f.Close()
+}
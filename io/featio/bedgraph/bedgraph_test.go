@@ -0,0 +1,82 @@
+package bedgraph
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"github.com/kortschak/BioGo/feat"
+	"io"
+	check "launchpad.net/gocheck"
+	"strings"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+type closer struct{ io.Reader }
+
+func (closer) Close() error { return nil }
+
+type buffer struct{ *bytes.Buffer }
+
+func (buffer) Close() error { return nil }
+
+func (s *S) TestReadWrite(c *check.C) {
+	in := strings.Join([]string{
+		"browser position chr1:1-100",
+		"track type=bedGraph name=\"gc\"",
+		"chr1\t0\t50\t0.5",
+		"# comment",
+		"chr1\t50\t100\t0.25",
+		"",
+	}, "\n")
+	r := NewReader(closer{strings.NewReader(in)})
+	var f []*feat.Feature
+	for {
+		ft, err := r.Read()
+		if err != nil {
+			c.Check(err, check.Equals, io.EOF)
+			break
+		}
+		f = append(f, ft)
+	}
+	c.Assert(len(f), check.Equals, 2)
+	c.Check(f[1].Location, check.Equals, "chr1")
+	c.Check(f[1].Start, check.Equals, 50)
+	c.Check(f[1].End, check.Equals, 100)
+	c.Check(f[1].Score, check.Equals, 0.25)
+	c.Check(f[1].ID, check.Equals, "chr1:50..100")
+
+	_, err := NewReader(closer{strings.NewReader("chr1\t0\t50\n")}).Read()
+	c.Check(err, check.NotNil)
+
+	b := buffer{&bytes.Buffer{}}
+	w := NewWriter(b)
+	w.Precision = 2
+	w.FloatFormat = 'f'
+	w.WriteTrack("gc", "GC content")
+	for _, ft := range f {
+		_, err = w.Write(ft)
+		c.Check(err, check.IsNil)
+	}
+	c.Check(w.Close(), check.IsNil)
+	c.Check(b.String(), check.Equals, "track type=bedGraph name=\"gc\" description=\"GC content\"\nchr1\t0\t50\t0.50\nchr1\t50\t100\t0.25\n")
+}
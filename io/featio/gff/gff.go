@@ -180,7 +180,7 @@ func (self *Reader) metaSequence(moltype, id string) (sequence *seq.Seq, err err
 	}
 
 	sequence = seq.New(id, body, nil)
-	sequence.Moltype = bio.ParseMoltype(moltype)
+	sequence.Alphabet = seq.AlphabetOf(bio.ParseMoltype(moltype))
 
 	return
 }
@@ -378,7 +378,7 @@ func (self *Writer) WriteMetaData(d interface{}) (n int, err error) {
 		n, err = self.w.WriteString("##" + d.(string) + "\n")
 	case *seq.Seq:
 		sw := fasta.NewWriter(self.f, self.Width)
-		sw.IDPrefix = fmt.Sprintf("##%s ", d.(*seq.Seq).Moltype)
+		sw.IDPrefix = fmt.Sprintf("##%s ", d.(*seq.Seq).Moltype())
 		sw.SeqPrefix = "##"
 		if n, err = sw.Write(d.(*seq.Seq)); err != nil {
 			return
@@ -387,7 +387,7 @@ func (self *Writer) WriteMetaData(d interface{}) (n int, err error) {
 			return
 		}
 		var m int
-		m, err = self.w.WriteString("##end-" + d.(*seq.Seq).Moltype.String() + "\n")
+		m, err = self.w.WriteString("##end-" + d.(*seq.Seq).Moltype().String() + "\n")
 		n += m
 		if err != nil {
 			return
@@ -17,6 +17,7 @@ package gff
 
 import (
 	"fmt"
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/feat"
 	"github.com/kortschak/BioGo/seq"
@@ -53,9 +54,9 @@ var (
 		{ID: "SEQ2:16..19", Source: "grail", Location: "SEQ2", Start: 16, End: 19, Feature: "ATG", Score: 2.1, Probability: 0, Attributes: "", Comments: "", Frame: 0, Strand: -1, Moltype: 0x0, Meta: interface{}(nil)},
 	}
 	expectMeta []interface{} = []interface{}{
-		&seq.Seq{ID: "<seqname>", Seq: []byte("acggctcggattggcgctggatgatagatcagacgac..."), Offset: 0, Strand: 1, Circular: false, Moltype: 0x0, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
-		&seq.Seq{ID: "<seqname>", Seq: []byte("acggcucggauuggcgcuggaugauagaucagacgac..."), Offset: 0, Strand: 1, Circular: false, Moltype: 0x1, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
-		&seq.Seq{ID: "<seqname>", Seq: []byte("MVLSPADKTNVKAAWGKVGAHAGEYGAEALERMFLSF..."), Offset: 0, Strand: 1, Circular: false, Moltype: 0x2, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
+		&seq.Seq{ID: "<seqname>", Seq: []byte("acggctcggattggcgctggatgatagatcagacgac..."), Offset: 0, Strand: 1, Circular: false, Alphabet: alphabet.DNA, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
+		&seq.Seq{ID: "<seqname>", Seq: []byte("acggcucggauuggcgcuggaugauagaucagacgac..."), Offset: 0, Strand: 1, Circular: false, Alphabet: alphabet.RNA, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
+		&seq.Seq{ID: "<seqname>", Seq: []byte("MVLSPADKTNVKAAWGKVGAHAGEYGAEALERMFLSF..."), Offset: 0, Strand: 1, Circular: false, Alphabet: alphabet.Protein, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
 		&feat.Feature{ID: "<seqname>", Source: "", Location: "", Start: 0, End: 5, Feature: "", Score: 0, Probability: 0, Attributes: "", Comments: "", Frame: 0, Strand: 0, Moltype: 0x0, Meta: interface{}(nil)},
 	}
 	writeMeta []interface{} = []interface{}{
@@ -63,9 +64,9 @@ var (
 		"source-version <source> <version-text>",
 		"date Mon Jan 2 15:04:05 MST 2006",
 		"Type <type> <seqname>",
-		&seq.Seq{ID: "<seqname>", Seq: []byte("acggctcggattggcgctggatgatagatcagacgac..."), Offset: 0, Strand: 1, Circular: false, Moltype: 0x0, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
-		&seq.Seq{ID: "<seqname>", Seq: []byte("acggcucggauuggcgcuggaugauagaucagacgac..."), Offset: 0, Strand: 1, Circular: false, Moltype: 0x1, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
-		&seq.Seq{ID: "<seqname>", Seq: []byte("MVLSPADKTNVKAAWGKVGAHAGEYGAEALERMFLSF..."), Offset: 0, Strand: 1, Circular: false, Moltype: 0x2, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
+		&seq.Seq{ID: "<seqname>", Seq: []byte("acggctcggattggcgctggatgatagatcagacgac..."), Offset: 0, Strand: 1, Circular: false, Alphabet: alphabet.DNA, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
+		&seq.Seq{ID: "<seqname>", Seq: []byte("acggcucggauuggcgcuggaugauagaucagacgac..."), Offset: 0, Strand: 1, Circular: false, Alphabet: alphabet.RNA, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
+		&seq.Seq{ID: "<seqname>", Seq: []byte("MVLSPADKTNVKAAWGKVGAHAGEYGAEALERMFLSF..."), Offset: 0, Strand: 1, Circular: false, Alphabet: alphabet.Protein, Quality: (*seq.Quality)(nil), Inplace: false, Meta: interface{}(nil)},
 		&feat.Feature{ID: "<seqname>", Source: "", Location: "", Start: 0, End: 5, Feature: "", Score: 0, Probability: 0, Attributes: "", Comments: "", Frame: 0, Strand: 0, Moltype: 0x0, Meta: interface{}(nil)},
 	}
 )
@@ -0,0 +1,278 @@
+// Package to read and write JASPAR pfm and jaspar format motif files
+package jaspar
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/pwm"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// JASPAR matrix formats.
+const (
+	Pfm    = iota // Unlabelled rows of counts, one row per letter, with an optional '>' header.
+	Jaspar        // Labelled rows of bracketed counts with a '>' header.
+)
+
+var Letters = "ACGT" // Default letter order for pfm rows.
+
+// JASPAR format reader type.
+type Reader struct {
+	f       io.ReadCloser
+	r       *bufio.Reader
+	Format  int
+	Letters string // Letter order of rows in pfm format files.
+	line    int
+}
+
+// Returns a new JASPAR format reader using f.
+func NewReader(f io.ReadCloser, format int) *Reader {
+	return &Reader{
+		f:       f,
+		r:       bufio.NewReader(f),
+		Format:  format,
+		Letters: Letters,
+	}
+}
+
+// Returns a new JASPAR format reader using a filename.
+func NewReaderName(name string, format int) (r *Reader, err error) {
+	var f *os.File
+	if f, err = os.Open(name); err != nil {
+		return
+	}
+	return NewReader(f, format), nil
+}
+
+func (self *Reader) readLine() (line string, err error) {
+	for len(line) == 0 {
+		if line, err = self.r.ReadString('\n'); err == io.EOF && len(line) > 0 {
+			err = nil
+		}
+		if err != nil {
+			return "", err
+		}
+		self.line++
+		line = strings.TrimSpace(line)
+	}
+
+	return
+}
+
+// Read a single motif and return it or an error.
+func (self *Reader) Read() (m *pwm.Motif, err error) {
+	var (
+		line    string
+		rows    [][]float64
+		letters []byte
+	)
+
+	m = &pwm.Motif{Type: pwm.Counts}
+
+	for len(rows) < len(self.Letters) {
+		if line, err = self.readLine(); err != nil {
+			if err == io.EOF && (len(rows) > 0 || m.ID != "") {
+				err = bio.NewError(fmt.Sprintf("Incomplete matrix at line %d", self.line), 0, m)
+			}
+			return nil, err
+		}
+
+		if line[0] == '>' {
+			if len(rows) > 0 || m.ID != "" {
+				return nil, bio.NewError(fmt.Sprintf("Unexpected header on line %d", self.line), 0, line)
+			}
+			fields := strings.Fields(line[1:])
+			if len(fields) > 0 {
+				m.ID = fields[0]
+			}
+			if len(fields) > 1 {
+				m.Name = strings.Join(fields[1:], " ")
+			}
+			continue
+		}
+
+		var (
+			label byte
+			row   []float64
+		)
+		switch self.Format {
+		case Pfm:
+			label = self.Letters[len(rows)]
+			row, err = parseRow(line)
+		case Jaspar:
+			label, row, err = parseJasparRow(line)
+		default:
+			return nil, bio.NewError("Unknown JASPAR format", 0, self.Format)
+		}
+		if err != nil {
+			return nil, bio.NewError(fmt.Sprintf("Bad matrix row on line %d", self.line), 0, line, err)
+		}
+		if len(rows) > 0 && len(row) != len(rows[0]) {
+			return nil, bio.NewError(fmt.Sprintf("Matrix row length mismatch on line %d", self.line), 0, line)
+		}
+		letters = append(letters, label)
+		rows = append(rows, row)
+	}
+
+	m.Letters = string(letters)
+	m.Matrix = make([][]float64, len(rows[0]))
+	for i := range m.Matrix {
+		m.Matrix[i] = make([]float64, len(rows))
+		for j := range rows {
+			m.Matrix[i][j] = rows[j][i]
+		}
+	}
+
+	return
+}
+
+func parseRow(line string) (row []float64, err error) {
+	fields := strings.Fields(line)
+	row = make([]float64, len(fields))
+	for i, f := range fields {
+		if row[i], err = strconv.ParseFloat(f, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+func parseJasparRow(line string) (label byte, row []float64, err error) {
+	open := strings.Index(line, "[")
+	end := strings.LastIndex(line, "]")
+	if open < 1 || end < open {
+		return 0, nil, bio.NewError("Malformed jaspar row", 0, line)
+	}
+	l := strings.TrimSpace(line[:open])
+	if len(l) != 1 {
+		return 0, nil, bio.NewError("Malformed jaspar row label", 0, line)
+	}
+	row, err = parseRow(line[open+1 : end])
+
+	return l[0], row, err
+}
+
+// Rewind the reader.
+func (self *Reader) Rewind() (err error) {
+	if s, ok := self.f.(io.Seeker); ok {
+		_, err = s.Seek(0, 0)
+		self.r = bufio.NewReader(self.f)
+		self.line = 0
+	} else {
+		err = bio.NewError("Not a Seeker", 0, self)
+	}
+	return
+}
+
+// Close the reader.
+func (self *Reader) Close() (err error) {
+	return self.f.Close()
+}
+
+// JASPAR format writer type.
+type Writer struct {
+	f           io.WriteCloser
+	w           *bufio.Writer
+	Format      int
+	FloatFormat byte
+	Precision   int
+}
+
+// Returns a new JASPAR format writer using f. Values are written with the smallest
+// precision that represents them exactly unless Precision is set.
+func NewWriter(f io.WriteCloser, format int) *Writer {
+	return &Writer{
+		f:           f,
+		w:           bufio.NewWriter(f),
+		Format:      format,
+		FloatFormat: bio.FloatFormat,
+		Precision:   -1,
+	}
+}
+
+// Returns a new JASPAR format writer using a filename, truncating any existing file.
+// If appending is required use NewWriter and os.OpenFile.
+func NewWriterName(name string, format int) (w *Writer, err error) {
+	var f *os.File
+	if f, err = os.Create(name); err != nil {
+		return
+	}
+	return NewWriter(f, format), nil
+}
+
+// Write a single motif and return the number of bytes written and any error.
+func (self *Writer) Write(m *pwm.Motif) (n int, err error) {
+	for i, row := range m.Matrix {
+		if len(row) != len(m.Letters) {
+			return 0, bio.NewError("Matrix row length does not match letters", 0, i, m.Letters)
+		}
+	}
+
+	var b []byte
+	if m.ID != "" || m.Name != "" || self.Format == Jaspar {
+		b = append(b, '>')
+		b = append(b, m.ID...)
+		if m.Name != "" {
+			b = append(b, '\t')
+			b = append(b, m.Name...)
+		}
+		b = append(b, '\n')
+	}
+
+	for j := range m.Letters {
+		switch self.Format {
+		case Pfm:
+			for i, row := range m.Matrix {
+				if i > 0 {
+					b = append(b, '\t')
+				}
+				b = strconv.AppendFloat(b, row[j], self.FloatFormat, self.Precision, 64)
+			}
+		case Jaspar:
+			b = append(b, m.Letters[j])
+			b = append(b, "  ["...)
+			for _, row := range m.Matrix {
+				b = append(b, fmt.Sprintf("%6s", strconv.FormatFloat(row[j], self.FloatFormat, self.Precision, 64))...)
+			}
+			b = append(b, " ]"...)
+		default:
+			return 0, bio.NewError("Unknown JASPAR format", 0, self.Format)
+		}
+		b = append(b, '\n')
+	}
+
+	return self.w.Write(b)
+}
+
+// Flush the writer.
+func (self *Writer) Flush() error {
+	return self.w.Flush()
+}
+
+// Close the writer, flushing any unwritten data.
+func (self *Writer) Close() (err error) {
+	if err = self.w.Flush(); err != nil {
+		return
+	}
+	return self.f.Close()
+}
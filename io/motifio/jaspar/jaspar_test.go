@@ -0,0 +1,127 @@
+package jaspar
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/pwm"
+	"io"
+	check "launchpad.net/gocheck"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+var (
+	J = []struct {
+		name   string
+		format int
+	}{
+		{"../../testdata/motifs.jaspar", Jaspar},
+		{"../../testdata/motifs.pfm", Pfm},
+	}
+
+	expect = []*pwm.Motif{
+		{
+			ID: "MA0004.1", Name: "Arnt", Letters: "ACGT", Type: pwm.Counts,
+			Matrix: [][]float64{
+				{4, 16, 0, 0},
+				{19, 0, 1, 0},
+				{0, 20, 0, 0},
+				{0, 0, 20, 0},
+				{0, 0, 0, 20},
+				{0, 0, 20, 0},
+			},
+		},
+		{
+			ID: "MA0006.1", Name: "Ahr::Arnt", Letters: "ACGT", Type: pwm.Counts,
+			Matrix: [][]float64{
+				{3, 8, 2, 11},
+				{0, 0, 23, 1},
+				{0, 23, 0, 1},
+				{0, 0, 23, 1},
+				{0, 0, 0, 24},
+				{0, 0, 24, 0},
+			},
+		},
+	}
+)
+
+func read(c *check.C, r *Reader) (motifs []*pwm.Motif) {
+	for {
+		m, err := r.Read()
+		if err != nil {
+			if err != io.EOF {
+				c.Fatalf("Failed to read: %v", err)
+			}
+			break
+		}
+		motifs = append(motifs, m)
+	}
+	return
+}
+
+func (s *S) TestReadJaspar(c *check.C) {
+	for _, j := range J {
+		r, err := NewReaderName(j.name, j.format)
+		if err != nil {
+			c.Fatalf("Failed to open %q: %v", j.name, err)
+		}
+		for i := 0; i < 2; i++ {
+			c.Check(read(c, r), check.DeepEquals, expect)
+			if err = r.Rewind(); err != nil {
+				c.Fatalf("Failed to Rewind: %v", err)
+			}
+		}
+		r.Close()
+	}
+}
+
+func (s *S) TestWriteJaspar(c *check.C) {
+	o := c.MkDir()
+	for _, j := range J {
+		w, err := NewWriterName(o+"/j", j.format)
+		if err != nil {
+			c.Fatalf("Failed to open %q for write: %v", o+"/j", err)
+		}
+		for _, m := range expect {
+			if _, err = w.Write(m); err != nil {
+				c.Fatalf("Failed to write %q: %v", o+"/j", err)
+			}
+		}
+		if err = w.Close(); err != nil {
+			c.Fatalf("Failed to Close %q: %v", o+"/j", err)
+		}
+		r, err := NewReaderName(o+"/j", j.format)
+		if err != nil {
+			c.Fatalf("Failed to open %q: %v", o+"/j", err)
+		}
+		c.Check(read(c, r), check.DeepEquals, expect)
+		r.Close()
+	}
+}
+
+func (s *S) TestPWM(c *check.C) {
+	m := &pwm.Motif{Letters: "TGCA", Matrix: [][]float64{{1, 2, 3, 4}}}
+	n, err := m.Nucleic()
+	c.Check(err, check.IsNil)
+	c.Check(n, check.DeepEquals, [][]float64{{4, 3, 2, 1}})
+	c.Check(expect[0].Consensus(), check.Equals, "CACGTG")
+}
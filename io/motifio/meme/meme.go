@@ -0,0 +1,376 @@
+// Package to read and write MEME minimal motif format files
+package meme
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/pwm"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	DefaultVersion = "4"
+	DefaultLetters = "ACGT"
+	DefaultStrands = "+ -"
+)
+
+// MEME minimal motif format reader type. Header information is available
+// once the first motif has been read.
+type Reader struct {
+	f          io.ReadCloser
+	r          *bufio.Reader
+	Version    string
+	Letters    string
+	Strands    string
+	Background []float64 // Background letter frequencies in the order of Letters.
+	last       string
+	line       int
+}
+
+// Returns a new MEME format reader using f.
+func NewReader(f io.ReadCloser) *Reader {
+	return &Reader{
+		f:       f,
+		r:       bufio.NewReader(f),
+		Letters: DefaultLetters,
+	}
+}
+
+// Returns a new MEME format reader using a filename.
+func NewReaderName(name string) (r *Reader, err error) {
+	var f *os.File
+	if f, err = os.Open(name); err != nil {
+		return
+	}
+	return NewReader(f), nil
+}
+
+func (self *Reader) readLine() (line string, err error) {
+	if self.last != "" {
+		line, self.last = self.last, ""
+		return
+	}
+	for len(line) == 0 {
+		if line, err = self.r.ReadString('\n'); err == io.EOF && len(line) > 0 {
+			err = nil
+		}
+		if err != nil {
+			return "", err
+		}
+		self.line++
+		line = strings.TrimSpace(line)
+	}
+
+	return
+}
+
+// Read a single motif and return it or an error. The motif URL, if present, is
+// stored in the Description field.
+func (self *Reader) Read() (m *pwm.Motif, err error) {
+	var line string
+
+	for {
+		if line, err = self.readLine(); err != nil {
+			if err == io.EOF && m != nil {
+				if m.Matrix == nil {
+					return nil, bio.NewError(fmt.Sprintf("No matrix for motif %q", m.ID), 0, m)
+				}
+				err = nil
+				return
+			}
+			return nil, err
+		}
+
+		switch {
+		case strings.HasPrefix(line, "MOTIF"):
+			if m != nil {
+				self.last = line
+				if m.Matrix == nil {
+					return nil, bio.NewError(fmt.Sprintf("No matrix for motif %q", m.ID), 0, m)
+				}
+				return
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				return nil, bio.NewError(fmt.Sprintf("Missing motif name on line %d", self.line), 0, line)
+			}
+			m = &pwm.Motif{
+				ID:      fields[1],
+				Letters: self.Letters,
+				Type:    pwm.Probabilities,
+			}
+			if len(fields) > 2 {
+				m.Name = strings.Join(fields[2:], " ")
+			}
+		case m == nil:
+			if err = self.header(line); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(line, "letter-probability matrix"):
+			params := parameters(line[strings.Index(line, ":")+1:])
+			if m.Matrix, err = self.matrix(params); err != nil {
+				return nil, err
+			}
+			if n, ok := params["nsites"]; ok {
+				if f, err := strconv.ParseFloat(n, 64); err == nil {
+					m.Sites = int(f)
+				}
+			}
+			if e, ok := params["E"]; ok {
+				m.EValue, _ = strconv.ParseFloat(e, 64)
+			}
+		case strings.HasPrefix(line, "log-odds matrix"):
+			if _, err = self.matrix(parameters(line[strings.Index(line, ":")+1:])); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(line, "URL"):
+			m.Description = strings.TrimSpace(line[len("URL"):])
+		}
+	}
+}
+
+func (self *Reader) header(line string) (err error) {
+	switch {
+	case strings.HasPrefix(line, "MEME version"):
+		if fields := strings.Fields(line); len(fields) > 2 {
+			self.Version = fields[2]
+		}
+	case strings.HasPrefix(line, "ALPHABET="):
+		self.Letters = strings.TrimSpace(line[len("ALPHABET="):])
+	case strings.HasPrefix(line, "strands:"):
+		self.Strands = strings.TrimSpace(line[len("strands:"):])
+	case strings.HasPrefix(line, "Background letter frequencies"):
+		self.Background = make([]float64, len(self.Letters))
+		for found := 0; found < len(self.Letters); {
+			if line, err = self.readLine(); err != nil {
+				return bio.NewError(fmt.Sprintf("Incomplete background frequencies at line %d", self.line), 0, err)
+			}
+			fields := strings.Fields(line)
+			if len(fields)%2 != 0 {
+				return bio.NewError(fmt.Sprintf("Malformed background frequencies on line %d", self.line), 0, line)
+			}
+			for i := 0; i < len(fields); i += 2 {
+				j := strings.Index(self.Letters, fields[i])
+				if len(fields[i]) != 1 || j < 0 {
+					return bio.NewError(fmt.Sprintf("Unknown background letter on line %d", self.line), 0, fields[i])
+				}
+				if self.Background[j], err = strconv.ParseFloat(fields[i+1], 64); err != nil {
+					return bio.NewError(fmt.Sprintf("Bad background frequency on line %d", self.line), 0, err)
+				}
+				found++
+			}
+		}
+	}
+
+	return
+}
+
+func (self *Reader) matrix(params map[string]string) (m [][]float64, err error) {
+	var w, alength int
+	if w, err = strconv.Atoi(params["w"]); err != nil {
+		return nil, bio.NewError(fmt.Sprintf("Missing matrix width on line %d", self.line), 0, params)
+	}
+	if alength, err = strconv.Atoi(params["alength"]); err != nil {
+		alength = len(self.Letters)
+	}
+	if alength != len(self.Letters) {
+		return nil, bio.NewError(fmt.Sprintf("Matrix alphabet length mismatch on line %d", self.line), 0, alength, self.Letters)
+	}
+
+	m = make([][]float64, w)
+	for i := range m {
+		var line string
+		if line, err = self.readLine(); err != nil {
+			return nil, bio.NewError(fmt.Sprintf("Incomplete matrix at line %d", self.line), 0, err)
+		}
+		fields := strings.Fields(line)
+		if len(fields) != alength {
+			return nil, bio.NewError(fmt.Sprintf("Matrix row length mismatch on line %d", self.line), 0, line)
+		}
+		m[i] = make([]float64, alength)
+		for j, f := range fields {
+			if m[i][j], err = strconv.ParseFloat(f, 64); err != nil {
+				return nil, bio.NewError(fmt.Sprintf("Bad matrix value on line %d", self.line), 0, err)
+			}
+		}
+	}
+
+	return
+}
+
+// Parse "key= value" pairs from a matrix description line.
+func parameters(s string) (p map[string]string) {
+	p = make(map[string]string)
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		if j := strings.Index(fields[i], "="); j >= 0 {
+			key, value := fields[i][:j], fields[i][j+1:]
+			if value == "" && i+1 < len(fields) {
+				i++
+				value = fields[i]
+			}
+			p[key] = value
+		}
+	}
+
+	return
+}
+
+// Rewind the reader.
+func (self *Reader) Rewind() (err error) {
+	if s, ok := self.f.(io.Seeker); ok {
+		_, err = s.Seek(0, 0)
+		self.r = bufio.NewReader(self.f)
+		self.last = ""
+		self.line = 0
+	} else {
+		err = bio.NewError("Not a Seeker", 0, self)
+	}
+	return
+}
+
+// Close the reader.
+func (self *Reader) Close() (err error) {
+	return self.f.Close()
+}
+
+// MEME minimal motif format writer type. The header is written before the first motif
+// using the Version, Letters, Strands and Background fields. If Letters is empty the
+// letters of the first motif are used and if Background is nil uniform frequencies are
+// written.
+type Writer struct {
+	f           io.WriteCloser
+	w           *bufio.Writer
+	Version     string
+	Letters     string
+	Strands     string
+	Background  []float64
+	FloatFormat byte
+	Precision   int
+	header      bool
+}
+
+// Returns a new MEME format writer using f.
+func NewWriter(f io.WriteCloser) *Writer {
+	return &Writer{
+		f:           f,
+		w:           bufio.NewWriter(f),
+		Version:     DefaultVersion,
+		Strands:     DefaultStrands,
+		FloatFormat: 'f',
+		Precision:   6,
+	}
+}
+
+// Returns a new MEME format writer using a filename, truncating any existing file.
+// If appending is required use NewWriter and os.OpenFile.
+func NewWriterName(name string) (w *Writer, err error) {
+	var f *os.File
+	if f, err = os.Create(name); err != nil {
+		return
+	}
+	return NewWriter(f), nil
+}
+
+func (self *Writer) writeHeader() (n int, err error) {
+	if self.Background != nil && len(self.Background) != len(self.Letters) {
+		return 0, bio.NewError("Background length does not match letters", 0, self.Background, self.Letters)
+	}
+
+	b := []byte("MEME version " + self.Version + "\n\nALPHABET= " + self.Letters + "\n\n")
+	if self.Strands != "" {
+		b = append(b, "strands: "+self.Strands+"\n\n"...)
+	}
+	b = append(b, "Background letter frequencies\n"...)
+	for i := range self.Letters {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		f := 1 / float64(len(self.Letters))
+		if self.Background != nil {
+			f = self.Background[i]
+		}
+		b = append(b, self.Letters[i], ' ')
+		b = strconv.AppendFloat(b, f, self.FloatFormat, 3, 64)
+	}
+	b = append(b, "\n\n"...)
+
+	return self.w.Write(b)
+}
+
+// Write a single motif and return the number of bytes written and any error.
+func (self *Writer) Write(m *pwm.Motif) (n int, err error) {
+	if self.Letters == "" {
+		self.Letters = m.Letters
+	}
+	if !strings.EqualFold(m.Letters, self.Letters) {
+		return 0, bio.NewError("Motif letters do not match writer letters", 0, m.Letters, self.Letters)
+	}
+	if !self.header {
+		if n, err = self.writeHeader(); err != nil {
+			return
+		}
+		self.header = true
+	}
+
+	b := []byte("MOTIF " + m.ID)
+	if m.Name != "" {
+		b = append(b, ' ')
+		b = append(b, m.Name...)
+	}
+	b = append(b, fmt.Sprintf("\nletter-probability matrix: alength= %d w= %d nsites= %d E= %s\n",
+		len(m.Letters), m.Len(), m.Sites, strconv.FormatFloat(m.EValue, 'g', -1, 64))...)
+	probs := m.Matrix
+	if m.Type != pwm.Probabilities {
+		probs = m.Probs()
+	}
+	for _, row := range probs {
+		for _, v := range row {
+			b = append(b, ' ')
+			b = strconv.AppendFloat(b, v, self.FloatFormat, self.Precision, 64)
+		}
+		b = append(b, '\n')
+	}
+	if m.Description != "" {
+		b = append(b, "\nURL "+m.Description+"\n"...)
+	}
+	b = append(b, '\n')
+
+	var c int
+	c, err = self.w.Write(b)
+	n += c
+
+	return
+}
+
+// Flush the writer.
+func (self *Writer) Flush() error {
+	return self.w.Flush()
+}
+
+// Close the writer, flushing any unwritten data.
+func (self *Writer) Close() (err error) {
+	if err = self.w.Flush(); err != nil {
+		return
+	}
+	return self.f.Close()
+}
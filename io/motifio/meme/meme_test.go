@@ -0,0 +1,114 @@
+package meme
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/pwm"
+	"io"
+	check "launchpad.net/gocheck"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+var (
+	memeFile = "../../testdata/motifs.meme"
+
+	expect = []*pwm.Motif{
+		{
+			ID: "MA0004.1", Name: "Arnt", Letters: "ACGT", Type: pwm.Probabilities, Sites: 20,
+			Description: "http://jaspar.genereg.net/matrix/MA0004.1",
+			Matrix: [][]float64{
+				{0.2, 0.8, 0, 0},
+				{0.95, 0, 0.05, 0},
+				{0, 1, 0, 0},
+				{0, 0, 1, 0},
+				{0, 0, 0, 1},
+				{0, 0, 1, 0},
+			},
+		},
+		{
+			ID: "crp", Letters: "ACGT", Type: pwm.Probabilities, Sites: 17, EValue: 4.1e-09,
+			Matrix: [][]float64{
+				{0, 0.176471, 0, 0.823529},
+				{0, 0.058824, 0.647059, 0.294118},
+				{0, 0.058824, 0, 0.941176},
+				{0.176471, 0, 0.764706, 0.058824},
+			},
+		},
+	}
+	background = []float64{0.303, 0.183, 0.209, 0.306}
+)
+
+func read(c *check.C, r *Reader) (motifs []*pwm.Motif) {
+	for {
+		m, err := r.Read()
+		if err != nil {
+			if err != io.EOF {
+				c.Fatalf("Failed to read: %v", err)
+			}
+			break
+		}
+		motifs = append(motifs, m)
+	}
+	return
+}
+
+func (s *S) TestReadMeme(c *check.C) {
+	r, err := NewReaderName(memeFile)
+	if err != nil {
+		c.Fatalf("Failed to open %q: %v", memeFile, err)
+	}
+	for i := 0; i < 2; i++ {
+		c.Check(read(c, r), check.DeepEquals, expect)
+		c.Check(r.Version, check.Equals, "4")
+		c.Check(r.Strands, check.Equals, "+ -")
+		c.Check(r.Background, check.DeepEquals, background)
+		if err = r.Rewind(); err != nil {
+			c.Fatalf("Failed to Rewind: %v", err)
+		}
+	}
+	r.Close()
+}
+
+func (s *S) TestWriteMeme(c *check.C) {
+	o := c.MkDir()
+	w, err := NewWriterName(o + "/m")
+	if err != nil {
+		c.Fatalf("Failed to open %q for write: %v", o+"/m", err)
+	}
+	w.Background = background
+	for _, m := range expect {
+		if _, err = w.Write(m); err != nil {
+			c.Fatalf("Failed to write %q: %v", o+"/m", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		c.Fatalf("Failed to Close %q: %v", o+"/m", err)
+	}
+	r, err := NewReaderName(o + "/m")
+	if err != nil {
+		c.Fatalf("Failed to open %q: %v", o+"/m", err)
+	}
+	c.Check(read(c, r), check.DeepEquals, expect)
+	c.Check(r.Background, check.DeepEquals, background)
+	r.Close()
+}
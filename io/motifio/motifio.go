@@ -1,6 +1,7 @@
-package dp
+// Packages for reading and writing motif matrices
+package motifio
 
-// Copyright ©2011 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
 //
 // This program is free software: you can redistribute it and/or modify
 // it under the terms of the GNU General Public License as published by
@@ -15,20 +16,14 @@ package dp
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
-import (
-	"github.com/kortschak/BioGo/bio"
-	"github.com/kortschak/BioGo/util"
-)
+import "github.com/kortschak/BioGo/pwm"
 
-var lookUp util.CTL
-
-func init() {
-	m := make(map[int]int)
-
-	for i, v := range bio.N {
-		m[int(v)] = i
-		m[int(v+32)] = i
-	}
+type Reader interface {
+	Read() (*pwm.Motif, error)
+	Rewind() error
+	Close() error
+}
 
-	lookUp = *util.NewCTL(m)
+type Writer interface {
+	Write(*pwm.Motif) (int, error)
 }
@@ -0,0 +1,339 @@
+// Package to read and write TRANSFAC format matrix files
+package transfac
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/pwm"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tags with explicit handling. All other tags are retained in the Motif's Meta field
+// as a Tags value.
+const (
+	accessionTag   = "AC"
+	identifierTag  = "ID"
+	nameTag        = "NA"
+	descriptionTag = "DE"
+	sitesTag       = "BA"
+	separatorTag   = "XX"
+	endTag         = "//"
+)
+
+// Tags holds the unhandled tagged lines of a TRANSFAC matrix entry.
+type Tags map[string][]string
+
+// TRANSFAC format reader type.
+type Reader struct {
+	f    io.ReadCloser
+	r    *bufio.Reader
+	line int
+}
+
+// Returns a new TRANSFAC format reader using f.
+func NewReader(f io.ReadCloser) *Reader {
+	return &Reader{
+		f: f,
+		r: bufio.NewReader(f),
+	}
+}
+
+// Returns a new TRANSFAC format reader using a filename.
+func NewReaderName(name string) (r *Reader, err error) {
+	var f *os.File
+	if f, err = os.Open(name); err != nil {
+		return
+	}
+	return NewReader(f), nil
+}
+
+func (self *Reader) readLine() (line string, err error) {
+	for len(line) == 0 {
+		if line, err = self.r.ReadString('\n'); err == io.EOF && len(line) > 0 {
+			err = nil
+		}
+		if err != nil {
+			return "", err
+		}
+		self.line++
+		line = strings.TrimSpace(line)
+	}
+
+	return
+}
+
+// Read a single motif and return it or an error. The AC accession is used as the
+// motif ID and the ID identifier, or NA name if no ID is given, as the motif Name.
+// NA names not used as the Name are retained in the NA tag.
+func (self *Reader) Read() (m *pwm.Motif, err error) {
+	var (
+		line    string
+		tags    = Tags{}
+		inMat   bool
+		started bool
+		naName  bool // m.Name holds an NA value.
+	)
+
+	m = &pwm.Motif{Type: pwm.Counts}
+
+	for {
+		if line, err = self.readLine(); err != nil {
+			if err == io.EOF && started {
+				err = bio.NewError(fmt.Sprintf("Unterminated entry at line %d", self.line), 0, m)
+			}
+			return nil, err
+		}
+		started = true
+
+		tag, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			tag, value = line[:i], strings.TrimSpace(line[i:])
+		}
+
+		if inMat {
+			if _, e := strconv.Atoi(tag); e == nil {
+				var row []float64
+				if row, err = parseRow(value, len(m.Letters)); err != nil {
+					return nil, bio.NewError(fmt.Sprintf("Bad matrix row on line %d", self.line), 0, line, err)
+				}
+				m.Matrix = append(m.Matrix, row)
+				continue
+			}
+			inMat = false
+		}
+
+		switch tag {
+		case endTag:
+			if m.Matrix == nil {
+				return nil, bio.NewError(fmt.Sprintf("No matrix for entry ending at line %d", self.line), 0, m)
+			}
+			if len(tags) > 0 {
+				m.Meta = tags
+			}
+			return
+		case separatorTag:
+		case "P0", "PO":
+			m.Letters = strings.Join(strings.Fields(value), "")
+			inMat = true
+		case accessionTag:
+			m.ID = value
+		case identifierTag:
+			if naName {
+				tags[nameTag] = append([]string{m.Name}, tags[nameTag]...)
+				naName = false
+			}
+			m.Name = value
+		case nameTag:
+			if m.Name == "" {
+				m.Name, naName = value, true
+			} else {
+				tags[tag] = append(tags[tag], value)
+			}
+		case descriptionTag:
+			if m.Description != "" {
+				m.Description += " "
+			}
+			m.Description += value
+		case sitesTag:
+			if fields := strings.Fields(value); len(fields) > 0 {
+				m.Sites, _ = strconv.Atoi(fields[0])
+			}
+			tags[tag] = append(tags[tag], value)
+		default:
+			tags[tag] = append(tags[tag], value)
+		}
+	}
+}
+
+func parseRow(s string, n int) (row []float64, err error) {
+	fields := strings.Fields(s)
+	if len(fields) < n {
+		return nil, bio.NewError("Matrix row too short", 0, s, n)
+	}
+	row = make([]float64, n)
+	for i := range row {
+		if row[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Rewind the reader.
+func (self *Reader) Rewind() (err error) {
+	if s, ok := self.f.(io.Seeker); ok {
+		_, err = s.Seek(0, 0)
+		self.r = bufio.NewReader(self.f)
+		self.line = 0
+	} else {
+		err = bio.NewError("Not a Seeker", 0, self)
+	}
+	return
+}
+
+// Close the reader.
+func (self *Reader) Close() (err error) {
+	return self.f.Close()
+}
+
+// TRANSFAC format writer type.
+type Writer struct {
+	f           io.WriteCloser
+	w           *bufio.Writer
+	FloatFormat byte
+	Precision   int
+	Consensus   bool // Append an IUPAC consensus letter to each matrix row.
+}
+
+// Returns a new TRANSFAC format writer using f.
+func NewWriter(f io.WriteCloser) *Writer {
+	return &Writer{
+		f:           f,
+		w:           bufio.NewWriter(f),
+		FloatFormat: bio.FloatFormat,
+		Precision:   -1,
+		Consensus:   true,
+	}
+}
+
+// Returns a new TRANSFAC format writer using a filename, truncating any existing file.
+// If appending is required use NewWriter and os.OpenFile.
+func NewWriterName(name string) (w *Writer, err error) {
+	var f *os.File
+	if f, err = os.Create(name); err != nil {
+		return
+	}
+	return NewWriter(f), nil
+}
+
+func appendTag(b []byte, tag, value string) []byte {
+	return append(b, tag+"  "+value+"\nXX\n"...)
+}
+
+// Write a single motif and return the number of bytes written and any error.
+func (self *Writer) Write(m *pwm.Motif) (n int, err error) {
+	for i, row := range m.Matrix {
+		if len(row) != len(m.Letters) {
+			return 0, bio.NewError("Matrix row length does not match letters", 0, i, m.Letters)
+		}
+	}
+
+	var b []byte
+	if m.ID != "" {
+		b = appendTag(b, accessionTag, m.ID)
+	}
+	if m.Name != "" {
+		b = appendTag(b, identifierTag, m.Name)
+	}
+	if m.Description != "" {
+		b = appendTag(b, descriptionTag, m.Description)
+	}
+
+	b = append(b, "P0"...)
+	for _, l := range m.Letters {
+		b = append(b, fmt.Sprintf("%7c", l)...)
+	}
+	b = append(b, '\n')
+	for i, row := range m.Matrix {
+		b = append(b, fmt.Sprintf("%02d", i+1)...)
+		for _, v := range row {
+			b = append(b, fmt.Sprintf("%7s", strconv.FormatFloat(v, self.FloatFormat, self.Precision, 64))...)
+		}
+		if self.Consensus {
+			b = append(b, fmt.Sprintf("%7c", consensus(m.Letters, row))...)
+		}
+		b = append(b, '\n')
+	}
+	b = append(b, "XX\n"...)
+
+	if tags, ok := m.Meta.(Tags); ok {
+		keys := make([]string, 0, len(tags))
+		for k := range tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			for _, v := range tags[k] {
+				b = append(b, k+"  "+v+"\n"...)
+			}
+		}
+		if len(keys) > 0 {
+			b = append(b, "XX\n"...)
+		}
+	}
+	b = append(b, "//\n"...)
+
+	return self.w.Write(b)
+}
+
+var iupacPair = map[string]byte{
+	"AC": 'M', "AG": 'R', "AT": 'W',
+	"CG": 'S', "CT": 'Y', "GT": 'K',
+}
+
+// Return the consensus letter for a matrix row using the rules of Cavener (1987).
+// Degenerate letters are only used when the letters are A, C, G and T.
+func consensus(letters string, row []float64) byte {
+	var sum float64
+	first, second := -1, -1
+	for i, v := range row {
+		sum += v
+		switch {
+		case first < 0 || v > row[first]:
+			first, second = i, first
+		case second < 0 || v > row[second]:
+			second = i
+		}
+	}
+	if first < 0 || sum == 0 {
+		return 'N'
+	}
+	if row[first]/sum > 0.5 && (second < 0 || row[first] > 2*row[second]) {
+		return strings.ToUpper(letters[first : first+1])[0]
+	}
+	if second >= 0 && (row[first]+row[second])/sum > 0.75 {
+		pair := []byte(strings.ToUpper(string([]byte{letters[first], letters[second]})))
+		if pair[0] > pair[1] {
+			pair[0], pair[1] = pair[1], pair[0]
+		}
+		if c, ok := iupacPair[string(pair)]; ok {
+			return c
+		}
+	}
+
+	return 'N'
+}
+
+// Flush the writer.
+func (self *Writer) Flush() error {
+	return self.w.Flush()
+}
+
+// Close the writer, flushing any unwritten data.
+func (self *Writer) Close() (err error) {
+	if err = self.w.Flush(); err != nil {
+		return
+	}
+	return self.f.Close()
+}
@@ -0,0 +1,149 @@
+package transfac
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/pwm"
+	"io"
+	"io/ioutil"
+	check "launchpad.net/gocheck"
+	"strings"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+var (
+	transfacFile = "../../testdata/motifs.transfac"
+
+	expect = []*pwm.Motif{
+		{
+			ID: "M00001", Name: "V$MYOD_01", Description: "myoblast determining factor",
+			Letters: "ACGT", Type: pwm.Counts, Sites: 5,
+			Matrix: [][]float64{
+				{1, 2, 2, 0},
+				{2, 1, 2, 0},
+				{3, 0, 1, 1},
+				{0, 5, 0, 0},
+				{5, 0, 0, 0},
+				{0, 0, 4, 1},
+			},
+			Meta: Tags{"NA": {"MyoD"}, "BA": {"5 functional elements in 3 genes"}},
+		},
+		{
+			ID: "MA0004.1", Name: "Arnt", Letters: "ACGT", Type: pwm.Counts,
+			Matrix: [][]float64{
+				{4, 16, 0, 0},
+				{19, 0, 1, 0},
+				{0, 20, 0, 0},
+				{0, 0, 20, 0},
+				{0, 0, 0, 20},
+				{0, 0, 20, 0},
+			},
+		},
+	}
+)
+
+func read(c *check.C, r *Reader) (motifs []*pwm.Motif) {
+	for {
+		m, err := r.Read()
+		if err != nil {
+			if err != io.EOF {
+				c.Fatalf("Failed to read: %v", err)
+			}
+			break
+		}
+		motifs = append(motifs, m)
+	}
+	return
+}
+
+func (s *S) TestReadTransfac(c *check.C) {
+	r, err := NewReaderName(transfacFile)
+	if err != nil {
+		c.Fatalf("Failed to open %q: %v", transfacFile, err)
+	}
+	for i := 0; i < 2; i++ {
+		c.Check(read(c, r), check.DeepEquals, expect)
+		if err = r.Rewind(); err != nil {
+			c.Fatalf("Failed to Rewind: %v", err)
+		}
+	}
+	r.Close()
+}
+
+func (s *S) TestReadNameOrder(c *check.C) {
+	for _, t := range []struct {
+		entry string
+		name  string
+		meta  interface{}
+	}{
+		{"AC  M1\nNA  first\nNA  second\nID  ident\nP0  A C G T\n01  1 2 3 4\n//\n", "ident", Tags{"NA": {"first", "second"}}},
+		{"AC  M1\nID  ident\nNA  first\nNA  second\nP0  A C G T\n01  1 2 3 4\n//\n", "ident", Tags{"NA": {"first", "second"}}},
+		{"AC  M1\nNA  first\nP0  A C G T\n01  1 2 3 4\n//\n", "first", nil},
+	} {
+		m, err := NewReader(ioutil.NopCloser(strings.NewReader(t.entry))).Read()
+		c.Assert(err, check.IsNil)
+		c.Check(m.Name, check.Equals, t.name)
+		c.Check(m.Meta, check.DeepEquals, t.meta)
+	}
+}
+
+func (s *S) TestWriteTransfac(c *check.C) {
+	o := c.MkDir()
+	w, err := NewWriterName(o + "/t")
+	if err != nil {
+		c.Fatalf("Failed to open %q for write: %v", o+"/t", err)
+	}
+	for _, m := range expect {
+		if _, err = w.Write(m); err != nil {
+			c.Fatalf("Failed to write %q: %v", o+"/t", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		c.Fatalf("Failed to Close %q: %v", o+"/t", err)
+	}
+	r, err := NewReaderName(o + "/t")
+	if err != nil {
+		c.Fatalf("Failed to open %q: %v", o+"/t", err)
+	}
+	c.Check(read(c, r), check.DeepEquals, expect)
+	r.Close()
+
+	b, err := ioutil.ReadFile(o + "/t")
+	c.Check(err, check.IsNil)
+	c.Check(string(b[len(b)-len(arnt):]), check.Equals, arnt)
+}
+
+var arnt = `AC  MA0004.1
+XX
+ID  Arnt
+XX
+P0      A      C      G      T
+01      4     16      0      0      C
+02     19      0      1      0      A
+03      0     20      0      0      C
+04      0      0     20      0      G
+05      0      0      0     20      T
+06      0      0     20      0      G
+XX
+//
+`
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"bufio"
 	"bytes"
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/seq"
 	"github.com/kortschak/BioGo/util"
@@ -38,6 +39,7 @@ type Reader struct {
 	r         *bufio.Reader
 	IDPrefix  []byte
 	SeqPrefix []byte
+	Alphabet  alphabet.Alphabet // If not nil, sequences are given and validated against Alphabet.
 	last      []byte
 }
 
@@ -84,6 +86,11 @@ func (self *Reader) Read() (sequence *seq.Seq, err error) {
 	}
 	if len(label) > 0 && len(body) > 0 {
 		sequence = seq.New(string(label), body, nil)
+		if self.Alphabet != nil {
+			if err = sequence.SetAlphabet(self.Alphabet); err != nil {
+				return nil, err
+			}
+		}
 	} else {
 		return nil, bio.NewError("Invalid fasta entry", 0, nil)
 	}
@@ -16,11 +16,14 @@ package fasta
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/seq"
 	"io"
 	"io/ioutil"
 	check "launchpad.net/gocheck"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -101,6 +104,23 @@ func (s *S) TestReadFasta(c *check.C) {
 	}
 }
 
+func (s *S) TestReadFastaAlphabet(c *check.C) {
+	r := NewReader(ioutil.NopCloser(strings.NewReader(">a\nACGTRYKM\nSWBDHVN\n>b\nACGTJ\n")))
+	r.Alphabet = alphabet.RNAredundant
+	_, err := r.Read()
+	c.Check(err, check.NotNil)
+
+	r = NewReader(ioutil.NopCloser(strings.NewReader(">a\nACGTRYKM\nSWBDHVN\n>b\nACGTJ\n")))
+	r.Alphabet = alphabet.DNAredundant
+	sq, err := r.Read()
+	c.Assert(err, check.IsNil)
+	c.Check(string(sq.Seq), check.Equals, "ACGTRYKMSWBDHVN")
+	c.Check(sq.Alphabet, check.Equals, alphabet.Alphabet(alphabet.DNAredundant))
+	c.Check(sq.Moltype(), check.Equals, bio.DNA)
+	_, err = r.Read()
+	c.Check(err, check.NotNil)
+}
+
 func (s *S) TestWriteFasta(c *check.C) {
 	fa := fas[0]
 	o := c.MkDir()
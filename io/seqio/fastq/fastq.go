@@ -19,6 +19,7 @@ package fastq
 import (
 	"bufio"
 	"bytes"
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/seq"
 	"io"
@@ -52,6 +53,7 @@ type Reader struct {
 	f        io.ReadCloser
 	r        *bufio.Reader
 	Encoding seq.Encoding
+	Alphabet alphabet.Alphabet // If not nil, sequences are given and validated against Alphabet.
 }
 
 // Returns a new fastq format reader using r.
@@ -120,6 +122,11 @@ READ:
 
 	labelString := string(label)
 	sequence = seq.New(labelString, seqBody, seq.NewQuality(labelString, self.decodeQuality(qualBody)))
+	if self.Alphabet != nil {
+		if err = sequence.SetAlphabet(self.Alphabet); err != nil {
+			return nil, err
+		}
+	}
 
 	return
 }
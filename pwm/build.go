@@ -0,0 +1,286 @@
+package pwm
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/index/kmerindex"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+)
+
+// A Background holds nucleotide frequencies in alphabet index order.
+type Background []float64
+
+// Uniform is the equiprobable nucleotide background.
+var Uniform = Background{0.25, 0.25, 0.25, 0.25}
+
+// Return a Background reflecting the composition of the valid bases in the sequences s.
+// Sequences on both strands are counted if bothStrands is true.
+func BackgroundOf(bothStrands bool, s ...*seq.Seq) (b Background, err error) {
+	b = make(Background, 4)
+	for _, sq := range s {
+		index := LetterIndex(sq, len(b))
+		for _, l := range sq.Seq {
+			if base := index[l]; base >= 0 {
+				b[base]++
+				if bothStrands {
+					b[3-base]++
+				}
+			}
+		}
+	}
+
+	return b.normalise()
+}
+
+// Return a Background reflecting the base composition described by a set of kmer frequencies,
+// such as those returned by kmerindex.Index.NormalisedKmerFrequencies.
+func BackgroundOfKmers(k int, freqs map[kmerindex.Kmer]float64) (b Background, err error) {
+	b = make(Background, 4)
+	for kmer, f := range freqs {
+		for i := 0; i < k; i, kmer = i+1, kmer>>2 {
+			b[kmer&3] += f
+		}
+	}
+
+	return b.normalise()
+}
+
+func (self Background) normalise() (b Background, err error) {
+	if len(self) != 4 {
+		return nil, bio.NewError("Background length does not match alphabet", 0, self)
+	}
+	var sum float64
+	for _, f := range self {
+		if f < 0 {
+			return nil, bio.NewError("Negative background frequency", 0, self)
+		}
+		sum += f
+	}
+	if sum == 0 {
+		return nil, bio.NewError("No background frequency information", 0, self)
+	}
+	b = make(Background, len(self))
+	for i, f := range self {
+		b[i] = f / sum
+	}
+
+	return
+}
+
+// Return a position count matrix for the columns of an alignment. Letters not in the four letter
+// nucleotide alphabet of each sequence, including gaps, are not counted.
+func CountsOf(a seq.Alignment) (counts [][]float64, err error) {
+	if len(a) == 0 {
+		return nil, bio.NewError("Empty alignment", 0, a)
+	}
+	start := a.Start()
+	counts = make([][]float64, a.Len())
+	for i := range counts {
+		counts[i] = make([]float64, 4)
+	}
+	for _, s := range a {
+		index := LetterIndex(s, 4)
+		for i, l := range s.Seq {
+			if base := index[l]; base >= 0 {
+				counts[s.Offset-start+i][base]++
+			}
+		}
+	}
+
+	return
+}
+
+// Return a position probability matrix from counts, adding a total of pseudocount pseudocounts
+// to each position distributed according to bg. If bg is nil a uniform background is used.
+func Frequencies(counts [][]float64, pseudocount float64, bg Background) (probs [][]float64, err error) {
+	if bg == nil {
+		bg = Uniform
+	}
+	if bg, err = bg.normalise(); err != nil {
+		return
+	}
+	if pseudocount < 0 {
+		return nil, bio.NewError("Negative pseudocount", 0, pseudocount)
+	}
+
+	probs = make([][]float64, len(counts))
+	for i, row := range counts {
+		if len(row) != len(bg) {
+			return nil, bio.NewError("Count row length does not match background", 0, i, row, bg)
+		}
+		var n float64
+		for _, c := range row {
+			n += c
+		}
+		probs[i] = make([]float64, len(row))
+		for j, c := range row {
+			if n+pseudocount > 0 {
+				probs[i][j] = (c + pseudocount*bg[j]) / (n + pseudocount)
+			} else {
+				probs[i][j] = bg[j]
+			}
+		}
+	}
+
+	return
+}
+
+// Return the log2-odds scores of probs against the background bg. Letters with zero probability
+// are given a score of negative infinity, so a non-zero pseudocount should be used when
+// calculating probs if this is not wanted.
+func LogOdds(probs [][]float64, bg Background) (lo [][]float64, err error) {
+	if bg == nil {
+		bg = Uniform
+	}
+	if bg, err = bg.normalise(); err != nil {
+		return
+	}
+
+	lo = make([][]float64, len(probs))
+	for i, row := range probs {
+		if len(row) != len(bg) {
+			return nil, bio.NewError("Probability row length does not match background", 0, i, row, bg)
+		}
+		lo[i] = make([]float64, len(row))
+		for j, p := range row {
+			lo[i][j] = math.Log2(p / bg[j])
+		}
+	}
+
+	return
+}
+
+// Return the information content in bits of each position of probs relative to the background
+// bg. If bg is nil a uniform background is used.
+func InformationContent(probs [][]float64, bg Background) (ic []float64, err error) {
+	if bg == nil {
+		bg = Uniform
+	}
+	if bg, err = bg.normalise(); err != nil {
+		return
+	}
+
+	ic = make([]float64, len(probs))
+	for i, row := range probs {
+		if len(row) != len(bg) {
+			return nil, bio.NewError("Probability row length does not match background", 0, i, row, bg)
+		}
+		for j, p := range row {
+			if p > 0 {
+				ic[i] += p * math.Log2(p/bg[j])
+			}
+		}
+	}
+
+	return
+}
+
+// Return a log-odds PWM built from a position count matrix using a total of pseudocount
+// pseudocounts at each position distributed according to the background bg. If bg is nil
+// a uniform background is used.
+func NewFromCounts(counts [][]float64, pseudocount float64, bg Background) (m *PWM, err error) {
+	if bg == nil {
+		bg = Uniform
+	}
+	if bg, err = bg.normalise(); err != nil {
+		return
+	}
+
+	var probs, lo [][]float64
+	if probs, err = Frequencies(counts, pseudocount, bg); err != nil {
+		return
+	}
+	if lo, err = LogOdds(probs, bg); err != nil {
+		return
+	}
+
+	m = New(lo)
+	m.probs = probs
+	m.Background = bg
+
+	return
+}
+
+// Return a log-odds PWM built from the columns of an alignment. Pseudocounts and background
+// are handled as described for NewFromCounts.
+func NewFromAlignment(a seq.Alignment, pseudocount float64, bg Background) (m *PWM, err error) {
+	var counts [][]float64
+	if counts, err = CountsOf(a); err != nil {
+		return
+	}
+
+	return NewFromCounts(counts, pseudocount, bg)
+}
+
+// Return a position probability matrix from a matrix of non-negative values. If any value
+// is negative, nil is returned.
+func frequencies(matrix [][]float64) (probs [][]float64) {
+	probs = make([][]float64, len(matrix))
+	for i, row := range matrix {
+		var n float64
+		for _, v := range row {
+			if v < 0 || math.IsNaN(v) {
+				return nil
+			}
+			n += v
+		}
+		probs[i] = make([]float64, len(row))
+		for j, v := range row {
+			if n > 0 {
+				probs[i][j] = v / n
+			} else {
+				probs[i][j] = 1 / float64(len(row))
+			}
+		}
+	}
+
+	return
+}
+
+// Return the length of the PWM.
+func (self *PWM) Len() int {
+	return len(self.matrix)
+}
+
+// Return a copy of the position probability matrix underlying the PWM, or nil if it is not known.
+func (self *PWM) Probs() (probs [][]float64) {
+	if self.probs == nil {
+		return nil
+	}
+	probs = make([][]float64, len(self.probs))
+	for i, row := range self.probs {
+		probs[i] = append([]float64(nil), row...)
+	}
+
+	return
+}
+
+// Return the information content in bits of each position of the PWM relative to the PWM's
+// Background, or to a uniform background if Background is nil. Returns nil if the probability
+// matrix underlying the PWM is not known.
+func (self *PWM) InformationContent() []float64 {
+	if self.probs == nil {
+		return nil
+	}
+	ic, err := InformationContent(self.probs, self.Background)
+	if err != nil {
+		return nil
+	}
+
+	return ic
+}
@@ -0,0 +1,111 @@
+package compare
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"math"
+	"sort"
+)
+
+// Linkage methods for hierarchical clustering.
+const (
+	Single   Linkage = iota // Distance between the closest members of two clusters.
+	Complete                // Distance between the most distant members of two clusters.
+	Average                 // Mean distance between the members of two clusters (UPGMA).
+)
+
+type Linkage int8
+
+// A Cluster is a node of the dendrogram produced by hierarchical clustering. Leaf nodes
+// have no children and hold the index of a single motif.
+type Cluster struct {
+	Left, Right *Cluster
+	Members     []int   // Indices of the motifs in the cluster, in ascending order.
+	Height      float64 // Distance at which the children were joined, zero for leaves.
+}
+
+// Return the root of a dendrogram built by agglomerative clustering of the items described
+// by the symmetric distance matrix d using the specified linkage.
+func Hierarchical(d [][]float64, linkage Linkage) (root *Cluster, err error) {
+	if len(d) == 0 {
+		return nil, bio.NewError("Empty distance matrix", 0, d)
+	}
+	for i := range d {
+		if len(d[i]) != len(d) {
+			return nil, bio.NewError("Distance matrix not square", 0, i)
+		}
+	}
+
+	var (
+		active = make([]*Cluster, len(d))
+		dist   = make([][]float64, len(d))
+	)
+	for i := range d {
+		active[i] = &Cluster{Members: []int{i}}
+		dist[i] = append([]float64(nil), d[i]...)
+	}
+
+	for n := len(d); n > 1; n-- {
+		bi, bj, best := -1, -1, math.Inf(1)
+		for i, a := range active {
+			if a == nil {
+				continue
+			}
+			for j := i + 1; j < len(active); j++ {
+				if active[j] != nil && (bi < 0 || dist[i][j] < best) {
+					bi, bj, best = i, j, dist[i][j]
+				}
+			}
+		}
+
+		a, b := active[bi], active[bj]
+		for k, c := range active {
+			if c == nil || k == bi || k == bj {
+				continue
+			}
+			switch linkage {
+			case Single:
+				dist[bi][k] = math.Min(dist[bi][k], dist[bj][k])
+			case Complete:
+				dist[bi][k] = math.Max(dist[bi][k], dist[bj][k])
+			case Average:
+				na, nb := float64(len(a.Members)), float64(len(b.Members))
+				dist[bi][k] = (na*dist[bi][k] + nb*dist[bj][k]) / (na + nb)
+			default:
+				return nil, bio.NewError("Unknown linkage", 0, linkage)
+			}
+			dist[k][bi] = dist[bi][k]
+		}
+
+		members := append(append([]int(nil), a.Members...), b.Members...)
+		sort.Ints(members)
+		active[bi] = &Cluster{Left: a, Right: b, Members: members, Height: best}
+		active[bj] = nil
+	}
+
+	return active[0], nil
+}
+
+// Return the member sets of the clusters formed by cutting the dendrogram at height h. Nodes
+// joined at a height no greater than h are placed in the same cluster.
+func (self *Cluster) Cut(h float64) (clusters [][]int) {
+	if self.Left == nil || self.Height <= h {
+		return [][]int{append([]int(nil), self.Members...)}
+	}
+
+	return append(self.Left.Cut(h), self.Right.Cut(h)...)
+}
@@ -0,0 +1,212 @@
+// Package for comparing and clustering nucleotide motifs
+//
+// Motif alignment p-values are calculated following the approach of Gupta et al., "Quantifying
+// similarity between motifs", Genome Biology 8:R24 (2007).
+package compare
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/pwm"
+	"math"
+)
+
+// A Metric returns the similarity of two motif columns holding letter probabilities. Larger
+// values indicate greater similarity.
+type Metric func(a, b []float64) float64
+
+// Smallest probability used when calculating Kullback-Leibler divergence.
+const epsilon = 1e-6
+
+// Return the Pearson correlation coefficient of columns a and b. Columns with no variance
+// have a correlation of zero with all other columns.
+func Pearson(a, b []float64) float64 {
+	var ma, mb float64
+	for i := range a {
+		ma += a[i]
+		mb += b[i]
+	}
+	ma /= float64(len(a))
+	mb /= float64(len(b))
+
+	var sab, saa, sbb float64
+	for i := range a {
+		da, db := a[i]-ma, b[i]-mb
+		sab += da * db
+		saa += da * da
+		sbb += db * db
+	}
+	if saa == 0 || sbb == 0 {
+		return 0
+	}
+
+	return sab / math.Sqrt(saa*sbb)
+}
+
+// Return the negated Euclidean distance between columns a and b.
+func Euclidean(a, b []float64) float64 {
+	var d float64
+	for i := range a {
+		d += (a[i] - b[i]) * (a[i] - b[i])
+	}
+
+	return -math.Sqrt(d)
+}
+
+// Return the negated average of the Kullback-Leibler divergences, in bits, of a from b and
+// of b from a. Probabilities are bounded below by a small constant to avoid infinite divergence.
+func KullbackLeibler(a, b []float64) float64 {
+	var d float64
+	for i := range a {
+		pa, pb := math.Max(a[i], epsilon), math.Max(b[i], epsilon)
+		d += pa*math.Log2(pa/pb) + pb*math.Log2(pb/pa)
+	}
+
+	return -d / 2
+}
+
+// Return the probability matrices of a set of PWMs. An error is returned if the probability
+// matrix of a PWM is not known.
+func Matrices(m ...*pwm.PWM) (matrices [][][]float64, err error) {
+	matrices = make([][][]float64, len(m))
+	for i, p := range m {
+		if matrices[i] = p.Probs(); matrices[i] == nil {
+			return nil, bio.NewError("PWM has no probability matrix", 0, i)
+		}
+	}
+
+	return
+}
+
+// Return the reverse complement of a probability matrix with columns in nucleotide alphabet index order.
+func RevComp(m [][]float64) (rc [][]float64) {
+	rc = make([][]float64, len(m))
+	for i, row := range m {
+		rc[len(m)-i-1] = make([]float64, len(row))
+		for j, v := range row {
+			rc[len(m)-i-1][len(row)-j-1] = v
+		}
+	}
+
+	return
+}
+
+// An Alignment describes the ungapped alignment of a query motif against a target motif.
+type Alignment struct {
+	Target  int     // Index of the target motif.
+	Offset  int     // Position in the target of the first query column, which may be negative.
+	Strand  int8    // Orientation of the query, -1 if the query is reverse complemented.
+	Overlap int     // Number of aligned columns.
+	Score   float64 // Sum of the aligned column scores.
+	PValue  float64 // Probability of a score at least as good at any offset and orientation.
+	EValue  float64 // Expected number of targets aligning at least as well.
+}
+
+// Call fn for each ungapped alignment of query against target with at least minOverlap
+// aligned columns, giving the offset of the query in the target and the aligned range of
+// query columns.
+func offsets(query, target [][]float64, minOverlap int, fn func(offset, start, end int)) {
+	for offset := minOverlap - len(query); offset <= len(target)-minOverlap; offset++ {
+		start, end := 0, len(query)
+		if offset < 0 {
+			start = -offset
+		}
+		if offset+end > len(target) {
+			end = len(target) - offset
+		}
+		if end-start >= minOverlap {
+			fn(offset, start, end)
+		}
+	}
+}
+
+// Return the best scoring ungapped alignment of query against target using metric to score
+// aligned columns. Both orientations of the query are considered if bothStrands is true.
+// No p-value is calculated; the PValue and EValue fields of the returned Alignment are zero.
+func Align(query, target [][]float64, metric Metric, minOverlap int, bothStrands bool) (a *Alignment, err error) {
+	if minOverlap < 1 || minOverlap > len(query) || minOverlap > len(target) {
+		return nil, bio.NewError("Minimum overlap out of range", 0, minOverlap)
+	}
+
+	queries := [][][]float64{query}
+	if bothStrands {
+		queries = append(queries, RevComp(query))
+	}
+	for k, q := range queries {
+		strand := int8(1 - 2*k)
+		offsets(q, target, minOverlap, func(offset, start, end int) {
+			var score float64
+			for i := start; i < end; i++ {
+				score += metric(q[i], target[offset+i])
+			}
+			if a == nil || score > a.Score {
+				a = &Alignment{Offset: offset, Strand: strand, Overlap: end - start, Score: score}
+			}
+		})
+	}
+
+	return
+}
+
+// Return a dissimilarity between motifs a and b based on their best ungapped alignment.
+// The dissimilarity is the mean over aligned column pairs of the average of each column's
+// score against itself less the score of the pair, so identical motifs have a dissimilarity
+// of zero.
+func Distance(a, b [][]float64, metric Metric, minOverlap int, bothStrands bool) (d float64, err error) {
+	var (
+		queries = [][][]float64{a}
+		best    = math.Inf(1)
+	)
+	if minOverlap < 1 || minOverlap > len(a) || minOverlap > len(b) {
+		return 0, bio.NewError("Minimum overlap out of range", 0, minOverlap)
+	}
+	if bothStrands {
+		queries = append(queries, RevComp(a))
+	}
+	for _, q := range queries {
+		offsets(q, b, minOverlap, func(offset, start, end int) {
+			var sum float64
+			for i := start; i < end; i++ {
+				qi, ti := q[i], b[offset+i]
+				sum += (metric(qi, qi)+metric(ti, ti))/2 - metric(qi, ti)
+			}
+			if sum /= float64(end - start); sum < best {
+				best = sum
+			}
+		})
+	}
+
+	return best, nil
+}
+
+// Return the matrix of pairwise Distances between a set of motifs.
+func DistanceMatrix(motifs [][][]float64, metric Metric, minOverlap int, bothStrands bool) (d [][]float64, err error) {
+	d = make([][]float64, len(motifs))
+	for i := range d {
+		d[i] = make([]float64, len(motifs))
+	}
+	for i := range motifs {
+		for j := i + 1; j < len(motifs); j++ {
+			if d[i][j], err = Distance(motifs[i], motifs[j], metric, minOverlap, bothStrands); err != nil {
+				return nil, err
+			}
+			d[j][i] = d[i][j]
+		}
+	}
+
+	return
+}
@@ -0,0 +1,141 @@
+package compare
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	check "launchpad.net/gocheck"
+	"math"
+	"testing"
+)
+
+// Checkers
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+// Return a probability matrix for a consensus string with weight p on the consensus letter.
+func matrix(cons string, p float64) (m [][]float64) {
+	for _, l := range cons {
+		row := make([]float64, 4)
+		for j := range row {
+			if "ACGT"[j] == byte(l) {
+				row[j] = p
+			} else {
+				row[j] = (1 - p) / 3
+			}
+		}
+		m = append(m, row)
+	}
+	return
+}
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func (s *S) TestMetrics(c *check.C) {
+	a, b := []float64{0.7, 0.1, 0.1, 0.1}, []float64{0.1, 0.1, 0.1, 0.7}
+	c.Check(approx(Pearson(a, a), 1), check.Equals, true)
+	c.Check(approx(Pearson(a, b), -1./3), check.Equals, true)
+	c.Check(Pearson(a, []float64{0.25, 0.25, 0.25, 0.25}), check.Equals, 0.)
+	c.Check(approx(Euclidean(a, b), -math.Sqrt(0.72)), check.Equals, true)
+	c.Check(Euclidean(a, a), check.Equals, 0.)
+	c.Check(KullbackLeibler(a, a), check.Equals, 0.)
+	c.Check(approx(KullbackLeibler(a, b), -0.6*math.Log2(7)), check.Equals, true)
+	c.Check(approx(KullbackLeibler(a, b), KullbackLeibler(b, a)), check.Equals, true)
+}
+
+func (s *S) TestAlign(c *check.C) {
+	q := matrix("GATTACA", 0.9)
+	t := matrix("CCGATTACACC", 0.9)
+	a, err := Align(q, t, Pearson, 4, true)
+	c.Assert(err, check.IsNil)
+	c.Check(a.Offset, check.Equals, 2)
+	c.Check(a.Strand, check.Equals, int8(1))
+	c.Check(a.Overlap, check.Equals, 7)
+
+	a, err = Align(RevComp(q), t, Pearson, 4, true)
+	c.Assert(err, check.IsNil)
+	c.Check(a.Offset, check.Equals, 2)
+	c.Check(a.Strand, check.Equals, int8(-1))
+
+	a, err = Align(matrix("CACCT", 0.9), t, Euclidean, 3, false)
+	c.Assert(err, check.IsNil)
+	c.Check(a.Offset, check.Equals, 7)
+	c.Check(a.Overlap, check.Equals, 4)
+
+	_, err = Align(q, t, Pearson, 8, true)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestCompare(c *check.C) {
+	targets := [][][]float64{
+		matrix("CCGTAAAT", 0.85),
+		matrix("TTGCGCAA", 0.85),
+		matrix("GGGATTACAGG", 0.85),
+		matrix("ACACACAC", 0.85),
+		matrix("CTCTAGAG", 0.85),
+	}
+	cmp := NewComparer(targets, Pearson)
+	hits, err := cmp.Compare(matrix("GATTACA", 0.9))
+	c.Assert(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, len(targets))
+	c.Check(hits[0].Target, check.Equals, 2)
+	c.Check(hits[0].Offset, check.Equals, 2)
+	c.Check(hits[0].Strand, check.Equals, int8(1))
+	c.Check(hits[0].PValue < hits[1].PValue, check.Equals, true)
+	c.Check(approx(hits[0].EValue, hits[0].PValue*float64(len(targets))), check.Equals, true)
+	for _, h := range hits {
+		c.Check(h.PValue >= 0 && h.PValue <= 1, check.Equals, true)
+	}
+
+	hits, err = cmp.Compare(RevComp(matrix("GATTACA", 0.9)))
+	c.Assert(err, check.IsNil)
+	c.Check(hits[0].Target, check.Equals, 2)
+	c.Check(hits[0].Strand, check.Equals, int8(-1))
+}
+
+func (s *S) TestCluster(c *check.C) {
+	motifs := [][][]float64{
+		matrix("GATTACA", 0.9),
+		matrix("CCGCGGA", 0.9),
+		matrix("GATTACA", 0.8),
+		matrix("TCCGCGG", 0.85),
+		matrix("TGTAATC", 0.9), // Reverse complement of GATTACA.
+	}
+	d, err := DistanceMatrix(motifs, Euclidean, 5, true)
+	c.Assert(err, check.IsNil)
+	c.Check(d[0][0], check.Equals, 0.)
+	c.Check(approx(d[0][4], 0), check.Equals, true)
+	c.Check(d[0][1], check.Equals, d[1][0])
+
+	for _, l := range []Linkage{Single, Complete, Average} {
+		root, err := Hierarchical(d, l)
+		c.Assert(err, check.IsNil)
+		c.Check(root.Members, check.DeepEquals, []int{0, 1, 2, 3, 4})
+		c.Check(root.Cut(math.Inf(1)), check.DeepEquals, [][]int{{0, 1, 2, 3, 4}})
+		clusters := root.Cut(0.3)
+		c.Check(len(clusters), check.Equals, 2, check.Commentf("linkage %d: %v", l, clusters))
+		for _, cl := range clusters {
+			if cl[0] == 0 {
+				c.Check(cl, check.DeepEquals, []int{0, 2, 4})
+			} else {
+				c.Check(cl, check.DeepEquals, []int{1, 3})
+			}
+		}
+		c.Check(len(root.Cut(-1)), check.Equals, len(motifs))
+	}
+}
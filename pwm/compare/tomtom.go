@@ -0,0 +1,231 @@
+package compare
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"math"
+	"sort"
+)
+
+var (
+	DefaultBins       = 100 // Default number of bins used to discretise column scores.
+	DefaultMinOverlap = 5   // Default minimum number of aligned columns.
+)
+
+// A Comparer aligns query motifs against a set of target motifs, calculating the significance
+// of each alignment. The null distribution of each query column's score is the distribution of
+// its scores against all columns of the targets.
+type Comparer struct {
+	Targets     [][][]float64 // Target probability matrices with columns in alphabet index order.
+	Metric      Metric
+	MinOverlap  int // Minimum number of aligned columns, reduced for motifs shorter than this.
+	BothStrands bool
+	Bins        int // Number of bins used to discretise column scores.
+}
+
+// Return a new Comparer using the target motifs and metric.
+func NewComparer(targets [][][]float64, metric Metric) *Comparer {
+	return &Comparer{
+		Targets:     targets,
+		Metric:      metric,
+		MinOverlap:  DefaultMinOverlap,
+		BothStrands: true,
+		Bins:        DefaultBins,
+	}
+}
+
+// Alignments is a set of Alignments sortable by p-value.
+type Alignments []*Alignment
+
+func (self Alignments) Len() int { return len(self) }
+func (self Alignments) Less(i, j int) bool {
+	if self[i].PValue == self[j].PValue {
+		return self[i].Score > self[j].Score
+	}
+	return self[i].PValue < self[j].PValue
+}
+func (self Alignments) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+// The score distributions of one orientation of a query.
+type null struct {
+	query [][]float64
+	bins  [][]int     // bins[i][c] is the bin of the score of query column i against target column c.
+	hist  [][]float64 // hist[i] is the score distribution of query column i.
+	tails map[[2]int][]float64
+	pmfs  map[[2]int][]float64
+}
+
+// Return the tail distribution of the summed scores of query columns start to end.
+func (self *null) tail(start, end int) []float64 {
+	key := [2]int{start, end}
+	if t, ok := self.tails[key]; ok {
+		return t
+	}
+	pmf := self.pmf(start, end)
+	t := append([]float64(nil), pmf...)
+	for k := len(t) - 2; k >= 0; k-- {
+		t[k] += t[k+1]
+	}
+	self.tails[key] = t
+
+	return t
+}
+
+func (self *null) pmf(start, end int) (p []float64) {
+	key := [2]int{start, end}
+	if p, ok := self.pmfs[key]; ok {
+		return p
+	}
+	if end-start == 1 {
+		p = self.hist[start]
+	} else {
+		prev, h := self.pmf(start, end-1), self.hist[end-1]
+		p = make([]float64, len(prev)+len(h)-1)
+		for i, a := range prev {
+			if a == 0 {
+				continue
+			}
+			for j, b := range h {
+				p[i+j] += a * b
+			}
+		}
+	}
+	self.pmfs[key] = p
+
+	return
+}
+
+// Return the best alignment of query against each target, sorted by p-value. The p-value of
+// each alignment is the probability of the best offset's score under the null distribution,
+// corrected for the number of offsets and orientations tested. The E-value is the p-value
+// multiplied by the number of targets.
+func (self *Comparer) Compare(query [][]float64) (hits Alignments, err error) {
+	if len(query) == 0 || len(self.Targets) == 0 {
+		return nil, bio.NewError("Empty query or target set", 0, query, self.Targets)
+	}
+	if self.Bins < 1 || self.MinOverlap < 1 {
+		return nil, bio.NewError("Invalid comparer parameters", 0, self)
+	}
+
+	var (
+		columns [][]float64
+		starts  = make([]int, len(self.Targets))
+	)
+	for i, t := range self.Targets {
+		starts[i] = len(columns)
+		columns = append(columns, t...)
+	}
+
+	queries := [][][]float64{query}
+	if self.BothStrands {
+		queries = append(queries, RevComp(query))
+	}
+	scores := make([][][]float64, len(queries))
+	min, max := math.Inf(1), math.Inf(-1)
+	for k, q := range queries {
+		scores[k] = make([][]float64, len(q))
+		for i, qc := range q {
+			scores[k][i] = make([]float64, len(columns))
+			for c, tc := range columns {
+				if len(tc) != len(qc) {
+					return nil, bio.NewError("Column length mismatch", 0, qc, tc)
+				}
+				s := self.Metric(qc, tc)
+				scores[k][i][c] = s
+				min, max = math.Min(min, s), math.Max(max, s)
+			}
+		}
+	}
+	width := (max - min) / float64(self.Bins)
+	if width == 0 {
+		width = 1
+	}
+
+	nulls := make([]*null, len(queries))
+	for k, q := range queries {
+		n := &null{
+			query: q,
+			bins:  make([][]int, len(q)),
+			hist:  make([][]float64, len(q)),
+			tails: make(map[[2]int][]float64),
+			pmfs:  make(map[[2]int][]float64),
+		}
+		for i := range q {
+			n.bins[i] = make([]int, len(columns))
+			n.hist[i] = make([]float64, self.Bins+1)
+			for c, s := range scores[k][i] {
+				b := int((s - min) / width)
+				if b > self.Bins {
+					b = self.Bins
+				}
+				n.bins[i][c] = b
+				n.hist[i][b] += 1 / float64(len(columns))
+			}
+		}
+		nulls[k] = n
+	}
+
+	for ti, target := range self.Targets {
+		minOverlap := self.MinOverlap
+		if minOverlap > len(query) {
+			minOverlap = len(query)
+		}
+		if minOverlap > len(target) {
+			minOverlap = len(target)
+		}
+
+		var (
+			best  *Alignment
+			tests int
+		)
+		for k, n := range nulls {
+			strand := int8(1 - 2*k)
+			offsets(n.query, target, minOverlap, func(offset, start, end int) {
+				tests++
+				var (
+					score float64
+					bin   int
+				)
+				for i := start; i < end; i++ {
+					c := starts[ti] + offset + i
+					score += scores[k][i][c]
+					bin += n.bins[i][c]
+				}
+				a := &Alignment{
+					Target:  ti,
+					Offset:  offset,
+					Strand:  strand,
+					Overlap: end - start,
+					Score:   score,
+					PValue:  n.tail(start, end)[bin],
+				}
+				if best == nil || (Alignments{a, best}).Less(0, 1) {
+					best = a
+				}
+			})
+		}
+		if best == nil {
+			continue
+		}
+		best.PValue = -math.Expm1(float64(tests) * math.Log1p(-math.Min(best.PValue, 1)))
+		best.EValue = best.PValue * float64(len(self.Targets))
+		hits = append(hits, best)
+	}
+	sort.Sort(hits)
+
+	return
+}
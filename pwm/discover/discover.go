@@ -0,0 +1,472 @@
+// Package for de novo discovery of nucleotide motifs by expectation maximisation and Gibbs sampling
+//
+// Expectation maximisation follows Bailey and Elkan, "Fitting a mixture model by expectation
+// maximization to discover motifs in biopolymers", Proc. Int. Conf. Intell. Syst. Mol. Biol.
+// 2:28-36 (1994), and Gibbs sampling follows Lawrence et al., "Detecting subtle sequence
+// signals: a Gibbs sampling strategy for multiple alignment", Science 262:208-214 (1993).
+package discover
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/pwm"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Site distribution models.
+const (
+	OOPS  Model = iota // Exactly one site per sequence.
+	ZOOPS              // Zero or one site per sequence.
+	TCM                // Any number of non-overlapping sites per sequence.
+)
+
+type Model int8
+
+func (self Model) String() string {
+	switch self {
+	case OOPS:
+		return "OOPS"
+	case ZOOPS:
+		return "ZOOPS"
+	case TCM:
+		return "TCM"
+	}
+	return "Undefined"
+}
+
+// Letters gives the order of the columns of motif count matrices.
+var Letters = "ACGT"
+
+// Params holds the parameters for a motif search.
+type Params struct {
+	Width         int            // Width of the motifs to find.
+	Model         Model          // Distribution of sites in the sequences.
+	Motifs        int            // Maximum number of motifs to find. Sites of each motif found are masked before the next search.
+	Starts        int            // Number of random starting points used for each motif.
+	MaxIterations int            // Maximum number of iterations for each starting point.
+	Tolerance     float64        // Maximum change in motif probabilities for EM convergence.
+	Pseudocount   float64        // Total pseudocount added to each motif position.
+	Prior         float64        // Initial expected number of sites per sequence for ZOOPS and TCM models.
+	Background    pwm.Background // Background frequencies. If nil the composition of the sequences is used.
+	BothStrands   bool           // Search for sites on both strands.
+	Threads       int            // Number of concurrent workers, GOMAXPROCS if less than 1.
+	Seed          int64          // Seed for the random number generators used by each start.
+}
+
+// DefaultParams is used when a nil *Params is passed to EM or Gibbs.
+var DefaultParams = Params{
+	Width:         8,
+	Model:         ZOOPS,
+	Motifs:        1,
+	Starts:        20,
+	MaxIterations: 200,
+	Tolerance:     1e-4,
+	Pseudocount:   1,
+	Prior:         0.5,
+	BothStrands:   true,
+	Seed:          1,
+}
+
+// A Motif is a discovered motif with the sites that define it.
+type Motif struct {
+	PWM                *pwm.PWM
+	Counts             [][]float64 // Site counts with columns ordered according to Letters.
+	LogLikelihoodRatio float64     // Log2 likelihood ratio of the sites under the motif and the background.
+	EValue             float64
+	Sites              []*feat.Feature // Sites annotated as described for pwm.PWM.Scan.
+	sites              []site
+}
+
+// Return a pwm.Motif describing the Motif, suitable for writing with a motifio.Writer.
+func (self *Motif) Motif(id string) (m *pwm.Motif) {
+	counts := make([][]float64, len(self.Counts))
+	for i, row := range self.Counts {
+		counts[i] = append([]float64(nil), row...)
+	}
+	m = pwm.NewMotif(id, "", Letters, counts, pwm.Counts)
+	m.Name = m.Consensus()
+	m.Sites = len(self.Sites)
+	m.EValue = self.EValue
+
+	return
+}
+
+// Motifs is a set of Motifs sortable by E-value.
+type Motifs []*Motif
+
+func (self Motifs) Len() int { return len(self) }
+func (self Motifs) Less(i, j int) bool {
+	if self[i].EValue == self[j].EValue {
+		return self[i].LogLikelihoodRatio > self[j].LogLikelihoodRatio
+	}
+	return self[i].EValue < self[j].EValue
+}
+func (self Motifs) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+// Return motifs found in sequences by expectation maximisation, ranked by E-value.
+// Each random start is seeded with the letters of a randomly chosen word in the sequences.
+func EM(sequences []*seq.Seq, params *Params) (motifs Motifs, err error) {
+	return discover(sequences, params, (*finder).em)
+}
+
+// Return motifs found in sequences by Gibbs sampling, ranked by E-value. The TCM model
+// is not supported.
+func Gibbs(sequences []*seq.Seq, params *Params) (motifs Motifs, err error) {
+	if params != nil && params.Model == TCM {
+		return nil, bio.NewError("TCM model not supported by Gibbs sampler", 0, params)
+	}
+	return discover(sequences, params, (*finder).gibbs)
+}
+
+// A site is a candidate motif position in a sequence.
+type site struct {
+	seq, pos int
+	strand   int8
+}
+
+// A finder holds the encoded sequences and state shared by all starts of a search.
+type finder struct {
+	params    Params
+	sequences []*seq.Seq
+	codes     [][]int8 // Letter indices of the sequences, -1 for invalid or masked positions.
+	cands     [][]site // Candidate sites in each sequence.
+	bg        pwm.Background
+	logBg     []float64
+}
+
+type search func(self *finder, rnd *rand.Rand) []site
+
+func discover(sequences []*seq.Seq, params *Params, fn search) (motifs Motifs, err error) {
+	if params == nil {
+		p := DefaultParams
+		params = &p
+	}
+	if params.Width < 1 || params.Motifs < 1 || params.Starts < 1 || params.MaxIterations < 1 || params.Pseudocount < 0 {
+		return nil, bio.NewError("Invalid motif search parameters", 0, params)
+	}
+	if params.Model != TCM && (params.Prior <= 0 || params.Prior > 1) ||
+		params.Model == TCM && params.Prior <= 0 {
+		return nil, bio.NewError("Invalid site prior", 0, params.Prior)
+	}
+
+	self := &finder{
+		params:    *params,
+		sequences: sequences,
+		codes:     make([][]int8, len(sequences)),
+		cands:     make([][]site, len(sequences)),
+		bg:        params.Background,
+	}
+	if self.bg == nil {
+		if self.bg, err = pwm.BackgroundOf(params.BothStrands, sequences...); err != nil {
+			return
+		}
+	}
+	if len(self.bg) != len(Letters) {
+		return nil, bio.NewError("Background length does not match letters", 0, self.bg)
+	}
+	var sum float64
+	for _, f := range self.bg {
+		if f <= 0 {
+			return nil, bio.NewError("Non-positive background frequency", 0, self.bg)
+		}
+		sum += f
+	}
+	self.bg = append(pwm.Background(nil), self.bg...)
+	self.logBg = make([]float64, len(self.bg))
+	for i := range self.bg {
+		self.bg[i] /= sum
+		self.logBg[i] = math.Log2(self.bg[i])
+	}
+
+	for i, s := range sequences {
+		index := pwm.LetterIndex(s, len(self.bg))
+		self.codes[i] = make([]int8, s.Len())
+		for j, l := range s.Seq {
+			self.codes[i][j] = int8(index[l])
+		}
+	}
+
+	for n := 0; n < params.Motifs; n++ {
+		if self.candidates() == 0 {
+			break
+		}
+		var m *Motif
+		if m, err = self.best(fn, n); err != nil {
+			return
+		}
+		if m == nil {
+			break
+		}
+		motifs = append(motifs, m)
+		self.mask(m.sites)
+	}
+	if len(motifs) == 0 {
+		return nil, bio.NewError("No motif found", 0, params)
+	}
+	sort.Sort(motifs)
+
+	return
+}
+
+// Find the candidate sites of each sequence, returning the total number of candidates.
+func (self *finder) candidates() (n int) {
+	w := self.params.Width
+	for i, codes := range self.codes {
+		self.cands[i] = self.cands[i][:0]
+		run := 0
+		for j, c := range codes {
+			if c < 0 {
+				run = 0
+				continue
+			}
+			if run++; run >= w {
+				self.cands[i] = append(self.cands[i], site{seq: i, pos: j - w + 1, strand: 1})
+				if self.params.BothStrands {
+					self.cands[i] = append(self.cands[i], site{seq: i, pos: j - w + 1, strand: -1})
+				}
+			}
+		}
+		n += len(self.cands[i])
+	}
+
+	return
+}
+
+// Mask the positions covered by sites so that they are not found by subsequent searches.
+func (self *finder) mask(sites []site) {
+	for _, s := range sites {
+		for j := s.pos; j < s.pos+self.params.Width; j++ {
+			self.codes[s.seq][j] = -1
+		}
+	}
+}
+
+// Run the configured number of random starts concurrently and return the best resulting motif.
+func (self *finder) best(fn search, round int) (m *Motif, err error) {
+	threads := self.params.Threads
+	if available := runtime.GOMAXPROCS(0); threads > available || threads < 1 {
+		threads = available
+	}
+
+	var (
+		results = make([]*Motif, self.params.Starts)
+		errs    = make([]error, self.params.Starts)
+		next    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for t := 0; t < threads; t++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				rnd := rand.New(rand.NewSource(self.params.Seed + int64(round*self.params.Starts+i)))
+				results[i], errs[i] = self.motif(fn(self, rnd))
+			}
+		}()
+	}
+	for i := range results {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for i, r := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if r != nil && (m == nil || Motifs{r, m}.Less(0, 1)) {
+			m = r
+		}
+	}
+
+	return
+}
+
+// Return the letter code of position k of a site, complemented for sites on the reverse strand.
+func (self *finder) letter(s site, k int) int8 {
+	if s.strand < 0 {
+		return 3 - self.codes[s.seq][s.pos+self.params.Width-1-k]
+	}
+	return self.codes[s.seq][s.pos+k]
+}
+
+// Return the log2 likelihood ratio of a site given the log2 motif probabilities.
+func (self *finder) ratio(logTheta [][]float64, s site) (r float64) {
+	for k := range logTheta {
+		b := self.letter(s, k)
+		r += logTheta[k][b] - self.logBg[b]
+	}
+	return
+}
+
+// Add weight w of a site to counts.
+func (self *finder) count(counts [][]float64, s site, w float64) {
+	for k := range counts {
+		counts[k][self.letter(s, k)] += w
+	}
+}
+
+// Return the probabilities of a motif from counts using the search pseudocount.
+func (self *finder) theta(counts [][]float64) (theta [][]float64) {
+	theta = make([][]float64, len(counts))
+	for k, row := range counts {
+		var n float64
+		for _, c := range row {
+			n += c
+		}
+		theta[k] = make([]float64, len(row))
+		for b, c := range row {
+			if n+self.params.Pseudocount > 0 {
+				theta[k][b] = (c + self.params.Pseudocount*self.bg[b]) / (n + self.params.Pseudocount)
+			} else {
+				theta[k][b] = self.bg[b]
+			}
+		}
+	}
+
+	return
+}
+
+func newCounts(w int) (counts [][]float64) {
+	counts = make([][]float64, w)
+	for i := range counts {
+		counts[i] = make([]float64, len(Letters))
+	}
+	return
+}
+
+func log2Matrix(m [][]float64) (l [][]float64) {
+	l = make([][]float64, len(m))
+	for i, row := range m {
+		l[i] = make([]float64, len(row))
+		for j, v := range row {
+			l[i][j] = math.Log2(v)
+		}
+	}
+	return
+}
+
+// Return the log2 likelihood ratio of a set of site counts.
+func (self *finder) llr(counts [][]float64) (r float64) {
+	theta := self.theta(counts)
+	for k, row := range counts {
+		for b, c := range row {
+			if c > 0 {
+				r += c * math.Log2(theta[k][b]/self.bg[b])
+			}
+		}
+	}
+	return
+}
+
+// Build a Motif from a set of sites, annotating each site and calculating the E-value.
+//
+// The E-value is the probability of obtaining a product of site p-values at least as small
+// as that observed, multiplied by the number of random starts. Site p-values are calculated
+// from the score distribution of the motif PWM and corrected for the number of candidate
+// sites in each sequence.
+func (self *finder) motif(sites []site) (m *Motif, err error) {
+	if len(sites) == 0 {
+		return nil, nil
+	}
+
+	m = &Motif{Counts: newCounts(self.params.Width), sites: sites}
+	for _, s := range sites {
+		self.count(m.Counts, s, 1)
+	}
+	m.LogLikelihoodRatio = self.llr(m.Counts)
+	if m.PWM, err = pwm.NewFromCounts(m.Counts, self.params.Pseudocount, self.bg); err != nil {
+		return nil, err
+	}
+
+	var logP float64
+	for _, s := range sites {
+		strand := pwm.Forward
+		if s.strand < 0 {
+			strand = pwm.Reverse
+		}
+		var hits []*feat.Feature
+		if hits, err = m.PWM.Scan(self.sequences[s.seq], s.pos, s.pos+self.params.Width, math.Inf(-1), strand); err != nil {
+			return nil, err
+		}
+		if len(hits) != 1 {
+			return nil, bio.NewError("Unexpected site scan result", 0, s, hits)
+		}
+		m.Sites = append(m.Sites, hits[0])
+
+		n := float64(len(self.cands[s.seq]))
+		p := -math.Expm1(n * math.Log1p(-hits[0].Probability))
+		if p <= 0 {
+			p = math.SmallestNonzeroFloat64
+		}
+		logP += math.Log(p)
+	}
+	m.EValue = productPValue(logP, len(sites)) * float64(self.params.Starts)
+
+	return
+}
+
+// Return the probability that the product of n uniform random variables is no greater
+// than exp(logX).
+func productPValue(logX float64, n int) float64 {
+	if logX >= 0 {
+		return 1
+	}
+	// P = x * sum_{k=0}^{n-1} (-ln x)^k / k!, evaluated in log space.
+	var (
+		l     = -logX
+		terms = make([]float64, n)
+		max   = math.Inf(-1)
+	)
+	for k := range terms {
+		lg, _ := math.Lgamma(float64(k + 1))
+		terms[k] = float64(k)*math.Log(l) - lg
+		if terms[k] > max {
+			max = terms[k]
+		}
+	}
+	var sum float64
+	for _, t := range terms {
+		sum += math.Exp(t - max)
+	}
+
+	return math.Min(1, math.Exp(logX+max+math.Log(sum)))
+}
+
+// Return the log of the sum of the exponents of v.
+func logSumExp(v ...float64) float64 {
+	max := math.Inf(-1)
+	for _, x := range v {
+		if x > max {
+			max = x
+		}
+	}
+	if math.IsInf(max, -1) {
+		return max
+	}
+	var sum float64
+	for _, x := range v {
+		sum += math.Exp(x - max)
+	}
+	return max + math.Log(sum)
+}
@@ -0,0 +1,118 @@
+package discover
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"math"
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// Checkers
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+const planted = "TTGACGCA"
+
+// Return n random sequences of length l with the planted motif at the returned positions.
+func plant(n, l int, seed int64) (s []*seq.Seq, pos []int) {
+	rnd := rand.New(rand.NewSource(seed))
+	for i := 0; i < n; i++ {
+		b := make([]byte, l)
+		for j := range b {
+			b[j] = "acgt"[rnd.Intn(4)]
+		}
+		p := rnd.Intn(l - len(planted))
+		copy(b[p:], planted)
+		s = append(s, seq.New("s"+strconv.Itoa(i), b, nil))
+		pos = append(pos, p)
+	}
+	return
+}
+
+func (s *S) TestEM(c *check.C) {
+	sequences, pos := plant(12, 80, 1)
+	for _, model := range []Model{OOPS, ZOOPS, TCM} {
+		p := DefaultParams
+		p.Model = model
+		p.Width = len(planted)
+		p.BothStrands = false
+		motifs, err := EM(sequences, &p)
+		c.Assert(err, check.IsNil, check.Commentf("model %v", model))
+		c.Assert(len(motifs), check.Equals, 1)
+		m := motifs[0]
+		c.Check(m.Motif("m").Consensus(), check.Equals, planted, check.Commentf("model %v", model))
+		c.Check(m.EValue < 1e-3, check.Equals, true, check.Commentf("model %v E=%v", model, m.EValue))
+		c.Check(len(m.Sites), check.Equals, len(sequences), check.Commentf("model %v", model))
+		for _, f := range m.Sites {
+			i, _ := strconv.Atoi(f.Location[1:])
+			c.Check(f.Start, check.Equals, pos[i])
+			c.Check(f.Attributes[:len(planted)], check.Equals, planted)
+		}
+	}
+}
+
+func (s *S) TestGibbs(c *check.C) {
+	sequences, _ := plant(12, 80, 2)
+	p := DefaultParams
+	p.Width = len(planted)
+	p.Model = OOPS
+	motifs, err := Gibbs(sequences, &p)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(motifs), check.Equals, 1)
+	cons := motifs[0].Motif("m").Consensus()
+	c.Check(cons == planted || cons == "TGCGTCAA", check.Equals, true, check.Commentf("consensus %s", cons))
+
+	p.Model = TCM
+	_, err = Gibbs(sequences, &p)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestMultipleMotifs(c *check.C) {
+	sequences, _ := plant(12, 80, 3)
+	p := DefaultParams
+	p.Width = len(planted)
+	p.Motifs = 2
+	p.Model = ZOOPS
+	motifs, err := EM(sequences, &p)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(motifs), check.Equals, 2)
+	c.Check(motifs[0].EValue <= motifs[1].EValue, check.Equals, true)
+	cons := motifs[0].Motif("m").Consensus()
+	c.Check(cons == planted || cons == "TGCGTCAA", check.Equals, true, check.Commentf("consensus %s", cons))
+}
+
+func (s *S) TestProductPValue(c *check.C) {
+	for _, t := range []struct {
+		x float64
+		n int
+		p float64
+	}{
+		{0.5, 1, 0.5},
+		{0.01, 1, 0.01},
+		{0.01, 2, 0.01 * (1 - math.Log(0.01))},
+		{0.01, 3, 0.01 * (1 - math.Log(0.01) + math.Log(0.01)*math.Log(0.01)/2)},
+		{1, 3, 1},
+	} {
+		c.Check(math.Abs(productPValue(math.Log(t.x), t.n)-t.p) < 1e-12, check.Equals, true, check.Commentf("%v", t))
+	}
+}
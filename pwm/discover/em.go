@@ -0,0 +1,214 @@
+package discover
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Probability given to the letters of the word used to start EM.
+const seedWeight = 0.5
+
+// Smallest prior probability of a site used during EM.
+const minPrior = 1e-6
+
+// Run EM from a randomly chosen starting word, returning the sites of the converged model.
+func (self *finder) em(rnd *rand.Rand) []site {
+	start, ok := self.randomSite(rnd)
+	if !ok {
+		return nil
+	}
+
+	w := self.params.Width
+	theta := make([][]float64, w)
+	for k := range theta {
+		theta[k] = make([]float64, len(Letters))
+		b := self.letter(start, k)
+		for j := range theta[k] {
+			if int8(j) == b {
+				theta[k][j] = seedWeight
+			} else {
+				theta[k][j] = (1 - seedWeight) / float64(len(Letters)-1)
+			}
+		}
+	}
+
+	var n, total int
+	for _, c := range self.cands {
+		if len(c) > 0 {
+			n++
+			total += len(c)
+		}
+	}
+	prior := self.params.Prior
+	if self.params.Model == TCM {
+		prior = math.Min(prior*float64(n)/float64(total), 1/float64(w))
+	}
+
+	var z [][]float64
+	for i := 0; i < self.params.MaxIterations; i++ {
+		var sum float64
+		z, sum = self.expect(theta, prior)
+
+		counts := newCounts(w)
+		for j, cands := range self.cands {
+			for k, s := range cands {
+				self.count(counts, s, z[j][k])
+			}
+		}
+		next := self.theta(counts)
+
+		switch self.params.Model {
+		case ZOOPS:
+			prior = math.Max(minPrior, math.Min(1-minPrior, sum/float64(n)))
+		case TCM:
+			prior = math.Max(minPrior, math.Min(1/float64(w), sum/float64(total)))
+		}
+
+		var delta float64
+		for k := range theta {
+			for j := range theta[k] {
+				delta = math.Max(delta, math.Abs(next[k][j]-theta[k][j]))
+			}
+		}
+		theta = next
+		if delta < self.params.Tolerance {
+			break
+		}
+	}
+	z, _ = self.expect(theta, prior)
+
+	return self.sites(z)
+}
+
+// Return the posterior probability of each candidate site being a motif site under the
+// model theta with site prior probability prior, and the sum of the posteriors.
+func (self *finder) expect(theta [][]float64, prior float64) (z [][]float64, sum float64) {
+	logTheta := log2Matrix(theta)
+	z = make([][]float64, len(self.cands))
+	for i, cands := range self.cands {
+		if len(cands) == 0 {
+			continue
+		}
+		lr := make([]float64, len(cands))
+		for j, s := range cands {
+			lr[j] = self.ratio(logTheta, s) * math.Ln2
+		}
+
+		z[i] = make([]float64, len(cands))
+		switch self.params.Model {
+		case OOPS:
+			norm := logSumExp(lr...)
+			for j, r := range lr {
+				z[i][j] = math.Exp(r - norm)
+			}
+		case ZOOPS:
+			lp := math.Log(prior / float64(len(cands)))
+			for j := range lr {
+				lr[j] += lp
+			}
+			norm := logSumExp(append(lr, math.Log(1-prior))...)
+			for j, r := range lr {
+				z[i][j] = math.Exp(r - norm)
+			}
+		case TCM:
+			odds := math.Log(1-prior) - math.Log(prior)
+			for j, r := range lr {
+				z[i][j] = 1 / (1 + math.Exp(odds-r))
+			}
+		}
+		for _, p := range z[i] {
+			sum += p
+		}
+	}
+
+	return
+}
+
+type candidate struct {
+	site
+	z float64
+}
+
+type byPosterior []candidate
+
+func (self byPosterior) Len() int           { return len(self) }
+func (self byPosterior) Less(i, j int) bool { return self[i].z > self[j].z }
+func (self byPosterior) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+
+// Return the sites implied by the posterior probabilities z. For OOPS the best site in
+// each sequence is returned, for ZOOPS the best site if its posterior exceeds one half and
+// for TCM all non-overlapping sites with posteriors exceeding one half.
+func (self *finder) sites(z [][]float64) (sites []site) {
+	for i, cands := range self.cands {
+		if len(cands) == 0 {
+			continue
+		}
+		var found []candidate
+		for j, s := range cands {
+			found = append(found, candidate{s, z[i][j]})
+		}
+		sort.Stable(byPosterior(found))
+
+		switch self.params.Model {
+		case OOPS:
+			sites = append(sites, found[0].site)
+		case ZOOPS:
+			if found[0].z > 0.5 {
+				sites = append(sites, found[0].site)
+			}
+		case TCM:
+			var accepted []site
+		SITES:
+			for _, c := range found {
+				if c.z <= 0.5 {
+					break
+				}
+				for _, a := range accepted {
+					if c.pos < a.pos+self.params.Width && a.pos < c.pos+self.params.Width {
+						continue SITES
+					}
+				}
+				accepted = append(accepted, c.site)
+			}
+			sites = append(sites, accepted...)
+		}
+	}
+
+	return
+}
+
+// Return a randomly chosen candidate site.
+func (self *finder) randomSite(rnd *rand.Rand) (s site, ok bool) {
+	var total int
+	for _, c := range self.cands {
+		total += len(c)
+	}
+	if total == 0 {
+		return s, false
+	}
+	n := rnd.Intn(total)
+	for _, c := range self.cands {
+		if n < len(c) {
+			return c[n], true
+		}
+		n -= len(c)
+	}
+
+	return s, false
+}
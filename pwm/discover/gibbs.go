@@ -0,0 +1,94 @@
+package discover
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Run a site sampler from a random alignment, returning the sites of the highest scoring
+// alignment visited. Under ZOOPS each sequence may also be sampled as having no site with
+// probability determined by the Prior parameter.
+func (self *finder) gibbs(rnd *rand.Rand) (best []site) {
+	w := self.params.Width
+	current := make([]int, len(self.cands)) // Index of the current site in each sequence, -1 for none.
+	counts := newCounts(w)
+	for i, cands := range self.cands {
+		if len(cands) == 0 {
+			current[i] = -1
+			continue
+		}
+		current[i] = rnd.Intn(len(cands))
+		self.count(counts, cands[current[i]], 1)
+	}
+
+	bestScore := math.Inf(-1)
+	for iter := 0; iter < self.params.MaxIterations; iter++ {
+		for i, cands := range self.cands {
+			if len(cands) == 0 {
+				continue
+			}
+			if current[i] >= 0 {
+				self.count(counts, cands[current[i]], -1)
+			}
+
+			logTheta := log2Matrix(self.theta(counts))
+			weights := make([]float64, len(cands), len(cands)+1)
+			for j, s := range cands {
+				weights[j] = self.ratio(logTheta, s) * math.Ln2
+			}
+			if self.params.Model == ZOOPS {
+				lp := math.Log(self.params.Prior / float64(len(cands)))
+				for j := range weights {
+					weights[j] += lp
+				}
+				weights = append(weights, math.Log(1-self.params.Prior))
+			}
+
+			current[i] = sample(rnd, weights)
+			if current[i] == len(cands) {
+				current[i] = -1
+			} else {
+				self.count(counts, cands[current[i]], 1)
+			}
+		}
+
+		if score := self.llr(counts); score > bestScore {
+			bestScore = score
+			best = best[:0]
+			for i, j := range current {
+				if j >= 0 {
+					best = append(best, self.cands[i][j])
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// Return an index into logWeights sampled in proportion to the exponents of the weights.
+func sample(rnd *rand.Rand, logWeights []float64) int {
+	norm := logSumExp(logWeights...)
+	u := rnd.Float64()
+	for i, lw := range logWeights {
+		if u -= math.Exp(lw - norm); u < 0 {
+			return i
+		}
+	}
+	return len(logWeights) - 1
+}
@@ -0,0 +1,156 @@
+package pwm
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"strings"
+)
+
+// Matrix types held by a Motif.
+const (
+	Counts MatrixType = iota
+	Probabilities
+)
+
+type MatrixType int8
+
+func (self MatrixType) String() string {
+	switch self {
+	case Counts:
+		return "Counts"
+	case Probabilities:
+		return "Probabilities"
+	}
+	return "Undefined"
+}
+
+// A Motif holds a position count or probability matrix and the descriptive information
+// associated with it by motif databases and discovery tools. Matrix is indexed by
+// position and then by letter, with the letter order given by Letters.
+type Motif struct {
+	ID          string
+	Name        string
+	Description string
+	Letters     string
+	Matrix      [][]float64
+	Type        MatrixType
+	Sites       int     // Number of sites contributing to the matrix, 0 if not known.
+	EValue      float64 // Significance reported for the motif, 0 if not known.
+	Meta        interface{}
+}
+
+// Return a new Motif.
+func NewMotif(id, name, letters string, matrix [][]float64, typ MatrixType) *Motif {
+	return &Motif{
+		ID:      id,
+		Name:    name,
+		Letters: letters,
+		Matrix:  matrix,
+		Type:    typ,
+	}
+}
+
+// Return the number of positions in the Motif.
+func (self *Motif) Len() int {
+	return len(self.Matrix)
+}
+
+// Return a probability matrix for the Motif with letters ordered according to Letters.
+// Positions with no counts are given uniform probabilities.
+func (self *Motif) Probs() (p [][]float64) {
+	p = make([][]float64, len(self.Matrix))
+	for i, row := range self.Matrix {
+		p[i] = make([]float64, len(row))
+		var sum float64
+		for _, v := range row {
+			sum += v
+		}
+		for j, v := range row {
+			if sum > 0 {
+				p[i][j] = v / sum
+			} else {
+				p[i][j] = 1 / float64(len(row))
+			}
+		}
+	}
+
+	return
+}
+
+// Return the matrix of the Motif with columns reordered to match the letter indices of Alphabet.
+// An error is returned if a nucleotide letter is missing from Letters.
+func (self *Motif) Nucleic() (m [][]float64, err error) {
+	index := make([]int, 4)
+	for i := range index {
+		index[i] = -1
+	}
+	for i, l := range self.Letters {
+		if l > 0xff {
+			continue
+		}
+		if code := Alphabet.IndexOf(byte(l)); code >= 0 && code < len(index) {
+			index[code] = i
+		}
+	}
+	for i, j := range index {
+		if j < 0 {
+			return nil, bio.NewError("Motif letters missing nucleotide", 0, string(Alphabet.Letter(i)), self.Letters)
+		}
+	}
+
+	m = make([][]float64, len(self.Matrix))
+	for i, row := range self.Matrix {
+		if len(row) != len(self.Letters) {
+			return nil, bio.NewError("Matrix row length does not match letters", 0, i, self.Letters)
+		}
+		m[i] = make([]float64, 4)
+		for code, j := range index {
+			m[i][code] = row[j]
+		}
+	}
+
+	return
+}
+
+// Return a PWM based on the Motif's nucleotide matrix.
+func (self *Motif) PWM() (p *PWM, err error) {
+	var m [][]float64
+	if m, err = self.Nucleic(); err != nil {
+		return
+	}
+	return New(m), nil
+}
+
+// Return the consensus sequence of the Motif, using the most frequent letter at each position.
+func (self *Motif) Consensus() string {
+	c := make([]byte, len(self.Matrix))
+	for i, row := range self.Matrix {
+		max, best := -1., 0
+		for j, v := range row {
+			if v > max {
+				max, best = v, j
+			}
+		}
+		if best < len(self.Letters) {
+			c[i] = self.Letters[best]
+		} else {
+			c[i] = 'N'
+		}
+	}
+
+	return strings.ToUpper(string(c))
+}
@@ -0,0 +1,339 @@
+package pwm
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/seq"
+	"github.com/kortschak/BioGo/util"
+	"math"
+	"strconv"
+)
+
+// Strands to scan.
+const (
+	Forward = 1 << iota
+	Reverse
+	Both = Forward | Reverse
+)
+
+var DefaultResolution = 1e4 // Default number of discrete steps per unit of score used to calculate p-values.
+
+// A distribution holds the upper tail of the score distribution of a PWM under a background model.
+type distribution struct {
+	tail       []float64 // tail[k] is P(S >= (k+low)/resolution)
+	low        int
+	resolution float64
+	background Background
+}
+
+func (self *distribution) valid(resolution float64, bg Background) bool {
+	if self == nil || self.resolution != resolution || len(self.background) != len(bg) {
+		return false
+	}
+	for i, f := range bg {
+		if self.background[i] != f {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the score distribution of the PWM's matrix.
+func (self *PWM) distribution() (d *distribution, err error) {
+	return self.distributionOf(self.matrix, &self.dist)
+}
+
+// Return the score distribution of the reverse complement of the PWM's matrix, which is the
+// distribution of reverse strand scores. This differs from the distribution of the matrix
+// unless the background is complement-symmetric.
+func (self *PWM) rcDistribution() (d *distribution, err error) {
+	return self.distributionOf(self.revComp(), &self.rcDist)
+}
+
+// Calculate the exact distribution of discretised scores of matrix by dynamic programming over
+// its positions, caching the result in cache. Letters with a score of negative infinity can never
+// contribute to a match, so their probability mass is discarded.
+func (self *PWM) distributionOf(matrix [][]float64, cache **distribution) (d *distribution, err error) {
+	bg := self.Background
+	if bg == nil {
+		bg = Uniform
+	}
+	if (*cache).valid(self.Resolution, bg) {
+		return *cache, nil
+	}
+	if self.Resolution <= 0 {
+		return nil, bio.NewError("Non-positive PWM resolution", 0, self.Resolution)
+	}
+	var nbg Background
+	if nbg, err = bg.normalise(); err != nil {
+		return
+	}
+
+	cur, low := []float64{1}, 0
+	for _, row := range matrix {
+		if len(row) != len(nbg) {
+			return nil, bio.NewError("PWM row length does not match background", 0, row, nbg)
+		}
+		scores := make([]int, len(row))
+		min, max := util.MaxInt, util.MinInt
+		for j, v := range row {
+			if math.IsInf(v, -1) {
+				continue
+			}
+			scores[j] = int(math.Floor(v*self.Resolution + 0.5))
+			min, max = util.Min(min, scores[j]), util.Max(max, scores[j])
+		}
+		if min > max {
+			return &distribution{tail: []float64{0}, resolution: self.Resolution, background: bg}, nil
+		}
+		next := make([]float64, len(cur)+max-min)
+		for k, p := range cur {
+			if p == 0 {
+				continue
+			}
+			for j, v := range row {
+				if math.IsInf(v, -1) {
+					continue
+				}
+				next[k+scores[j]-min] += p * nbg[j]
+			}
+		}
+		cur, low = next, low+min
+	}
+
+	for k := len(cur) - 2; k >= 0; k-- {
+		cur[k] += cur[k+1]
+	}
+	*cache = &distribution{
+		tail:       cur,
+		low:        low,
+		resolution: self.Resolution,
+		background: append(Background(nil), bg...),
+	}
+
+	return *cache, nil
+}
+
+// Return the probability of a score at least as great as score occurring at a single position
+// of a sequence drawn from the PWM's Background, or from a uniform background if Background is nil.
+// The score is expressed in the normalised units returned by Search and Scan and is rounded to
+// the PWM's Resolution.
+func (self *PWM) PValue(score float64) (p float64, err error) {
+	var d *distribution
+	if d, err = self.distribution(); err != nil {
+		return
+	}
+
+	return d.pValue(int(math.Floor(score*d.resolution + 0.5))), nil
+}
+
+// Return the upper tail probability of the discretised score k.
+func (self *distribution) pValue(k int) float64 {
+	k -= self.low
+	switch {
+	case k < 0:
+		k = 0
+	case k >= len(self.tail):
+		return 0
+	}
+
+	return self.tail[k]
+}
+
+// Return the smallest achievable normalised forward strand score with a p-value no greater than p.
+// If no score satisfies p, the threshold is returned as positive infinity.
+func (self *PWM) Threshold(p float64) (t float64, err error) {
+	var d *distribution
+	if d, err = self.distribution(); err != nil {
+		return
+	}
+
+	return d.threshold(p), nil
+}
+
+// Return the smallest achievable normalised score with an upper tail probability no greater than p,
+// or positive infinity if there is none.
+func (self *distribution) threshold(p float64) float64 {
+	lo, hi := 0, len(self.tail)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if self.tail[mid] <= p {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	if lo == len(self.tail) {
+		return math.Inf(1)
+	}
+	for lo < len(self.tail)-1 && self.tail[lo] == self.tail[lo+1] { // advance to an achievable score
+		lo++
+	}
+
+	return float64(lo+self.low) / self.resolution
+}
+
+// Return the reverse complement of the PWM's matrix. The complement of the letter
+// with index j in a nucleotide alphabet is the letter with index 3-j.
+func (self *PWM) revComp() (rc [][]float64) {
+	l := len(self.matrix)
+	rc = make([][]float64, l)
+	for i, row := range self.matrix {
+		rc[l-i-1] = make([]float64, len(row))
+		for j, v := range row {
+			rc[l-i-1][len(row)-j-1] = v
+		}
+	}
+
+	return
+}
+
+// Scan sequence from start to end, given as indices into sequence.Seq, on the specified strands for
+// positions scoring at least minScore, returning the hits as features in sequence coordinates,
+// annotated with their strand, normalised score and p-value. The
+// p-value is held in the Probability field of each feature, and the matched sequence, reverse
+// complemented using the pairing of the sequence's alphabet for hits on the reverse strand, in the
+// Attributes field. Hits are given the Strand of the sequence, taking an unknown strand to be the
+// forward strand, or its opposite for hits on the reverse strand. If sequence is circular and end
+// is its length, hits spanning the origin are included and have an End less than their Start.
+func (self *PWM) Scan(sequence *seq.Seq, start, end int, minScore float64, strands int) (hits []*feat.Feature, err error) {
+	if start < 0 || end > sequence.Len() || start > end {
+		return nil, bio.NewError("Start or end position out of range.", 0, start, end)
+	}
+
+	strand := int8(1)
+	if sequence.Strand < 0 {
+		strand = -1
+	}
+	var (
+		length = len(self.matrix)
+		mats   [][][]float64
+		dists  []*distribution
+		signs  []int8
+	)
+	if strands&Forward != 0 {
+		var d *distribution
+		if d, err = self.distribution(); err != nil {
+			return
+		}
+		mats, dists, signs = append(mats, self.matrix), append(dists, d), append(signs, 1)
+	}
+	if strands&Reverse != 0 {
+		var d *distribution
+		if d, err = self.rcDistribution(); err != nil {
+			return
+		}
+		mats, dists, signs = append(mats, self.revComp()), append(dists, d), append(signs, -1)
+	}
+
+	var index []int
+	if length > 0 {
+		index = LetterIndex(sequence, len(self.matrix[0]))
+	}
+	n, last := sequence.Len(), end-length
+	if sequence.Circular && end == n && length <= n {
+		last = end - 1
+	}
+	bases := make([]int, length)
+	window := make([]byte, length)
+LOOP:
+	for position := start; position <= last; position++ {
+		for i := range bases {
+			window[i] = sequence.Seq[(position+i)%n]
+			if bases[i] = index[window[i]]; bases[i] < 0 {
+				continue LOOP
+			}
+		}
+		stop := position + length
+		if stop > n {
+			stop -= n
+		}
+
+		for k, m := range mats {
+			// The p-value is taken from the sum of discretised position scores so that it
+			// agrees exactly with the calculated distribution.
+			d := dists[k]
+			score, discrete := float64(0), 0
+			for i, b := range bases {
+				score += m[i][b]
+				discrete += int(math.Floor(m[i][b]*d.resolution + 0.5))
+			}
+			if score < minScore {
+				continue
+			}
+
+			var p float64
+			if !math.IsInf(score, -1) {
+				p = d.pValue(discrete)
+			}
+			match := append([]byte(nil), window...)
+			if signs[k] < 0 {
+				var rc *seq.Seq
+				if rc, err = (&seq.Seq{Seq: match, Alphabet: sequence.Alphabet}).WorkInplace(true).RevComp(); err != nil {
+					return nil, err
+				}
+				match = rc.Seq
+			}
+			hits = append(hits, &feat.Feature{
+				ID:          sequence.ID + ":" + strconv.Itoa(sequence.Offset+position) + ".." + strconv.Itoa(sequence.Offset+stop),
+				Location:    sequence.ID,
+				Start:       sequence.Offset + position,
+				End:         sequence.Offset + stop,
+				Score:       score,
+				Probability: p,
+				Attributes:  string(match) + " " + strconv.FormatFloat(p, 'e', self.Precision, 64),
+				Strand:      signs[k] * strand,
+				Moltype:     sequence.Moltype(),
+				Frame:       -1,
+			})
+		}
+	}
+
+	return
+}
+
+// Scan sequence from start to end on the specified strands for positions with a p-value no greater
+// than maxP. Hits are returned as described for Scan.
+func (self *PWM) ScanPValue(sequence *seq.Seq, start, end int, maxP float64, strands int) (hits []*feat.Feature, err error) {
+	t := math.Inf(1)
+	for _, s := range []struct {
+		strand       int
+		distribution func() (*distribution, error)
+	}{{Forward, self.distribution}, {Reverse, self.rcDistribution}} {
+		if strands&s.strand == 0 {
+			continue
+		}
+		var d *distribution
+		if d, err = s.distribution(); err != nil {
+			return
+		}
+		t = math.Min(t, d.threshold(maxP))
+	}
+	if math.IsInf(t, 1) {
+		return nil, nil
+	}
+	// Allow for rounding of the discretised scores; hits are filtered on their p-value.
+	candidates, err := self.Scan(sequence, start, end, t-float64(len(self.matrix))/self.Resolution, strands)
+	for _, h := range candidates {
+		if h.Probability <= maxP {
+			hits = append(hits, h)
+		}
+	}
+
+	return
+}
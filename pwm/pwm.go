@@ -19,30 +19,38 @@ package pwm
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/feat"
 	"github.com/kortschak/BioGo/seq"
-	"github.com/kortschak/BioGo/util"
 	"math"
 	"sort"
 	"strconv"
 )
 
 var (
-	valid  [256]bool
-	LookUp util.CTL
+	valid [256]bool
+
+	// Alphabet gives the letter order of matrix columns where it is not described by a
+	// sequence, as for Motif matrices.
+	Alphabet alphabet.Alphabet = alphabet.DNA
 )
 
 func init() {
-	m := make(map[int]int)
+	Valid(bio.N)
+}
 
-	for i, v := range bio.N {
-		m[int(v)] = i % 4
+// Return a letter to matrix column lookup for the sequence based on the letter indices of its
+// alphabet. Letters not in the alphabet or with an index of width or more map to -1.
+func LetterIndex(s *seq.Seq, width int) (index []int) {
+	index = s.GetAlphabet().LetterIndex()
+	for i, c := range index {
+		if c >= width {
+			index[i] = -1
+		}
 	}
 
-	LookUp = *util.NewCTL(m)
-
-	Valid(bio.N)
+	return
 }
 
 func Valid(alphabet []byte) {
@@ -73,31 +81,51 @@ type PWM struct {
 	lookAhead   []float64
 	table       probTable
 	minScore    float64
+	scale       float64
+	probs       [][]float64
+	Background  Background // Background frequencies used to construct the PWM, nil if not known.
+	Resolution  float64    // Number of discrete steps per unit of score used to calculate p-values.
+	dist        *distribution
+	rcDist      *distribution
 	FloatFormat byte
 	Precision   int
 }
 
+// Return a new PWM based on matrix. The values of matrix are scaled in place so that the
+// maximum achievable score is 1, unless the maximum is not positive, as for an uninformative
+// matrix, in which case matrix is left unscaled. If all values of matrix are non-negative, the
+// matrix is also taken to describe the relative letter frequencies at each position.
 func New(matrix [][]float64) (m *PWM) { // try this and also matrix []map[byte]float64 for speed comparison
 	m = &PWM{
 		matrix:      matrix,
 		lookAhead:   make([]float64, len(matrix)),
 		minScore:    math.MaxFloat64,
+		Resolution:  DefaultResolution,
 		FloatFormat: bio.FloatFormat,
 		Precision:   bio.Precision,
 	}
 
+	m.probs = frequencies(matrix)
+
 	var maxVal, maxScore float64
 
 	for i := len(matrix) - 1; i >= 0; i-- {
-		maxVal = 0
+		maxVal = math.Inf(-1)
 		for _, v := range matrix[i] {
 			if v > maxVal {
 				maxVal = v
 			}
 		}
-		maxScore += maxVal
+		if !math.IsInf(maxVal, -1) {
+			maxScore += maxVal
+		}
 		m.lookAhead[i] = maxScore
 	}
+	if maxScore <= 0 {
+		m.scale = 1
+		return
+	}
+	m.scale = maxScore
 
 	for i := range matrix {
 		for j := range matrix[i] {
@@ -122,7 +150,7 @@ func (self *PWM) genTable(minScore, score float64, position int, motif []byte) {
 				continue
 			}
 			// count frequencies of states in current motif
-			freqs := make([]int, 4)
+			freqs := make([]int, len(self.matrix[position]))
 			for _, j := range motif {
 				freqs[j]++
 			}
@@ -173,9 +201,14 @@ func (self *PWM) Search(sequence *seq.Seq, start, end int, minScore float64) (sc
 	}
 
 	length := len(self.matrix)
+	if length == 0 {
+		return
+	}
 
-	freqs := make([]float64, 4)
-	zeros := make([]float64, 4)
+	width := len(self.matrix[0])
+	index := LetterIndex(sequence, width)
+	freqs := make([]float64, width)
+	zeros := make([]float64, width)
 
 	diff := 1 / float64(length)
 LOOP:
@@ -183,7 +216,7 @@ LOOP:
 		// determine the score for this position
 		score := float64(0)
 		for i := 0; i < length; i++ {
-			if base := LookUp.ValueToCode[sequence.Seq[position+i]]; base < 0 || minScore-score > self.lookAhead[i] { // not valid base or will not be able to achieve minScore
+			if base := index[sequence.Seq[position+i]]; base < 0 || minScore-score > self.lookAhead[i] { // not valid base or will not be able to achieve minScore
 				continue LOOP
 			} else {
 				score += self.matrix[i][base]
@@ -197,7 +230,7 @@ LOOP:
 		// calculate base frequencies for window
 		copy(freqs, zeros)
 		for i := position; i < position+length; i++ {
-			if base := LookUp.ValueToCode[sequence.Seq[i]]; base >= 0 {
+			if base := index[sequence.Seq[i]]; base >= 0 {
 				freqs[base] += diff
 			} else { // probability for this position will be meaningless - if N is tolerated, include N in valid alphabet - make special case?
 				continue LOOP
@@ -226,7 +259,7 @@ LOOP:
 			Score:      score,
 			Attributes: string(sequence.Seq[position:position+length]) + " " + strconv.FormatFloat(prob, self.FloatFormat, self.Precision, 64),
 			Strand:     sequence.Strand,
-			Moltype:    sequence.Moltype,
+			Moltype:    sequence.Moltype(),
 			Frame:      -1,
 		})
 	}
@@ -0,0 +1,308 @@
+package pwm
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/index/kmerindex"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"math"
+	"testing"
+)
+
+// Checkers
+type floatApproxChecker struct {
+	*check.CheckerInfo
+}
+
+var floatApprox check.Checker = &floatApproxChecker{
+	&check.CheckerInfo{Name: "Approximately", Params: []string{"obtained", "expected", "epsilon"}},
+}
+
+func (checker *floatApproxChecker) Check(params []interface{}, names []string) (result bool, error string) {
+	return math.Abs(params[0].(float64)-params[1].(float64)) < params[2].(float64), ""
+}
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+var aln = seq.Alignment{
+	&seq.Seq{ID: "a", Seq: []byte("ACGT")},
+	&seq.Seq{ID: "b", Seq: []byte("ACGA")},
+	&seq.Seq{ID: "c", Seq: []byte("ACCT")},
+	&seq.Seq{ID: "d", Seq: []byte("-CGT"), Offset: 0},
+}
+
+func (s *S) TestCountsOf(c *check.C) {
+	counts, err := CountsOf(aln)
+	c.Check(err, check.IsNil)
+	c.Check(counts, check.DeepEquals, [][]float64{
+		{3, 0, 0, 0},
+		{0, 4, 0, 0},
+		{0, 1, 3, 0},
+		{1, 0, 0, 3},
+	})
+}
+
+func (s *S) TestBackground(c *check.C) {
+	b, err := BackgroundOf(false, &seq.Seq{Seq: []byte("AACGNt")})
+	c.Check(err, check.IsNil)
+	c.Check(b, check.DeepEquals, Background{0.4, 0.2, 0.2, 0.2})
+	b, err = BackgroundOf(true, &seq.Seq{Seq: []byte("AACG")})
+	c.Check(err, check.IsNil)
+	c.Check(b, check.DeepEquals, Background{0.25, 0.25, 0.25, 0.25})
+	_, err = BackgroundOf(false, &seq.Seq{Seq: []byte("NNN")})
+	c.Check(err, check.Not(check.IsNil))
+
+	sq := &seq.Seq{Seq: []byte("AAAACCCCGGTTAACCGGTT")}
+	i, err := kmerindex.New(4, sq)
+	c.Assert(err, check.IsNil)
+	f, _ := i.NormalisedKmerFrequencies()
+	b, err = BackgroundOfKmers(4, f)
+	c.Check(err, check.IsNil)
+	var sum float64
+	for _, v := range b {
+		sum += v
+	}
+	c.Check(sum, floatApprox, 1., 1e-12)
+	c.Check(b[1] > b[3], check.Equals, true)
+}
+
+func (s *S) TestFrequencies(c *check.C) {
+	p, err := Frequencies([][]float64{{3, 1, 0, 0}, {0, 0, 0, 0}}, 4, Uniform)
+	c.Check(err, check.IsNil)
+	c.Check(p, check.DeepEquals, [][]float64{{0.5, 0.25, 0.125, 0.125}, {0.25, 0.25, 0.25, 0.25}})
+	_, err = Frequencies([][]float64{{1, 1}}, 1, nil)
+	c.Check(err, check.Not(check.IsNil))
+}
+
+func (s *S) TestInformationContent(c *check.C) {
+	ic, err := InformationContent([][]float64{{1, 0, 0, 0}, {0.25, 0.25, 0.25, 0.25}, {0.5, 0.5, 0, 0}}, nil)
+	c.Check(err, check.IsNil)
+	c.Check(ic, check.DeepEquals, []float64{2, 0, 1})
+}
+
+func (s *S) TestNewFromAlignment(c *check.C) {
+	m, err := NewFromAlignment(aln, 1, Uniform)
+	c.Assert(err, check.IsNil)
+	c.Check(m.Len(), check.Equals, 4)
+	ic := m.InformationContent()
+	c.Check(len(ic), check.Equals, 4)
+	c.Check(ic[1] > ic[0], check.Equals, true)
+	c.Check(ic[0] > 0, check.Equals, true)
+
+	hits := m.Search(&seq.Seq{ID: "s", Seq: []byte("TTTTACGTTTTT")}, 0, 12, 0.99)
+	c.Assert(len(hits), check.Equals, 1)
+	c.Check(hits[0].Start, check.Equals, 5)
+	c.Check(hits[0].Score, floatApprox, 1., 1e-12)
+}
+
+func (s *S) TestPValue(c *check.C) {
+	m := New([][]float64{{1, 0, 0, 0}, {1, 0, 0, 0}})
+	for _, t := range []struct{ score, p float64 }{
+		{1, 1. / 16},
+		{0.5, 7. / 16},
+		{0.25, 7. / 16},
+		{0, 1},
+		{-1, 1},
+		{1.5, 0},
+	} {
+		p, err := m.PValue(t.score)
+		c.Check(err, check.IsNil)
+		c.Check(p, floatApprox, t.p, 1e-12)
+	}
+	for _, t := range []struct{ p, threshold float64 }{
+		{0.1, 1},
+		{0.5, 0.5},
+		{1, 0},
+		{0.01, math.Inf(1)},
+	} {
+		th, err := m.Threshold(t.p)
+		c.Check(err, check.IsNil)
+		c.Check(th, check.Equals, t.threshold)
+	}
+
+	m.Background = Background{0.5, 0.5, 0, 0}
+	p, err := m.PValue(1)
+	c.Check(err, check.IsNil)
+	c.Check(p, floatApprox, 0.25, 1e-12)
+}
+
+func (s *S) TestUninformative(c *check.C) {
+	m, err := NewFromCounts([][]float64{{5, 5, 5, 5}, {5, 5, 5, 5}}, 0.1, nil)
+	c.Assert(err, check.IsNil)
+	for _, row := range m.matrix {
+		for _, v := range row {
+			c.Check(v, check.Equals, 0.)
+		}
+	}
+	sq := &seq.Seq{ID: "s", Seq: []byte("ACGT"), Strand: 1}
+	hits, err := m.Scan(sq, 0, sq.Len(), 0, Forward)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, 3)
+	c.Check(hits[0].Score, check.Equals, 0.)
+	c.Check(hits[0].Probability, floatApprox, 1., 1e-12)
+}
+
+func (s *S) TestScan(c *check.C) {
+	m, err := NewFromCounts([][]float64{{10, 0, 0, 0}, {0, 10, 0, 0}, {0, 0, 0, 10}}, 0.1, nil)
+	c.Assert(err, check.IsNil)
+	sq := &seq.Seq{ID: "s", Seq: []byte("ggACTggAGTgg"), Strand: 1}
+	hits, err := m.Scan(sq, 0, sq.Len(), 0.9, Both)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, 2)
+	c.Check(hits[0].Start, check.Equals, 2)
+	c.Check(hits[0].End, check.Equals, 5)
+	c.Check(hits[0].Strand, check.Equals, int8(1))
+	c.Check(hits[1].Start, check.Equals, 7)
+	c.Check(hits[1].Strand, check.Equals, int8(-1))
+	c.Check(hits[1].Attributes[:3], check.Equals, "ACT")
+	c.Check(hits[0].Score, floatApprox, hits[1].Score, 1e-12)
+	c.Check(hits[0].Probability, floatApprox, 1./64, 1e-12)
+
+	hits, err = m.Scan(sq, 0, sq.Len(), 0.9, Forward)
+	c.Check(err, check.IsNil)
+	c.Check(len(hits), check.Equals, 1)
+
+	hits, err = m.ScanPValue(sq, 0, sq.Len(), 1./64, Both)
+	c.Check(err, check.IsNil)
+	c.Check(len(hits), check.Equals, 2)
+	hits, err = m.ScanPValue(sq, 0, sq.Len(), 1./65, Both)
+	c.Check(err, check.IsNil)
+	c.Check(len(hits), check.Equals, 0)
+
+	// Letter indices are taken from the sequence's alphabet.
+	sq = &seq.Seq{ID: "s", Seq: []byte("ggACUggAGUgg"), Strand: 1}
+	hits, err = m.Scan(sq, 0, sq.Len(), 0.9, Both)
+	c.Check(err, check.IsNil)
+	c.Check(len(hits), check.Equals, 0)
+	sq.Alphabet = alphabet.RNA
+	hits, err = m.Scan(sq, 0, sq.Len(), 0.9, Both)
+	c.Check(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, 2)
+	c.Check(hits[0].Attributes[:3], check.Equals, "ACU")
+	c.Check(hits[1].Attributes[:3], check.Equals, "ACU")
+
+	// Reverse strand hits take their p-values from the distribution of the reverse complement.
+	m, err = NewFromCounts([][]float64{{10, 0, 0, 0}, {10, 0, 0, 0}, {10, 0, 0, 0}}, 0.1, Background{0.7, 0.1, 0.1, 0.1})
+	c.Assert(err, check.IsNil)
+	sq = &seq.Seq{ID: "s", Seq: []byte("ggAAAggTTTgg"), Strand: 1}
+	hits, err = m.Scan(sq, 0, sq.Len(), 0.9, Both)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, 2)
+	c.Check(hits[0].Strand, check.Equals, int8(1))
+	c.Check(hits[0].Probability, floatApprox, 0.343, 1e-12)
+	c.Check(hits[1].Strand, check.Equals, int8(-1))
+	c.Check(hits[1].Attributes[:3], check.Equals, "AAA")
+	c.Check(hits[1].Probability, floatApprox, 0.001, 1e-12)
+
+	hits, err = m.ScanPValue(sq, 0, sq.Len(), 0.01, Both)
+	c.Check(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, 1)
+	c.Check(hits[0].Start, check.Equals, 7)
+	hits, err = m.ScanPValue(sq, 0, sq.Len(), 0.01, Forward)
+	c.Check(err, check.IsNil)
+	c.Check(len(hits), check.Equals, 0)
+}
+
+func (s *S) TestScanOffset(c *check.C) {
+	m, err := NewFromCounts([][]float64{{10, 0, 0, 0}, {0, 10, 0, 0}, {0, 0, 0, 10}}, 0.1, nil)
+	c.Assert(err, check.IsNil)
+	sq := &seq.Seq{ID: "s", Seq: []byte("ggACTggAGTgg"), Offset: 100, Strand: 1}
+	hits, err := m.Scan(sq, 0, sq.Len(), 0.9, Both)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, 2)
+	c.Check(hits[0].ID, check.Equals, "s:102..105")
+	for _, h := range hits {
+		t, err := sq.Trunc(h.Start, h.End)
+		c.Assert(err, check.IsNil)
+		c.Check(string(t.Seq), check.Matches, "ACT|AGT")
+	}
+	c.Check(hits[1].Start, check.Equals, 107)
+}
+
+func (s *S) TestScanUnknownStrand(c *check.C) {
+	m, err := NewFromCounts([][]float64{{10, 0, 0, 0}, {0, 10, 0, 0}, {0, 0, 0, 10}}, 0.1, nil)
+	c.Assert(err, check.IsNil)
+	for _, t := range []struct {
+		strand int8
+		want   []int8
+	}{
+		{0, []int8{1, -1}},
+		{1, []int8{1, -1}},
+		{-1, []int8{-1, 1}},
+	} {
+		sq := &seq.Seq{ID: "s", Seq: []byte("ACTggAGT"), Strand: t.strand}
+		hits, err := m.Scan(sq, 0, sq.Len(), 0.9, Both)
+		c.Assert(err, check.IsNil)
+		var strands []int8
+		for _, h := range hits {
+			strands = append(strands, h.Strand)
+		}
+		c.Check(strands, check.DeepEquals, t.want, check.Commentf("Strand %d", t.strand))
+	}
+}
+
+func (s *S) TestScanRedundant(c *check.C) {
+	sq := &seq.Seq{ID: "s", Seq: []byte("ggACTgRAGTggacNn"), Strand: 1}
+	c.Assert(sq.SetAlphabet(alphabet.DNAredundant), check.IsNil)
+
+	m, err := NewFromCounts([][]float64{{10, 0, 0, 0}, {0, 10, 0, 0}, {0, 0, 0, 10}}, 0.1, nil)
+	c.Assert(err, check.IsNil)
+	hits, err := m.Scan(sq, 0, sq.Len(), 0.9, Both)
+	c.Assert(err, check.IsNil)
+	var got []string
+	for _, h := range hits {
+		got = append(got, h.ID)
+	}
+	c.Check(got, check.DeepEquals, []string{"s:2..5", "s:7..10"})
+
+	i, err := kmerindex.New(4, sq)
+	c.Assert(err, check.IsNil)
+	i.Build()
+	pos, ok := i.StringKmerIndex()
+	c.Check(ok, check.Equals, true)
+	c.Check(pos, check.DeepEquals, map[string][]int{
+		"GGAC": {0, 10}, "GACT": {1}, "ACTG": {2}, "AGTG": {7}, "GTGG": {8}, "TGGA": {9},
+	})
+}
+
+func (s *S) TestScanCircular(c *check.C) {
+	m, err := NewFromCounts([][]float64{{10, 0, 0, 0}, {0, 10, 0, 0}, {0, 0, 0, 10}}, 0.1, nil)
+	c.Assert(err, check.IsNil)
+	sq := &seq.Seq{ID: "s", Seq: []byte("CTggggggA"), Strand: 1}
+	hits, err := m.Scan(sq, 0, sq.Len(), 0.9, Forward)
+	c.Check(err, check.IsNil)
+	c.Check(len(hits), check.Equals, 0)
+
+	sq.Circular = true
+	hits, err = m.Scan(sq, 0, sq.Len(), 0.9, Forward)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(hits), check.Equals, 1)
+	c.Check(hits[0].Start, check.Equals, 8)
+	c.Check(hits[0].End, check.Equals, 2)
+	c.Check(hits[0].Attributes[:3], check.Equals, "ACT")
+
+	// Hits spanning the origin are only reported when scanning to the end of the sequence.
+	hits, err = m.Scan(sq, 0, sq.Len()-1, 0.9, Forward)
+	c.Check(err, check.IsNil)
+	c.Check(len(hits), check.Equals, 0)
+}
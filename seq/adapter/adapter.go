@@ -0,0 +1,253 @@
+// Package for removal of adapter and primer sequences from sequencing reads
+package adapter
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/seq"
+)
+
+// Adapter types.
+const (
+	ThreePrime         Type = iota // Adapter and following bases are removed; partial adapters are found at the 3' end.
+	FivePrime                      // Adapter and preceding bases are removed; partial adapters are found at the 5' end.
+	AnchoredThreePrime             // The complete adapter must end at the 3' end of the read.
+	AnchoredFivePrime              // The complete adapter must begin at the 5' end of the read.
+)
+
+type Type int8
+
+// Stats holds the trimming statistics of an adapter.
+type Stats struct {
+	Reads   int         // Number of reads examined.
+	Trimmed int         // Number of reads in which the adapter was found.
+	Bases   int         // Number of bases removed.
+	Lengths map[int]int // Number of reads by length removed.
+}
+
+func (self *Stats) add(removed int) {
+	self.Reads++
+	if removed < 0 {
+		return
+	}
+	self.Trimmed++
+	self.Bases += removed
+	if self.Lengths == nil {
+		self.Lengths = make(map[int]int)
+	}
+	self.Lengths[removed]++
+}
+
+// An Adapter describes an adapter sequence to remove from reads. Adapters match with up to
+// MaxErrorRate errors, mismatches or indels, per adapter base aligned, and partial adapters
+// at read ends must align at least MinOverlap adapter bases. IUPAC codes in the adapter match
+// any of the bases they represent. An Adapter may be used as a trim.Trimmer; each call to Trim
+// updates the Stats, so an Adapter should not be shared between goroutines.
+type Adapter struct {
+	Name         string
+	Seq          []byte
+	Type         Type
+	MaxErrorRate float64
+	MinOverlap   int
+	Stats        Stats
+}
+
+// Return a new Adapter with the given sequence and type, a MaxErrorRate of 0.1 and a MinOverlap of 3.
+func New(name string, s []byte, t Type) (a *Adapter, err error) {
+	a = &Adapter{
+		Name:         name,
+		Seq:          s,
+		Type:         t,
+		MaxErrorRate: 0.1,
+		MinOverlap:   3,
+	}
+	if _, err = a.pattern(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Return the adapter as base sets.
+func (self *Adapter) pattern() (p []byte, err error) {
+	if len(self.Seq) == 0 {
+		return nil, bio.NewError("Empty adapter.", 0, self.Name)
+	}
+	p = make([]byte, len(self.Seq))
+	for i, l := range self.Seq {
+		if p[i] = alphabet.BaseSet(l); p[i] == 0 {
+			return nil, bio.NewError("Invalid letter in adapter.", 0, self.Name, string(self.Seq))
+		}
+	}
+	return
+}
+
+// Return whether the read letter l matches the adapter base set m.
+func matches(l, m byte) bool {
+	b := alphabet.BaseSet(l)
+	return b != 0 && b&^m == 0
+}
+
+// Find the adapter codes p in the letters l, with matches read from the 3' end of l if reverse is
+// true. The adapter may begin anywhere in l and, unless anchored, may be truncated by the end
+// of l. Matches must end at the end of l if anchored. The returned start and end are the indices
+// of the match in l in the direction of reading. If no match is found, found is false.
+func (self *Adapter) find(p []byte, l []byte, reverse, anchored bool) (start, end int, found bool) {
+	m, n := len(p), len(l)
+	at := func(j int) byte {
+		if reverse {
+			return l[n-j-1]
+		}
+		return l[j]
+	}
+	pat := func(i int) byte {
+		if reverse {
+			return p[m-i-1]
+		}
+		return p[i]
+	}
+
+	// d[i][j] is the least number of errors aligning the first i adapter bases ending at read
+	// index j, and s[i][j] the read index at which that alignment begins.
+	d, s := make([][]int, m+1), make([][]int, m+1)
+	for i := range d {
+		d[i], s[i] = make([]int, n+1), make([]int, n+1)
+		d[i][0] = i
+	}
+	for j := 0; j <= n; j++ {
+		s[0][j] = j
+	}
+	for i := 1; i <= m; i++ {
+		for j := 1; j <= n; j++ {
+			cost := 1
+			if matches(at(j-1), pat(i-1)) {
+				cost = 0
+			}
+			d[i][j], s[i][j] = d[i-1][j-1]+cost, s[i-1][j-1]
+			if v := d[i-1][j] + 1; v < d[i][j] {
+				d[i][j], s[i][j] = v, s[i-1][j]
+			}
+			if v := d[i][j-1] + 1; v < d[i][j] {
+				d[i][j], s[i][j] = v, s[i][j-1]
+			}
+		}
+	}
+
+	best := -1
+	consider := func(i, j int) {
+		e := d[i][j]
+		if i < self.MinOverlap || float64(e) > self.MaxErrorRate*float64(i) {
+			return
+		}
+		if score := i - e; score > best || score == best && s[i][j] < start {
+			best, start, end, found = score, s[i][j], j, true
+		}
+	}
+	if !anchored {
+		for j := 1; j <= n; j++ {
+			consider(m, j)
+		}
+		for i := 1; i < m; i++ {
+			consider(i, n)
+		}
+	} else {
+		consider(m, n)
+	}
+
+	return
+}
+
+// Return the indices of the part of the letters l to retain and whether the adapter was found.
+func (self *Adapter) locate(p, l []byte) (start, end int, found bool) {
+	n := len(l)
+	switch self.Type {
+	case ThreePrime, AnchoredThreePrime:
+		var s int
+		if s, _, found = self.find(p, l, false, self.Type == AnchoredThreePrime); found {
+			return 0, s, true
+		}
+	case FivePrime, AnchoredFivePrime:
+		var s int
+		if s, _, found = self.find(p, l, true, self.Type == AnchoredFivePrime); found {
+			return n - s, n, true
+		}
+	}
+	return 0, n, false
+}
+
+// Trim returns the part of s to retain after removal of the adapter in sequence coordinates.
+func (self *Adapter) Trim(s *seq.Seq) (start, end int, err error) {
+	p, err := self.pattern()
+	if err != nil {
+		return
+	}
+	start, end, found := self.locate(p, s.Seq)
+	removed := -1
+	if found {
+		removed = s.Len() - (end - start)
+	}
+	self.Stats.add(removed)
+
+	return s.Offset + start, s.Offset + end, nil
+}
+
+// A Linked adapter is a pair of adapters flanking the retained part of a read. The Front adapter
+// is found as a 5' adapter, or an anchored 5' adapter if its Type is AnchoredFivePrime, and the
+// Back adapter is found as a 3' adapter in the remainder of the read. Reads in which the Front
+// adapter is not found, or the Back adapter is not found if RequireBack is true, are not trimmed.
+// A Linked adapter may be used as a trim.Trimmer.
+type Linked struct {
+	Name        string
+	Front, Back *Adapter
+	RequireBack bool
+	Stats       Stats
+}
+
+// Trim returns the part of s to retain after removal of the linked adapters in sequence coordinates.
+func (self *Linked) Trim(s *seq.Seq) (start, end int, err error) {
+	front, err := self.Front.pattern()
+	if err != nil {
+		return
+	}
+	back, err := self.Back.pattern()
+	if err != nil {
+		return
+	}
+	start, end = 0, s.Len()
+	l := s.Seq
+	fs, _, found := self.Front.find(front, l, true, self.Front.Type == AnchoredFivePrime)
+	if found {
+		start = len(l) - fs
+		var bs int
+		bs, _, found = self.Back.find(back, l[start:], false, self.Back.Type == AnchoredThreePrime)
+		switch {
+		case found:
+			end = start + bs
+		case self.RequireBack:
+			start = 0
+		default:
+			found = true
+		}
+	}
+	removed := -1
+	if found {
+		removed = s.Len() - (end - start)
+	}
+	self.Stats.add(removed)
+
+	return s.Offset + start, s.Offset + end, nil
+}
@@ -0,0 +1,146 @@
+package adapter
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/seq"
+	"github.com/kortschak/BioGo/seq/trim"
+	check "launchpad.net/gocheck"
+	"strings"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const (
+	insert = "CATGCATTGACCGTAAGCTA"
+	three  = "AGATCGGAAGAGC"
+	five   = "TACACGACGCTCTTCCGATCT"
+)
+
+func (s *S) TestAdapter(c *check.C) {
+	a3, err := New("3'", []byte(three), ThreePrime)
+	c.Assert(err, check.IsNil)
+	a3a, _ := New("3' anchored", []byte(three), AnchoredThreePrime)
+	a5, _ := New("5'", []byte(five), FivePrime)
+	a5a, _ := New("5' anchored", []byte(five), AnchoredFivePrime)
+
+	for _, t := range []struct {
+		adapter    *Adapter
+		read       string
+		start, end int
+	}{
+		{a3, "ACGTTGCAACGT" + three + "TTTT", 0, 12},
+		{a3, insert + three[:7], 0, 20},
+		{a3, insert + three[:2], 0, 22},
+		{a3, insert + "AGATCGTAAGAGC", 0, 20},
+		{a3, insert + "AGATCGAAGAGCAA", 0, 20},
+		{a3, insert + "AGTTCGTAAGAGC", 0, 33},
+		{a3a, insert + three, 0, 20},
+		{a3a, insert + three + "TT", 0, 35},
+		{a5, five + insert, 21, 41},
+		{a5, five[14:] + insert, 7, 27},
+		{a5a, five + insert, 21, 41},
+		{a5a, "GGG" + five + insert, 0, 44},
+	} {
+		start, end, err := t.adapter.Trim(&seq.Seq{Seq: []byte(t.read), Offset: 10})
+		c.Check(err, check.IsNil)
+		c.Check(start, check.Equals, 10+t.start, check.Commentf("%s %s", t.adapter.Name, t.read))
+		c.Check(end, check.Equals, 10+t.end, check.Commentf("%s %s", t.adapter.Name, t.read))
+	}
+	c.Check(a3.Stats, check.DeepEquals, Stats{Reads: 6, Trimmed: 4, Bases: 17 + 7 + 13 + 14, Lengths: map[int]int{17: 1, 7: 1, 13: 1, 14: 1}})
+	c.Check(a5a.Stats.Trimmed, check.Equals, 1)
+
+	// Trimming a read removes the adapter from its quality.
+	q := make([]seq.Qsanger, 20+len(three))
+	for i := range q {
+		q[i] = seq.Qsanger(i)
+	}
+	r, err := trim.Trim(seq.New("read", []byte(insert+three), seq.NewQuality("read", q)), a3)
+	c.Assert(err, check.IsNil)
+	c.Check(string(r.Seq), check.Equals, insert)
+	c.Check(r.Quality.Qual, check.DeepEquals, q[:20])
+
+	_, err = New("bad", []byte("ACGTX"), ThreePrime)
+	c.Check(err, check.NotNil)
+	_, _, err = (&Adapter{Name: "empty"}).Trim(r)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestLinked(c *check.C) {
+	front, _ := New("front", []byte("ACGTACGTAC"), AnchoredFivePrime)
+	back, _ := New("back", []byte("TTTTGGGGCC"), ThreePrime)
+	l := &Linked{Name: "linked", Front: front, Back: back}
+	for _, t := range []struct {
+		read       string
+		require    bool
+		start, end int
+	}{
+		{"ACGTACGTAC" + insert + "TTTTGGGGCC" + "AA", false, 10, 30},
+		{"ACGTACGTAC" + insert, false, 10, 30},
+		{"ACGTACGTAC" + insert, true, 0, 30},
+		{"GG" + "ACGTACGTAC" + insert + "TTTTGGGGCC", false, 0, 42},
+	} {
+		l.RequireBack = t.require
+		start, end, err := l.Trim(&seq.Seq{Seq: []byte(t.read)})
+		c.Check(err, check.IsNil)
+		c.Check(start, check.Equals, t.start, check.Commentf("%s", t.read))
+		c.Check(end, check.Equals, t.end, check.Commentf("%s", t.read))
+	}
+	c.Check(l.Stats.Reads, check.Equals, 4)
+	c.Check(l.Stats.Trimmed, check.Equals, 2)
+}
+
+func (s *S) TestOverlap(c *check.C) {
+	rc := string(revComp([]byte(insert)))
+	r1 := seq.New("r1", []byte(insert+three+"ACAC"), nil)
+	r2 := seq.New("r2", []byte(rc+"AGATCGTCGGACTGTAG"), nil)
+
+	n, ok := InsertLength(r1.Seq, r2.Seq, 10, 0.1)
+	c.Check(ok, check.Equals, true)
+	c.Check(n, check.Equals, 20)
+	n, ok = InsertLength(r1.Seq, []byte(strings.Replace(string(r2.Seq), "T", "U", -1)), 10, 0.1)
+	c.Check(ok, check.Equals, true)
+	c.Check(n, check.Equals, 20)
+
+	o := &Overlap{MinOverlap: 10, MaxErrorRate: 0.1}
+	t1, t2, err := o.Trim(r1, r2)
+	c.Assert(err, check.IsNil)
+	c.Check(string(t1.Seq), check.Equals, insert)
+	c.Check(string(t2.Seq), check.Equals, rc)
+	c.Check(o.Stats.Bases, check.Equals, 34)
+
+	// Reads from a long insert are not trimmed.
+	u1, u2, err := o.Trim(seq.New("r1", []byte(insert), nil), seq.New("r2", []byte("GGGGGGGGGGCCCCCCCCCC"), nil))
+	c.Assert(err, check.IsNil)
+	c.Check(string(u1.Seq), check.Equals, insert)
+	c.Check(string(u2.Seq), check.Equals, "GGGGGGGGGGCCCCCCCCCC")
+	c.Check(o.Stats.Reads, check.Equals, 2)
+	c.Check(o.Stats.Trimmed, check.Equals, 1)
+}
+
+func revComp(l []byte) []byte {
+	r, err := (&seq.Seq{Seq: l}).RevComp()
+	if err != nil {
+		panic(err)
+	}
+	return r.Seq
+}
@@ -0,0 +1,82 @@
+package adapter
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/seq"
+)
+
+// Return the length of the insert of the read pair r1 and r2 when it is no longer than the
+// shorter read, found as the longest overlap of the start of r1 with the end of the reverse
+// complement of r2 that is at least minOverlap bases long with a mismatch rate no greater than
+// maxErrorRate. If no such overlap exists, ok is false.
+func InsertLength(r1, r2 []byte, minOverlap int, maxErrorRate float64) (length int, ok bool) {
+	n := len(r1)
+	if len(r2) < n {
+		n = len(r2)
+	}
+	if minOverlap < 1 {
+		minOverlap = 1
+	}
+	for length = n; length >= minOverlap; length-- {
+		limit := int(maxErrorRate * float64(length))
+		mismatches := 0
+		for k := 0; k < length && mismatches <= limit; k++ {
+			if a, b := alphabet.BaseSet(r1[k]), alphabet.ComplementSet(alphabet.BaseSet(r2[length-k-1])); a == 0 || a&(a-1) != 0 || a != b { // Ambiguous bases mismatch.
+				mismatches++
+			}
+		}
+		if mismatches <= limit {
+			return length, true
+		}
+	}
+	return 0, false
+}
+
+// Overlap removes adapters from read pairs whose insert is shorter than the reads, so that
+// each read extends through the insert into the adapter ligated to the other end. Adapter
+// sequences need not be known.
+type Overlap struct {
+	MinOverlap   int     // Shortest insert considered.
+	MaxErrorRate float64 // Mismatches per base of the overlap.
+	Stats        Stats   // Reads counts read pairs and Bases counts bases removed from both reads.
+}
+
+// Return the read pair with bases following the insert removed. Quality scores are trimmed
+// with the reads and the semantics of Inplace are followed.
+func (self *Overlap) Trim(r1, r2 *seq.Seq) (t1, t2 *seq.Seq, err error) {
+	t1, t2 = r1, r2
+	length, ok := InsertLength(r1.Seq, r2.Seq, self.MinOverlap, self.MaxErrorRate)
+	if !ok || length == r1.Len() && length == r2.Len() {
+		self.Stats.add(-1)
+		return
+	}
+	removed := r1.Len() + r2.Len() - 2*length
+	if length < r1.Len() {
+		if t1, err = r1.Trunc(r1.Offset, r1.Offset+length); err != nil {
+			return nil, nil, err
+		}
+	}
+	if length < r2.Len() {
+		if t2, err = r2.Trunc(r2.Offset, r2.Offset+length); err != nil {
+			return nil, nil, err
+		}
+	}
+	self.Stats.add(removed)
+
+	return
+}
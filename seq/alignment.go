@@ -100,7 +100,7 @@ func (self Alignment) Flush(where int, fill byte) (a Alignment) {
 					ID:       s.ID,
 					Seq:      append([]byte{}, append(s.Seq, bytes.Repeat([]byte{fill}, end-(s.Offset+s.Len()))...)...),
 					Offset:   s.Offset,
-					Moltype:  s.Moltype,
+					Alphabet: s.Alphabet,
 					Strand:   s.Strand,
 					Circular: false,
 				}
@@ -124,7 +124,7 @@ func (self Alignment) Flush(where int, fill byte) (a Alignment) {
 					ID:       s.ID,
 					Seq:      append(bytes.Repeat([]byte{fill}, diff), s.Seq...),
 					Offset:   start,
-					Moltype:  s.Moltype,
+					Alphabet: s.Alphabet,
 					Strand:   s.Strand,
 					Circular: false,
 				}
@@ -213,12 +213,12 @@ func (self Alignment) Join(a Alignment, fill byte, where int) (b Alignment, err
 			b[i].Quality = nil // TODO Handle Quality
 		} else {
 			b[i] = &Seq{
-				ID:      ID,
-				Seq:     ts,
-				Offset:  s1.Offset - shift,
-				Strand:  s1.Strand,
-				Moltype: s1.Moltype,
-				Quality: nil, // TODO Handle Quality
+				ID:       ID,
+				Seq:      ts,
+				Offset:   s1.Offset - shift,
+				Strand:   s1.Strand,
+				Alphabet: s1.Alphabet,
+				Quality:  nil, // TODO Handle Quality
 			}
 		}
 	}
@@ -297,7 +297,7 @@ func (self Alignment) Stitch(f feat.FeatureSet) (a Alignment, err error) {
 				Offset:   offset,
 				Strand:   s.Strand,
 				Circular: false,
-				Moltype:  s.Moltype,
+				Alphabet: s.Alphabet,
 				Quality:  q,
 			}
 		}
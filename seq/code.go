@@ -0,0 +1,183 @@
+package seq
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import "github.com/kortschak/BioGo/alphabet"
+
+// A GeneticCode describes the translation of codons to amino acids. AminoAcids and Starts
+// are indexed by codon in the NCBI order, with bases ordered TCAG from the first to the third
+// codon position. A start codon is marked by 'M' in Starts and a codon that may act as a
+// stop codon by '*'.
+type GeneticCode struct {
+	ID         int
+	Name       string
+	AminoAcids string
+	Starts     string
+}
+
+// The NCBI genetic codes, indexed by their NCBI identifier.
+var GeneticCodes = map[int]*GeneticCode{
+	1: {1, "Standard",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**--*----M---------------M----------------------------"},
+	2: {2, "Vertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+		"----------**--------------------MMMM----------**---M------------"},
+	3: {3, "Yeast Mitochondrial",
+		"FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**----------------------MM---------------M------------"},
+	4: {4, "Mold, Protozoan, and Coelenterate Mitochondrial and Mycoplasma/Spiroplasma",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--MM------**-------M------------MMMM---------------M------------"},
+	5: {5, "Invertebrate Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG",
+		"---M------**--------------------MMMM---------------M------------"},
+	6: {6, "Ciliate, Dasycladacean and Hexamita Nuclear",
+		"FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	9: {9, "Echinoderm and Flatworm Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"----------**-----------------------M---------------M------------"},
+	10: {10, "Euplotid Nuclear",
+		"FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**-----------------------M----------------------------"},
+	11: {11, "Bacterial, Archaeal and Plant Plastid",
+		"FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**--*----M------------MMMM---------------M------------"},
+	12: {12, "Alternative Yeast Nuclear",
+		"FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*----M---------------M----------------------------"},
+	13: {13, "Ascidian Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG",
+		"---M------**----------------------MM---------------M------------"},
+	14: {14, "Alternative Flatworm Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"-----------*-----------------------M----------------------------"},
+	16: {16, "Chlorophycean Mitochondrial",
+		"FFLLSSSSYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------*---*--------------------M----------------------------"},
+	21: {21, "Trematode Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+		"----------**-----------------------M---------------M------------"},
+	22: {22, "Scenedesmus obliquus Mitochondrial",
+		"FFLLSS*SYY*LCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"------*---*---*--------------------M----------------------------"},
+	23: {23, "Thraustochytrium Mitochondrial",
+		"FF*LSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--*-------**--*-----------------M--M---------------M------------"},
+	24: {24, "Rhabdopleuridae Mitochondrial",
+		"FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M------**-------M---------------M---------------M------------"},
+	25: {25, "Candidate Division SR1 and Gracilibacteria",
+		"FFLLSSSSYY**CCGWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------**-----------------------M---------------M------------"},
+	26: {26, "Pachysolen tannophilus Nuclear",
+		"FFLLSSSSYY**CC*WLLLAPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*----M---------------M----------------------------"},
+	27: {27, "Karyorelict Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	28: {28, "Condylostoma Nuclear",
+		"FFLLSSSSYYQQCCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**--*--------------------M----------------------------"},
+	29: {29, "Mesodinium Nuclear",
+		"FFLLSSSSYYYYCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	30: {30, "Peritrich Nuclear",
+		"FFLLSSSSYYEECC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"--------------*--------------------M----------------------------"},
+	31: {31, "Blastocrithidia Nuclear",
+		"FFLLSSSSYYEECCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"----------**-----------------------M----------------------------"},
+	32: {32, "Balanophoraceae Plastid",
+		"FFLLSSSSYY*WCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+		"---M------*---*----M------------MMMM---------------M------------"},
+	33: {33, "Cephalodiscidae Mitochondrial",
+		"FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSSKVVVVAAAADDEEGGGG",
+		"---M-------*-------M---------------M---------------M------------"},
+}
+
+// The standard genetic code.
+var Standard = GeneticCodes[1]
+
+// Base order of NCBI codon tables.
+var codonBases = [4]byte{'T', 'C', 'A', 'G'}
+
+// Return the indices into the code's tables of all codons represented by c, which may contain
+// IUPAC ambiguity codes. Returns nil if c is not a valid codon.
+func codons(c []byte) (idx []int) {
+	if len(c) != 3 {
+		return nil
+	}
+	idx = []int{0}
+	for _, b := range c {
+		bases := alphabet.BaseSet(b)
+		if bases == 0 {
+			return nil
+		}
+		var next []int
+		for _, i := range idx {
+			for j, cb := range codonBases {
+				if bases&alphabet.BaseSet(cb) != 0 {
+					next = append(next, i*4+j)
+				}
+			}
+		}
+		idx = next
+	}
+
+	return
+}
+
+// Return the amino acid encoded by the codon c. Ambiguous codons that can only encode a
+// single amino acid are resolved to that amino acid, other ambiguous codons and invalid
+// codons are translated as 'X'.
+func (self *GeneticCode) Translate(c []byte) byte {
+	idx := codons(c)
+	if idx == nil {
+		return 'X'
+	}
+	aa := self.AminoAcids[idx[0]]
+	for _, i := range idx[1:] {
+		if self.AminoAcids[i] != aa {
+			return 'X'
+		}
+	}
+
+	return aa
+}
+
+// Return whether every codon represented by c is a start codon.
+func (self *GeneticCode) IsStart(c []byte) bool {
+	idx := codons(c)
+	for _, i := range idx {
+		if self.Starts[i] != 'M' {
+			return false
+		}
+	}
+	return idx != nil
+}
+
+// Return whether every codon represented by c is a stop codon.
+func (self *GeneticCode) IsStop(c []byte) bool {
+	idx := codons(c)
+	for _, i := range idx {
+		if self.AminoAcids[i] != '*' {
+			return false
+		}
+	}
+	return idx != nil
+}
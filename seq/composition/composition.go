@@ -0,0 +1,232 @@
+// Package for sliding-window sequence composition statistics
+package composition
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+	"strconv"
+)
+
+// Base indices into Counts.
+const (
+	A = iota
+	C
+	G
+	T // Also U for RNA.
+)
+
+// The bases represented by each IUPAC nucleotide code as fractional counts. Letters
+// representing all four bases, gaps and invalid letters are unknown.
+var weights = func() (w [256]*[4]float64) {
+	for l := range w {
+		m := alphabet.BaseSet(byte(l))
+		if m == 0 || m == 15 {
+			continue
+		}
+		var n float64
+		for b := uint(0); b < 4; b++ {
+			n += float64(m >> b & 1)
+		}
+		f := &[4]float64{}
+		for b := range f {
+			f[b] = float64(m>>uint(b)&1) / n
+		}
+		w[l] = f
+	}
+	return
+}()
+
+// Counts holds the composition of a region of sequence. Ambiguity codes contribute fractionally
+// to the base counts of the bases they represent; N and other letters are counted as unknown.
+// Dinucleotides are counted only for pairs of unambiguous bases.
+type Counts struct {
+	Bases         [4]float64
+	Dinucleotides [4][4]float64
+	Unknown       int
+	Length        int
+}
+
+// Return the composition of the letters l.
+func CountsOf(l []byte) (c *Counts) {
+	c = &Counts{}
+	for i := range l {
+		c.add(l, i, 1)
+	}
+	return
+}
+
+// Add (sign 1) or remove (sign -1) the letter at index i of l and the dinucleotide it begins.
+// Removal assumes letters are removed from the start of the region, and addition that letters
+// are added to its end.
+func (self *Counts) add(l []byte, i int, sign float64) {
+	self.Length += int(sign)
+	if w := weights[l[i]]; w != nil {
+		for b, f := range w {
+			self.Bases[b] += sign * f
+		}
+	} else {
+		self.Unknown += int(sign)
+	}
+
+	j, k := i-1, i // Dinucleotide ending at an added letter.
+	if sign < 0 {
+		j, k = i, i+1 // Dinucleotide beginning at a removed letter.
+	}
+	if j < 0 || k >= len(l) {
+		return
+	}
+	if b1, b2 := alphabet.BaseIndex(l[j]), alphabet.BaseIndex(l[k]); b1 >= 0 && b2 >= 0 {
+		self.Dinucleotides[b1][b2] += sign
+	}
+}
+
+// Return the number of bases counted, excluding unknown positions.
+func (self *Counts) Known() float64 {
+	return self.Bases[A] + self.Bases[C] + self.Bases[G] + self.Bases[T]
+}
+
+// A Statistic calculates a value from the composition of a region. Values that are undefined
+// for the region are returned as NaN.
+type Statistic func(*Counts) float64
+
+// GC is the fraction of known bases that are G or C.
+func GC(c *Counts) float64 {
+	return (c.Bases[G] + c.Bases[C]) / c.Known()
+}
+
+// GCSkew is (G-C)/(G+C).
+func GCSkew(c *Counts) float64 {
+	return (c.Bases[G] - c.Bases[C]) / (c.Bases[G] + c.Bases[C])
+}
+
+// ATSkew is (A-T)/(A+T).
+func ATSkew(c *Counts) float64 {
+	return (c.Bases[A] - c.Bases[T]) / (c.Bases[A] + c.Bases[T])
+}
+
+// Entropy is the Shannon entropy of the base frequencies in bits.
+func Entropy(c *Counts) (h float64) {
+	n := c.Known()
+	if n == 0 {
+		return math.NaN()
+	}
+	for _, b := range c.Bases {
+		if p := b / n; p > 0 {
+			h -= p * math.Log2(p)
+		}
+	}
+	return
+}
+
+// CpG is the ratio of observed to expected CpG dinucleotides, CpG*N/(C*G), where N is the
+// number of known bases.
+func CpG(c *Counts) float64 {
+	return c.Dinucleotides[C][G] * c.Known() / (c.Bases[C] * c.Bases[G])
+}
+
+// Return a Statistic giving the frequency of the dinucleotide xy among the dinucleotides of
+// unambiguous bases.
+func Dinucleotide(x, y byte) (s Statistic, err error) {
+	b1, b2 := alphabet.BaseIndex(x), alphabet.BaseIndex(y)
+	if b1 < 0 || b2 < 0 {
+		return nil, bio.NewError("Invalid dinucleotide.", 0, string([]byte{x, y}))
+	}
+	return func(c *Counts) float64 {
+		var n float64
+		for _, row := range c.Dinucleotides {
+			for _, d := range row {
+				n += d
+			}
+		}
+		return c.Dinucleotides[b1][b2] / n
+	}, nil
+}
+
+// A Window holds the values of statistics for a region of sequence.
+type Window struct {
+	Start, End int
+	Values     []float64
+}
+
+// Return windows of size letters at intervals of step along s, with the values of stats for each
+// window. Windows are in sequence coordinates and only windows lying entirely within s are returned.
+func Windows(s *seq.Seq, size, step int, stats ...Statistic) (w []Window, err error) {
+	if size < 1 || step < 1 {
+		return nil, bio.NewError("Window size and step must be positive.", 0, size, step)
+	}
+	if m := s.Moltype(); m != bio.DNA && m != bio.RNA {
+		return nil, bio.NewError("Cannot calculate composition of non-nucleic acid sequence.", 0, s)
+	}
+
+	var (
+		l = s.Seq
+		c = &Counts{}
+		// Region currently counted.
+		lo, hi int
+	)
+	for start := 0; start+size <= len(l); start += step {
+		end := start + size
+		if start >= hi {
+			c, lo, hi = &Counts{}, start, start
+		}
+		for ; lo < start; lo++ {
+			c.add(l[:hi], lo, -1)
+		}
+		for ; hi < end; hi++ {
+			c.add(l[lo:hi+1], hi-lo, 1)
+		}
+
+		v := make([]float64, len(stats))
+		for i, f := range stats {
+			v[i] = f(c)
+		}
+		w = append(w, Window{Start: s.Offset + start, End: s.Offset + end, Values: v})
+	}
+
+	return
+}
+
+// Return the values of the statistic with index stat for the windows as features with the value
+// held in the Score field, suitable for writing as bedGraph.
+func Features(location string, w []Window, stat int) (f feat.FeatureSet) {
+	f = make(feat.FeatureSet, 0, len(w))
+	for _, win := range w {
+		f = append(f, &feat.Feature{
+			ID:       location + ":" + strconv.Itoa(win.Start) + ".." + strconv.Itoa(win.End),
+			Location: location,
+			Start:    win.Start,
+			End:      win.End,
+			Score:    win.Values[stat],
+			Frame:    -1,
+			Moltype:  bio.DNA,
+		})
+	}
+	return
+}
+
+// Return the values of the statistic with index stat for the windows.
+func Values(w []Window, stat int) (v []float64) {
+	v = make([]float64, len(w))
+	for i, win := range w {
+		v[i] = win.Values[stat]
+	}
+	return
+}
@@ -0,0 +1,126 @@
+package composition
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func (s *S) TestStatistics(c *check.C) {
+	cg, err := Dinucleotide('c', 'g')
+	c.Assert(err, check.IsNil)
+	_, err = Dinucleotide('C', 'N')
+	c.Check(err, check.NotNil)
+
+	for _, t := range []struct {
+		seq    string
+		stat   Statistic
+		expect float64
+	}{
+		{"GGGCCCAT", GC, 0.75},
+		{"GGGCCCAT", GCSkew, 0},
+		{"GGGCCCAA", ATSkew, 1},
+		{"GGGCCCAT", CpG, 0},
+		{"CGCG", CpG, 2},
+		{"cgcg", cg, 2. / 3},
+		{"ACGT", Entropy, 2},
+		{"AAAA", Entropy, 0},
+		{"SSWW", GC, 0.5},
+		{"GGNNGC", GCSkew, 0.5},
+		{"RRRR", ATSkew, 1},
+		{"NNNN", GC, math.NaN()},
+		{"NNNN", Entropy, math.NaN()},
+	} {
+		v := t.stat(CountsOf([]byte(t.seq)))
+		if math.IsNaN(t.expect) {
+			c.Check(math.IsNaN(v), check.Equals, true, check.Commentf("%s", t.seq))
+		} else {
+			c.Check(math.Abs(v-t.expect) < 1e-12, check.Equals, true, check.Commentf("%s: %v != %v", t.seq, v, t.expect))
+		}
+	}
+
+	counts := CountsOf([]byte("ACNGTGN"))
+	c.Check(counts.Unknown, check.Equals, 2)
+	c.Check(counts.Length, check.Equals, 7)
+	c.Check(counts.Known(), check.Equals, 5.)
+	c.Check(counts.Dinucleotides[A][C], check.Equals, 1.)
+	c.Check(counts.Dinucleotides[C][G], check.Equals, 0.)
+}
+
+func (s *S) TestWindows(c *check.C) {
+	sq := &seq.Seq{ID: "chr", Seq: []byte("AAAAGGGGCCCCTTTT"), Offset: 100}
+	w, err := Windows(sq, 4, 4, GC, GCSkew)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(w), check.Equals, 4)
+	c.Check(Values(w, 0), check.DeepEquals, []float64{0, 1, 1, 0})
+	c.Check(w[1].Values[1], check.Equals, 1.)
+	c.Check(w[2].Values[1], check.Equals, -1.)
+	c.Check(w[3].Start, check.Equals, 112)
+	c.Check(w[3].End, check.Equals, 116)
+
+	f := Features("chr", w, 0)
+	c.Check(len(f), check.Equals, 4)
+	c.Check(f[1].Start, check.Equals, 104)
+	c.Check(f[1].Score, check.Equals, 1.)
+
+	w, err = Windows(sq, 8, 3, GC)
+	c.Assert(err, check.IsNil)
+	c.Check(len(w), check.Equals, 3)
+	w, err = Windows(sq, 20, 1, GC)
+	c.Check(err, check.IsNil)
+	c.Check(len(w), check.Equals, 0)
+
+	_, err = Windows(sq, 0, 1, GC)
+	c.Check(err, check.NotNil)
+	_, err = Windows(&seq.Seq{Seq: []byte("MKVLAAGIEE"), Alphabet: alphabet.Protein}, 4, 1, GC)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestWindowsIncremental(c *check.C) {
+	l := make([]byte, 500)
+	for i := range l {
+		l[i] = "ACGTacgtNRYSW"[rand.Intn(13)]
+	}
+	sq := &seq.Seq{Seq: l}
+	cg, _ := Dinucleotide('A', 'T')
+	stats := []Statistic{GC, GCSkew, ATSkew, Entropy, CpG, cg}
+	for _, p := range [][2]int{{1, 1}, {10, 1}, {10, 3}, {10, 10}, {10, 25}, {50, 7}} {
+		w, err := Windows(sq, p[0], p[1], stats...)
+		c.Assert(err, check.IsNil)
+		c.Check(len(w), check.Equals, (len(l)-p[0])/p[1]+1)
+		for _, win := range w {
+			counts := CountsOf(l[win.Start:win.End])
+			for i, f := range stats {
+				want, got := f(counts), win.Values[i]
+				ok := math.IsNaN(want) && math.IsNaN(got) || math.Abs(want-got) < 1e-9 ||
+					math.IsInf(want, 0) && want == got
+				c.Check(ok, check.Equals, true, check.Commentf("window %v stat %d: %v != %v", p, i, got, want))
+			}
+		}
+	}
+}
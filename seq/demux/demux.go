@@ -0,0 +1,304 @@
+// Package for barcode demultiplexing of sequencing reads
+package demux
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/io/seqio/fastq"
+	"github.com/kortschak/BioGo/seq"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Barcode locations.
+const (
+	InSequence Location = iota // Barcodes begin read 1 and, for dual barcodes, read 2 and are removed on assignment.
+	InHeader                   // Barcodes are the last colon separated field of the read 1 header, dual barcodes separated by '+'.
+)
+
+type Location int8
+
+// Name given to the sample of unassigned reads.
+var Undetermined = "undetermined"
+
+// A Sample is a sample of a sample sheet. Barcode2 is the second barcode of dual indexed samples.
+type Sample struct {
+	Name     string
+	Barcode  string
+	Barcode2 string
+}
+
+// Read a sample sheet from r. Each line holds a sample name and one or two barcodes separated by
+// commas or tabs. Blank lines and lines beginning with '#' are ignored.
+func ReadSampleSheet(r io.Reader) (samples []Sample, err error) {
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		var text string
+		if text, err = br.ReadString('\n'); err != nil && (err != io.EOF || len(text) == 0) {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		text = strings.TrimSpace(text)
+		if len(text) == 0 || text[0] == '#' {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\t' })
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, bio.NewError(fmt.Sprintf("Bad sample sheet line %d", line), 0, text)
+		}
+		s := Sample{Name: strings.TrimSpace(fields[0]), Barcode: strings.TrimSpace(fields[1])}
+		if len(fields) == 3 {
+			s.Barcode2 = strings.TrimSpace(fields[2])
+		}
+		samples = append(samples, s)
+	}
+}
+
+// Stats holds assignment statistics.
+type Stats struct {
+	Reads      int            // Number of reads, or read pairs, examined.
+	Assigned   map[string]int // Number of reads assigned to each sample.
+	Mismatched map[string]int // Number of reads assigned to each sample with mismatched barcodes.
+	Unassigned int            // Number of reads matching no sample.
+	Ambiguous  int            // Number of reads matching more than one sample equally well.
+}
+
+// Writers holds the writers for the first and second reads of a sample.
+type Writers struct {
+	R1, R2 *fastq.Writer
+}
+
+// A Demultiplexer assigns reads to samples by their barcodes.
+type Demultiplexer struct {
+	Samples       []Sample
+	Location      Location
+	MaxMismatches int                 // Largest number of mismatches allowed in each barcode.
+	Writers       map[string]*Writers // Writers keyed by sample name, including Undetermined.
+	Stats         Stats
+}
+
+// Return a new Demultiplexer for the samples. An error is returned if barcodes are not of a
+// consistent length, if sample names are repeated, or if a read could match the barcodes of two
+// samples with maxMismatches mismatches in each barcode. Barcodes are held in upper case.
+func New(samples []Sample, location Location, maxMismatches int) (d *Demultiplexer, err error) {
+	samples = append([]Sample(nil), samples...)
+	names := make(map[string]bool)
+	for i := range samples {
+		samples[i].Barcode, samples[i].Barcode2 = strings.ToUpper(samples[i].Barcode), strings.ToUpper(samples[i].Barcode2)
+		s := samples[i]
+		if names[s.Name] || s.Name == Undetermined {
+			return nil, bio.NewError("Duplicate sample name.", 0, s.Name)
+		}
+		names[s.Name] = true
+		if len(s.Barcode) == 0 || len(s.Barcode) != len(samples[0].Barcode) || len(s.Barcode2) != len(samples[0].Barcode2) {
+			return nil, bio.NewError("Inconsistent barcode length.", 0, s)
+		}
+		for _, t := range samples[:i] {
+			if distance(s.Barcode, t.Barcode) <= 2*maxMismatches && distance(s.Barcode2, t.Barcode2) <= 2*maxMismatches {
+				return nil, bio.NewError("Barcode collision.", 0, s, t)
+			}
+		}
+	}
+
+	return &Demultiplexer{
+		Samples:       samples,
+		Location:      location,
+		MaxMismatches: maxMismatches,
+		Writers:       make(map[string]*Writers),
+		Stats: Stats{
+			Assigned:   make(map[string]int),
+			Mismatched: make(map[string]int),
+		},
+	}, nil
+}
+
+// Return the number of positions at which a and b differ.
+func distance(a, b string) (d int) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			d++
+		}
+	}
+	return
+}
+
+// Return the number of mismatches between the barcode b and the letters l. N matches nothing.
+func mismatches(b string, l []byte) (d int) {
+	for i := range b {
+		if c := l[i] &^ 0x20; c != b[i] || c == 'N' {
+			d++
+		}
+	}
+	return
+}
+
+// Return the barcodes of a read pair.
+func (self *Demultiplexer) barcodes(r1, r2 *seq.Seq) (b1, b2 []byte, ok bool) {
+	n1, n2 := len(self.Samples[0].Barcode), len(self.Samples[0].Barcode2)
+	switch self.Location {
+	case InSequence:
+		if r1.Len() < n1 {
+			return nil, nil, false
+		}
+		b1 = r1.Seq[:n1]
+		if n2 > 0 {
+			if r2 == nil || r2.Len() < n2 {
+				return nil, nil, false
+			}
+			b2 = r2.Seq[:n2]
+		}
+	case InHeader:
+		f := r1.ID[strings.LastIndex(r1.ID, ":")+1:]
+		parts := strings.Split(f, "+")
+		b1 = []byte(parts[0])
+		if n2 > 0 {
+			if len(parts) < 2 {
+				return nil, nil, false
+			}
+			b2 = []byte(parts[1])
+		}
+		if len(b1) != n1 || len(b2) != n2 {
+			return nil, nil, false
+		}
+	}
+	return b1, b2, true
+}
+
+// Assign the read pair, or single read if r2 is nil, to a sample. If barcodes are InSequence they
+// are removed from the returned reads using Seq.Trunc, otherwise the reads are returned unaltered.
+// The returned sample is nil if the reads cannot be unambiguously assigned.
+func (self *Demultiplexer) Assign(r1, r2 *seq.Seq) (sample *Sample, t1, t2 *seq.Seq, err error) {
+	self.Stats.Reads++
+	t1, t2 = r1, r2
+	if len(self.Samples) == 0 {
+		self.Stats.Unassigned++
+		return
+	}
+	b1, b2, ok := self.barcodes(r1, r2)
+	if !ok {
+		self.Stats.Unassigned++
+		return
+	}
+
+	best, ties := -1, 0
+	for i := range self.Samples {
+		s := &self.Samples[i]
+		d1, d2 := mismatches(s.Barcode, b1), mismatches(s.Barcode2, b2)
+		if d1 > self.MaxMismatches || d2 > self.MaxMismatches {
+			continue
+		}
+		switch d := d1 + d2; {
+		case sample == nil || d < best:
+			sample, best, ties = s, d, 0
+		case d == best:
+			ties++
+		}
+	}
+	switch {
+	case sample == nil:
+		self.Stats.Unassigned++
+		return
+	case ties > 0:
+		self.Stats.Ambiguous++
+		return nil, r1, r2, nil
+	}
+	self.Stats.Assigned[sample.Name]++
+	if best > 0 {
+		self.Stats.Mismatched[sample.Name]++
+	}
+
+	if self.Location == InSequence {
+		if t1, err = r1.Trunc(r1.Offset+len(b1), r1.End()); err != nil {
+			return nil, nil, nil, err
+		}
+		if len(b2) > 0 {
+			if t2, err = r2.Trunc(r2.Offset+len(b2), r2.End()); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
+	return
+}
+
+// Assign the read pair, or single read if r2 is nil, and write it to the writers of its sample
+// or of Undetermined if it is not assigned. Reads for which no writers are held are discarded.
+func (self *Demultiplexer) Write(r1, r2 *seq.Seq) (err error) {
+	sample, t1, t2, err := self.Assign(r1, r2)
+	if err != nil {
+		return
+	}
+	name := Undetermined
+	if sample != nil {
+		name = sample.Name
+	}
+	w, ok := self.Writers[name]
+	if !ok {
+		return
+	}
+	if w.R1 != nil {
+		if _, err = w.R1.Write(t1); err != nil {
+			return
+		}
+	}
+	if w.R2 != nil && t2 != nil {
+		_, err = w.R2.Write(t2)
+	}
+	return
+}
+
+// Create fastq writers in dir for each sample and for Undetermined reads, named by the sample and
+// read number, as in "sample_R1.fastq". Writers for second reads are created if paired is true.
+func (self *Demultiplexer) Create(dir string, paired bool) (err error) {
+	names := []string{Undetermined}
+	for _, s := range self.Samples {
+		names = append(names, s.Name)
+	}
+	for _, n := range names {
+		w := &Writers{}
+		if w.R1, err = fastq.NewWriterName(filepath.Join(dir, n+"_R1.fastq")); err != nil {
+			return
+		}
+		self.Writers[n] = w
+		if paired {
+			if w.R2, err = fastq.NewWriterName(filepath.Join(dir, n+"_R2.fastq")); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// Close all the writers held by the Demultiplexer, returning the first error encountered.
+func (self *Demultiplexer) Close() (err error) {
+	for _, w := range self.Writers {
+		for _, fw := range []*fastq.Writer{w.R1, w.R2} {
+			if fw == nil {
+				continue
+			}
+			if e := fw.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return
+}
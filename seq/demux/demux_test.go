@@ -0,0 +1,148 @@
+package demux
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/io/seqio/fastq"
+	"github.com/kortschak/BioGo/seq"
+	"io"
+	"io/ioutil"
+	check "launchpad.net/gocheck"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func read(id, l string) *seq.Seq {
+	q := make([]seq.Qsanger, len(l))
+	for i := range q {
+		q[i] = seq.Qsanger(i)
+	}
+	return seq.New(id, []byte(l), seq.NewQuality(id, q))
+}
+
+func (s *S) TestSampleSheet(c *check.C) {
+	samples, err := ReadSampleSheet(strings.NewReader("# name,barcode\nsample1,ACGTAC,GGTTCC\n\nsample2\tTGCATG\ttgcaac"))
+	c.Assert(err, check.IsNil)
+	c.Check(samples, check.DeepEquals, []Sample{{"sample1", "ACGTAC", "GGTTCC"}, {"sample2", "TGCATG", "tgcaac"}})
+	_, err = ReadSampleSheet(strings.NewReader("sample1\n"))
+	c.Check(err, check.NotNil)
+
+	d, err := New(samples, InHeader, 1)
+	c.Assert(err, check.IsNil)
+	c.Check(d.Samples[1].Barcode2, check.Equals, "TGCAAC")
+	c.Check(samples[1].Barcode2, check.Equals, "tgcaac")
+
+	_, err = New([]Sample{{"a", "ACGTAC", ""}, {"b", "ACGTAA", ""}}, InSequence, 1)
+	c.Check(err, check.NotNil)
+	_, err = New([]Sample{{"a", "ACGTAC", ""}, {"b", "ACGTAA", ""}}, InSequence, 0)
+	c.Check(err, check.IsNil)
+	_, err = New([]Sample{{"a", "ACGTAC", ""}, {"a", "TTTTTT", ""}}, InSequence, 0)
+	c.Check(err, check.NotNil)
+	_, err = New([]Sample{{"a", "ACGTAC", ""}, {"b", "TTTTT", ""}}, InSequence, 0)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestAssign(c *check.C) {
+	d, err := New([]Sample{{"s1", "ACGTAC", ""}, {"s2", "TGCATG", ""}}, InSequence, 1)
+	c.Assert(err, check.IsNil)
+
+	sample, t1, t2, err := d.Assign(read("r", "ACGTACGGGG"), nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(sample, check.NotNil)
+	c.Check(sample.Name, check.Equals, "s1")
+	c.Check(string(t1.Seq), check.Equals, "GGGG")
+	c.Check(t1.Quality.Qual, check.DeepEquals, []seq.Qsanger{6, 7, 8, 9})
+	c.Check(t2, check.IsNil)
+
+	for _, r := range []string{"acgttcGGGG", "TGCATGAAAA", "NNNNNNGGGG", "ACG"} {
+		_, _, _, err = d.Assign(read("r", r), nil)
+		c.Check(err, check.IsNil)
+	}
+	c.Check(d.Stats.Reads, check.Equals, 5)
+	c.Check(d.Stats.Assigned, check.DeepEquals, map[string]int{"s1": 2, "s2": 1})
+	c.Check(d.Stats.Mismatched, check.DeepEquals, map[string]int{"s1": 1})
+	c.Check(d.Stats.Unassigned, check.Equals, 2)
+
+	// Dual barcodes from the header.
+	d, err = New([]Sample{{"s1", "ACGTAC", "GGTTCC"}, {"s2", "ACGTAC", "TTGGAA"}}, InHeader, 1)
+	c.Assert(err, check.IsNil)
+	for _, t := range []struct {
+		id     string
+		sample string
+	}{
+		{"read1 1:N:0:ACGTAC+GGTTCC", "s1"},
+		{"read2 1:N:0:ACGTAA+TTGGAA", "s2"},
+		{"read3 1:N:0:ACGTAC", ""},
+		{"read4 1:N:0:ACGTTT+TTGGAA", ""},
+	} {
+		sample, t1, t2, err = d.Assign(read(t.id, "ACGT"), read(t.id, "TTTT"))
+		c.Assert(err, check.IsNil)
+		if t.sample == "" {
+			c.Check(sample, check.IsNil)
+		} else {
+			c.Check(sample.Name, check.Equals, t.sample)
+		}
+		c.Check(string(t1.Seq), check.Equals, "ACGT")
+		c.Check(string(t2.Seq), check.Equals, "TTTT")
+	}
+}
+
+func (s *S) TestWrite(c *check.C) {
+	dir, err := ioutil.TempDir("", "demux")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	d, err := New([]Sample{{"s1", "ACG", "TTA"}, {"s2", "GTC", "CAT"}}, InSequence, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(d.Create(dir, true), check.IsNil)
+	for _, p := range [][2]string{{"ACGAAAA", "TTACCCC"}, {"GTCAAAA", "CATCCCC"}, {"ACGAAAA", "TTAGGGG"}, {"AAAAAAA", "CCCCCCC"}} {
+		c.Check(d.Write(read("r", p[0]), read("r", p[1])), check.IsNil)
+	}
+	c.Assert(d.Close(), check.IsNil)
+
+	for _, t := range []struct {
+		file string
+		seqs []string
+	}{
+		{"s1_R1.fastq", []string{"AAAA", "AAAA"}},
+		{"s1_R2.fastq", []string{"CCCC", "GGGG"}},
+		{"s2_R2.fastq", []string{"CCCC"}},
+		{Undetermined + "_R1.fastq", []string{"AAAAAAA"}},
+	} {
+		r, err := fastq.NewReaderName(filepath.Join(dir, t.file))
+		c.Assert(err, check.IsNil)
+		var got []string
+		for {
+			s, err := r.Read()
+			if err != nil {
+				c.Check(err, check.Equals, io.EOF)
+				break
+			}
+			got = append(got, string(s.Seq))
+		}
+		r.Close()
+		c.Check(got, check.DeepEquals, t.seqs, check.Commentf("%s", t.file))
+	}
+}
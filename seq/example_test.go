@@ -17,6 +17,7 @@ package seq
 
 import (
 	"fmt"
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/feat"
 	"strings"
@@ -24,10 +25,10 @@ import (
 
 func ExampleSeq_New() {
 	d := New("example sequence", []byte("ACGCTGACTTGGTGCACGT"), nil) // Default to bio.DNA
-	fmt.Println(d, d.Moltype)
+	fmt.Println(d, d.Moltype())
 	// Alternative using struct literal
-	r := &Seq{ID: "example RNA", Seq: d.Seq, Moltype: bio.RNA}
-	fmt.Println(r, r.Moltype)
+	r := &Seq{ID: "example RNA", Seq: d.Seq, Alphabet: alphabet.RNA}
+	fmt.Println(r, r.Moltype())
 	if ok, pos := bio.ValidR.Check(r.Seq); ok {
 		fmt.Println("valid RNA")
 	} else {
@@ -106,6 +107,35 @@ func ExampleSeq_RevComp_2() {
 	// !!!!7-+13345,#,3330+11135554550).6930355523-54433059797873237796158853474.#
 }
 
+func ExampleSeq_RevComp_iupac() {
+	s := &Seq{Seq: []byte("ACGTRYKMSWBDHVN-acgtrykmswbdhvn")}
+	fmt.Println(s)
+	if t, err := s.RevComp(); err == nil {
+		fmt.Println(t)
+	}
+	r := &Seq{Seq: []byte("ACGURYN-"), Alphabet: alphabet.RNA}
+	if t, err := r.RevComp(); err == nil {
+		fmt.Println(t)
+	}
+	// Output:
+	// ACGTRYKMSWBDHVN-acgtrykmswbdhvn
+	// nbdhvwskmryacgt-NBDHVWSKMRYACGT
+	// -NRYACGU
+}
+
+func ExampleSeq_Validate() {
+	s := &Seq{Seq: []byte("ACGTRYKMSWBDHVNJ")}
+	fmt.Println(s.Validate())
+	if err := s.SetAlphabet(alphabet.DNA); err != nil {
+		fmt.Println("Error:", err)
+	}
+	fmt.Println(s.Moltype())
+	// Output:
+	// false 15
+	// Error: Invalid letter in sequence.
+	// DNA
+}
+
 func ExampleQuality_Reverse() {
 	q := &Quality{Qual: []Qsanger{40, 40, 40, 39, 40, 36, 38, 32, 21, 13, 9, 0, 0, 0}}
 	fmt.Println(q)
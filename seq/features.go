@@ -0,0 +1,155 @@
+package seq
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/util"
+)
+
+// A segment maps the source indices from start to end onto the destination indices beginning at to.
+type segment struct {
+	start, end, to int
+}
+
+// A fragment is a destination range lo to hi holding the part of a feature from ulo to uhi, measured
+// from the feature's Start in the source.
+type fragment struct {
+	lo, hi, ulo, uhi int
+}
+
+// Return the Features of the Seq mapped through segs onto the indices of a destination sequence of
+// length n, reversing them if reverse is true. Features are copied and parts of features that are
+// not mapped are dropped, marking the remainder as truncated. A feature whose mapped parts are not
+// contiguous in the destination is split. If circular is true, parts meeting at the origin of the
+// destination are described by a single feature with a Start greater than its End.
+func (self *Seq) remapFeatures(segs []segment, n int, reverse, circular bool) (fs feat.FeatureSet) {
+	for _, f := range self.Features {
+		start, end := f.Start-self.Offset, f.End-self.Offset
+		var pieces [][2]int
+		switch {
+		case start <= end:
+			pieces = [][2]int{{start, end}}
+		case self.Circular:
+			pieces = [][2]int{{start, len(self.Seq)}, {0, end}}
+		default:
+			continue
+		}
+
+		var frags []fragment
+		u := 0
+		for _, p := range pieces {
+			for _, sg := range segs {
+				lo, hi := p[0], p[1]
+				if lo < sg.start {
+					lo = sg.start
+				}
+				if hi > sg.end {
+					hi = sg.end
+				}
+				if lo > hi || (lo == hi && (p[0] != p[1] || lo < sg.start || lo >= sg.end)) {
+					continue
+				}
+				fr := fragment{ulo: u + lo - p[0], uhi: u + hi - p[0]}
+				if reverse {
+					fr.lo, fr.hi = n-(sg.to+hi-sg.start), n-(sg.to+lo-sg.start)
+				} else {
+					fr.lo, fr.hi = sg.to+lo-sg.start, sg.to+hi-sg.start
+				}
+				frags = append(frags, fr)
+			}
+			u += p[1] - p[0]
+		}
+		length := u
+
+		frags = mergeFragments(frags)
+		if l := len(frags); circular && l > 1 {
+			if first, last := frags[0], frags[l-1]; first.lo == 0 && last.hi == n && adjoins(last, first) {
+				last.hi = first.hi
+				last.ulo, last.uhi = util.Min(last.ulo, first.ulo), util.Max(last.uhi, first.uhi)
+				frags = append(frags[1:l-1], last)
+			}
+		}
+
+		for _, fr := range frags {
+			nf := *f
+			nf.Start, nf.End = fr.lo, fr.hi
+
+			lowCut, highCut := fr.ulo > 0, fr.uhi < length
+			lowKept, highKept := f.Truncated&feat.TruncatedStart, f.Truncated&feat.TruncatedEnd
+			if reverse {
+				lowCut, highCut = highCut, lowCut
+				lowKept, highKept = highKept>>1, lowKept<<1
+				nf.Strand = -f.Strand
+			}
+			nf.Truncated = 0
+			if lowCut {
+				nf.Truncated |= feat.TruncatedStart
+			} else {
+				nf.Truncated |= lowKept
+			}
+			if highCut {
+				nf.Truncated |= feat.TruncatedEnd
+			} else {
+				nf.Truncated |= highKept
+			}
+
+			if f.Frame >= 0 {
+				removed := fr.ulo // Bases removed from the 5' end of the feature.
+				if f.Strand < 0 {
+					removed = length - fr.uhi
+				}
+				nf.Frame = int8(((int(f.Frame)-removed)%3 + 3) % 3)
+			}
+
+			fs = append(fs, &nf)
+		}
+	}
+
+	return
+}
+
+// Return fragments sorted by destination position with adjoining fragments merged.
+func mergeFragments(frags []fragment) (m []fragment) {
+	for i := 1; i < len(frags); i++ {
+		for j := i; j > 0 && frags[j].lo < frags[j-1].lo; j-- {
+			frags[j], frags[j-1] = frags[j-1], frags[j]
+		}
+	}
+	for _, fr := range frags {
+		if l := len(m); l > 0 && m[l-1].hi == fr.lo && adjoins(m[l-1], fr) {
+			m[l-1].hi = fr.hi
+			m[l-1].ulo, m[l-1].uhi = util.Min(m[l-1].ulo, fr.ulo), util.Max(m[l-1].uhi, fr.uhi)
+			continue
+		}
+		m = append(m, fr)
+	}
+	return
+}
+
+// Return whether the fragments a and b hold adjoining parts of a feature.
+func adjoins(a, b fragment) bool {
+	return a.uhi == b.ulo || b.uhi == a.ulo
+}
+
+// Translate the indices of features to sequence coordinates with the given offset.
+func offsetFeatures(fs feat.FeatureSet, offset int) feat.FeatureSet {
+	for _, f := range fs {
+		f.Start += offset
+		f.End += offset
+	}
+	return fs
+}
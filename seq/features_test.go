@@ -0,0 +1,144 @@
+package seq
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/feat"
+	check "launchpad.net/gocheck"
+)
+
+type featureState struct {
+	start, end int
+	strand     int8
+	frame      int8
+	truncated  int8
+}
+
+func states(fs feat.FeatureSet) (s []featureState) {
+	for _, f := range fs {
+		s = append(s, featureState{f.Start, f.End, f.Strand, f.Frame, f.Truncated})
+	}
+	return
+}
+
+func annotated(circular bool) *Seq {
+	return &Seq{
+		ID:       "x",
+		Seq:      []byte("AAAACCCCGGGGTTTT"),
+		Offset:   10,
+		Strand:   1,
+		Circular: circular,
+		Features: feat.FeatureSet{
+			{ID: "a", Start: 10, End: 14, Strand: 1, Frame: 0},
+			{ID: "b", Start: 12, End: 20, Strand: -1, Frame: 1},
+			{ID: "c", Start: 24, End: 26, Strand: 1, Frame: -1, Truncated: feat.TruncatedEnd},
+		},
+	}
+}
+
+func (s *S) TestFeaturesTrunc(c *check.C) {
+	sq := annotated(false)
+	t, err := sq.Trunc(13, 25)
+	c.Assert(err, check.IsNil)
+	c.Check(states(t.Features), check.DeepEquals, []featureState{
+		{13, 14, 1, 0, feat.TruncatedStart},
+		{13, 20, -1, 1, feat.TruncatedStart},
+		{24, 25, 1, -1, feat.TruncatedEnd},
+	})
+	c.Check(states(sq.Features)[0], check.Equals, featureState{10, 14, 1, 0, 0})
+
+	t, err = sq.Trunc(11, 13)
+	c.Assert(err, check.IsNil)
+	c.Check(states(t.Features), check.DeepEquals, []featureState{
+		{11, 13, 1, 2, feat.TruncatedStart | feat.TruncatedEnd},
+		{12, 13, -1, 0, feat.TruncatedEnd},
+	})
+
+	// Inplace truncation places features as a copying truncation does.
+	sq = annotated(false)
+	t, err = sq.Trunc(14, 20)
+	c.Assert(err, check.IsNil)
+	sq.Inplace = true
+	r, err := sq.Trunc(14, 20)
+	c.Assert(err, check.IsNil)
+	c.Check(r, check.Equals, sq)
+	c.Check(r.Offset, check.Equals, t.Offset)
+	c.Check(states(r.Features), check.DeepEquals, states(t.Features))
+
+	// Features of circular sequences may span the origin and survive wrapping truncation.
+	sq = annotated(true)
+	sq.Features = append(sq.Features, &feat.Feature{ID: "d", Start: 24, End: 12, Strand: 1, Frame: -1})
+	t, err = sq.Trunc(22, 14)
+	c.Assert(err, check.IsNil)
+	c.Check(string(t.Seq), check.Equals, "TTTTAAAA")
+	c.Check(states(t.Features), check.DeepEquals, []featureState{
+		{26, 30, 1, 0, 0},
+		{28, 30, -1, 1, feat.TruncatedEnd},
+		{24, 26, 1, -1, feat.TruncatedEnd},
+		{24, 28, 1, -1, 0},
+	})
+}
+
+func (s *S) TestFeaturesRevComp(c *check.C) {
+	sq := annotated(false)
+	r, err := sq.RevComp()
+	c.Assert(err, check.IsNil)
+	c.Check(states(r.Features), check.DeepEquals, []featureState{
+		{38, 42, -1, 0, 0},
+		{32, 40, 1, 1, 0},
+		{26, 28, -1, -1, feat.TruncatedStart},
+	})
+
+	sq = annotated(true)
+	sq.Features = feat.FeatureSet{{Start: 24, End: 12, Strand: 1, Frame: -1}}
+	r, err = sq.RevComp()
+	c.Assert(err, check.IsNil)
+	c.Check(states(r.Features), check.DeepEquals, []featureState{{40, 28, -1, -1, 0}})
+}
+
+func (s *S) TestFeaturesJoin(c *check.C) {
+	a := annotated(false)
+	b := &Seq{ID: "y", Seq: []byte("ACGT"), Features: feat.FeatureSet{{Start: 1, End: 3, Frame: -1}}}
+	j, err := a.Join(b, Append)
+	c.Assert(err, check.IsNil)
+	c.Check(states(j.Features), check.DeepEquals, []featureState{
+		{0, 4, 1, 0, 0},
+		{2, 10, -1, 1, 0},
+		{14, 16, 1, -1, feat.TruncatedEnd},
+		{17, 19, 0, -1, 0},
+	})
+	j, err = a.Join(b, Prepend)
+	c.Assert(err, check.IsNil)
+	c.Check(states(j.Features), check.DeepEquals, []featureState{
+		{-3, -1, 0, -1, 0},
+		{0, 4, 1, 0, 0},
+		{2, 10, -1, 1, 0},
+		{14, 16, 1, -1, feat.TruncatedEnd},
+	})
+}
+
+func (s *S) TestFeaturesStitch(c *check.C) {
+	sq := annotated(false)
+	t, err := sq.Stitch(feat.FeatureSet{{Start: 11, End: 13}, {Start: 16, End: 18}, {Start: 22, End: 30}})
+	c.Assert(err, check.IsNil)
+	c.Check(string(t.Seq), check.Equals, "AACCTTTT")
+	c.Check(states(t.Features), check.DeepEquals, []featureState{
+		{0, 2, 1, 2, feat.TruncatedStart | feat.TruncatedEnd},
+		{1, 2, -1, 0, feat.TruncatedEnd},
+		{2, 4, -1, 2, feat.TruncatedStart | feat.TruncatedEnd},
+		{6, 8, 1, -1, feat.TruncatedEnd},
+	})
+}
@@ -0,0 +1,124 @@
+// Package for memory-mapped on-disk sequences
+//
+// A prepared sequence file is mapped read-only into memory and its sequence is exposed without
+// copying, so genomes larger than available memory can be used and processes mapping the same
+// file share one image through the page cache. A prepared file is either plain, holding only the
+// letters of the sequence, or a packed sequence image written by packed.Seq's WriteTo.
+package mapped
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/index/kmerindex"
+	"github.com/kortschak/BioGo/seq"
+	"github.com/kortschak/BioGo/seq/packed"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A File is a memory-mapped prepared sequence file.
+type File struct {
+	ID   string // ID given to sequences read from a plain file. Defaults to the file name without extension.
+	data []byte
+}
+
+// Open and map the named prepared sequence file.
+func Open(name string) (m *File, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() != int64(int(fi.Size())) {
+		return nil, bio.NewError("File too large to map.", 0, name)
+	}
+
+	m = &File{ID: strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))}
+	if fi.Size() > 0 {
+		if m.data, err = mmap(f, int(fi.Size())); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Unmap the File. Sequences obtained from the File must not be used after Close.
+func (self *File) Close() (err error) {
+	if self.data != nil {
+		err = munmap(self.data)
+		self.data = nil
+	}
+	return
+}
+
+// Return whether the File holds a packed sequence image.
+func (self *File) IsPacked() bool { return packed.IsImage(self.data) }
+
+// Return the sequence held by a plain File. The letters of the returned Seq are the mapped file,
+// which is read-only, so operations on the Seq return copies of only the parts they use. Setting
+// Inplace allows Trunc to return views of the mapping, but other Inplace operations will fault.
+func (self *File) Seq() (s *seq.Seq, err error) {
+	if self.IsPacked() {
+		return nil, bio.NewError("Cannot read packed image as plain sequence.", 0, self.ID)
+	}
+	return &seq.Seq{ID: self.ID, Seq: self.data}, nil
+}
+
+// Return the sequence held by a packed File. The bases of the returned Seq are the mapped file.
+func (self *File) Packed() (s *packed.Seq, err error) {
+	return packed.ReadImage(self.data)
+}
+
+// Return a new kmer index of the File's sequence with a word size of k.
+func (self *File) Index(k int) (i *kmerindex.Index, err error) {
+	if self.IsPacked() {
+		var p *packed.Seq
+		if p, err = self.Packed(); err != nil {
+			return nil, err
+		}
+		return kmerindex.NewPacked(k, p)
+	}
+	var s *seq.Seq
+	if s, err = self.Seq(); err != nil {
+		return nil, err
+	}
+	return kmerindex.New(k, s)
+}
+
+// Write the letters of s to w as a plain prepared sequence file.
+func WritePlain(w io.Writer, s *seq.Seq) (err error) {
+	_, err = w.Write(s.Seq)
+	return
+}
+
+// Write s to w as a packed prepared sequence file.
+func WritePacked(w io.Writer, s *seq.Seq) (err error) {
+	var p *packed.Seq
+	if p, err = packed.Pack(s); err != nil {
+		return
+	}
+	_, err = p.WriteTo(w)
+	return
+}
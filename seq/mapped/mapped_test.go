@@ -0,0 +1,108 @@
+package mapped
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/seq"
+	"io/ioutil"
+	check "launchpad.net/gocheck"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct {
+	dir string
+}
+
+var _ = check.Suite(&S{})
+
+var testSeq = &seq.Seq{ID: "chr", Seq: []byte("ACGTTGCAacgtNNNNACGTACGGTACCAGTTGACCA")}
+
+func (s *S) SetUpSuite(c *check.C) {
+	var err error
+	s.dir, err = ioutil.TempDir("", "mapped")
+	c.Assert(err, check.IsNil)
+	for name, write := range map[string]func(*os.File, *seq.Seq) error{
+		"plain.seq":  func(f *os.File, s *seq.Seq) error { return WritePlain(f, s) },
+		"packed.seq": func(f *os.File, s *seq.Seq) error { return WritePacked(f, s) },
+	} {
+		f, err := os.Create(filepath.Join(s.dir, name))
+		c.Assert(err, check.IsNil)
+		c.Assert(write(f, testSeq), check.IsNil)
+		c.Assert(f.Close(), check.IsNil)
+	}
+}
+
+func (s *S) TearDownSuite(c *check.C) {
+	os.RemoveAll(s.dir)
+}
+
+func (s *S) TestPlain(c *check.C) {
+	m, err := Open(filepath.Join(s.dir, "plain.seq"))
+	c.Assert(err, check.IsNil)
+	defer m.Close()
+	c.Check(m.IsPacked(), check.Equals, false)
+	sq, err := m.Seq()
+	c.Assert(err, check.IsNil)
+	c.Check(sq.ID, check.Equals, "plain")
+	c.Check(string(sq.Seq), check.Equals, string(testSeq.Seq))
+	t, err := sq.Trunc(4, 12)
+	c.Check(err, check.IsNil)
+	c.Check(string(t.Seq), check.Equals, "TGCAacgt")
+	_, err = m.Packed()
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestPacked(c *check.C) {
+	m, err := Open(filepath.Join(s.dir, "packed.seq"))
+	c.Assert(err, check.IsNil)
+	defer m.Close()
+	c.Check(m.IsPacked(), check.Equals, true)
+	p, err := m.Packed()
+	c.Assert(err, check.IsNil)
+	c.Check(p.ID, check.Equals, "chr")
+	c.Check(p.String(), check.Equals, string(testSeq.Seq))
+	r, err := p.RevComp()
+	c.Check(err, check.IsNil)
+	rc, _ := testSeq.RevComp()
+	c.Check(r.String(), check.Equals, string(rc.Seq))
+	_, err = m.Seq()
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestIndex(c *check.C) {
+	var want map[string][]int
+	for _, name := range []string{"plain.seq", "packed.seq"} {
+		m, err := Open(filepath.Join(s.dir, name))
+		c.Assert(err, check.IsNil)
+		i, err := m.Index(4)
+		c.Assert(err, check.IsNil)
+		i.Build()
+		pos, ok := i.StringKmerIndex()
+		c.Check(ok, check.Equals, true)
+		if want == nil {
+			want = pos
+		} else {
+			c.Check(pos, check.DeepEquals, want)
+		}
+		c.Check(m.Close(), check.IsNil)
+	}
+	c.Check(want["ACGT"], check.DeepEquals, []int{0, 8, 16})
+}
@@ -0,0 +1,33 @@
+//go:build !darwin && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!freebsd,!linux,!netbsd,!openbsd
+
+package mapped
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"io"
+	"os"
+)
+
+// Systems without mmap read the file into memory.
+func mmap(f *os.File, size int) (b []byte, err error) {
+	b = make([]byte, size)
+	_, err = io.ReadFull(f, b)
+	return
+}
+
+func munmap(b []byte) error { return nil }
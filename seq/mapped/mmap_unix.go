@@ -1,6 +1,9 @@
-package filter
+//go:build darwin || freebsd || linux || netbsd || openbsd
+// +build darwin freebsd linux netbsd openbsd
 
-// Copyright ©2011 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+package mapped
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
 //
 // This program is free software: you can redistribute it and/or modify
 // it under the terms of the GNU General Public License as published by
@@ -16,19 +19,14 @@ package filter
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
-	"github.com/kortschak/BioGo/bio"
-	"github.com/kortschak/BioGo/util"
+	"os"
+	"syscall"
 )
 
-var lookUp util.CTL
-
-func init() {
-	m := make(map[int]int)
-
-	for i, v := range bio.N {
-		m[int(v)] = i
-		m[int(v+32)] = i
-	}
+func mmap(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+}
 
-	lookUp = *util.NewCTL(m)
+func munmap(b []byte) error {
+	return syscall.Munmap(b)
 }
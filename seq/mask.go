@@ -0,0 +1,168 @@
+package seq
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/interval"
+	"math"
+)
+
+// Feature type given to soft-masked regions.
+var MaskFeature = "masked"
+
+// Return whether the letter l is lower case and its case is not significant in the alphabet a,
+// so that it represents a soft-masked position.
+func IsSoftMasked(a alphabet.Alphabet, l byte) bool {
+	return 'a' <= l && l <= 'z' && a.IndexOf(l) == a.IndexOf(l-'a'+'A')
+}
+
+// Return the runs of soft-masked letters of the Seq as features in sequence coordinates.
+func (self *Seq) SoftMasked() (f feat.FeatureSet) {
+	a := self.GetAlphabet()
+	start := -1
+	for i := 0; i <= len(self.Seq); i++ {
+		masked := i < len(self.Seq) && IsSoftMasked(a, self.Seq[i])
+		switch {
+		case masked && start < 0:
+			start = i
+		case !masked && start >= 0:
+			f = append(f, &feat.Feature{
+				ID:       self.ID,
+				Location: self.ID,
+				Start:    self.Offset + start,
+				End:      self.Offset + i,
+				Feature:  MaskFeature,
+				Score:    math.NaN(),
+				Strand:   self.Strand,
+				Moltype:  self.Moltype(),
+			})
+			start = -1
+		}
+	}
+
+	return
+}
+
+// Return the soft-masked regions of the Seq as an interval tree keyed on the Seq's ID. The Meta
+// field of each interval holds the corresponding feature.
+func (self *Seq) SoftMaskedTree() (t interval.Tree, err error) {
+	t = interval.NewTree()
+	for _, f := range self.SoftMasked() {
+		var i *interval.Interval
+		if i, err = interval.New(self.ID, f.Start, f.End, 0, f); err != nil {
+			return nil, err
+		}
+		t.Insert(i)
+	}
+
+	return
+}
+
+// Return a Seq with the regions covered by the features in f, which are in sequence coordinates,
+// soft-masked by converting their letters to lower case. Letters whose case is significant in the
+// alphabet of the Seq are not changed. Features of a circular Seq with a Start greater than their
+// End span the origin.
+func (self *Seq) SoftMask(f feat.FeatureSet) (s *Seq, err error) {
+	a := self.GetAlphabet()
+	return self.mask(f, func(l byte) byte {
+		if 'A' <= l && l <= 'Z' {
+			if m := l - 'A' + 'a'; a.IndexOf(m) == a.IndexOf(l) {
+				return m
+			}
+		}
+		return l
+	})
+}
+
+// Return a Seq with the regions covered by the features in f, which are in sequence coordinates,
+// hard-masked by replacing their letters with N for nucleic acid sequences or X for protein.
+// Features of a circular Seq with a Start greater than their End span the origin.
+func (self *Seq) HardMask(f feat.FeatureSet) (s *Seq, err error) {
+	var m byte
+	switch self.Moltype() {
+	case bio.DNA, bio.RNA:
+		m = 'N'
+	case bio.Protein:
+		m = 'X'
+	default:
+		return nil, bio.NewError("Cannot hard-mask sequence of undefined type.", 0, self)
+	}
+	return self.mask(f, func(byte) byte { return m })
+}
+
+func (self *Seq) mask(f feat.FeatureSet, fn func(byte) byte) (s *Seq, err error) {
+	var ms []byte
+	if self.Inplace {
+		ms = self.Seq
+	} else {
+		if self.Quality != nil && self.Quality.Inplace {
+			return nil, bio.NewError("Inplace operation on Quality with non-Inplace operation on parent Seq.", 0, self)
+		}
+		ms = append([]byte(nil), self.Seq...)
+	}
+
+	apply := func(start, end int) {
+		start -= self.Offset
+		end -= self.Offset
+		if start < 0 {
+			start = 0
+		}
+		if end > len(ms) {
+			end = len(ms)
+		}
+		for i := start; i < end; i++ {
+			ms[i] = fn(ms[i])
+		}
+	}
+	for _, feature := range f {
+		switch {
+		case feature.Start <= feature.End:
+			apply(feature.Start, feature.End)
+		case self.Circular:
+			apply(feature.Start, self.End())
+			apply(self.Start(), feature.End)
+		default:
+			return nil, bio.NewError("Feature end < start for non-circular molecule.", 0, feature)
+		}
+	}
+
+	if self.Inplace {
+		return self, nil
+	}
+
+	var q *Quality
+	if self.Quality != nil {
+		if q, err = self.Quality.Trunc(self.Quality.Start(), self.Quality.End()); err != nil {
+			return nil, bio.NewError("Quality.Trunc() returned error", 0, err)
+		}
+		q.Circular = self.Quality.Circular
+	}
+	s = &Seq{
+		ID:       self.ID,
+		Seq:      ms,
+		Offset:   self.Offset,
+		Strand:   self.Strand,
+		Circular: self.Circular,
+		Alphabet: self.Alphabet,
+		Quality:  q,
+	}
+	s.Features = offsetFeatures(self.remapFeatures([]segment{{0, len(ms), 0}}, len(ms), false, self.Circular), s.Offset)
+
+	return
+}
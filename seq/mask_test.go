@@ -0,0 +1,82 @@
+package seq
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/interval"
+	check "launchpad.net/gocheck"
+)
+
+func spans(f feat.FeatureSet) (s [][2]int) {
+	for _, feature := range f {
+		s = append(s, [2]int{feature.Start, feature.End})
+	}
+	return
+}
+
+func (s *S) TestSoftMasked(c *check.C) {
+	sq := &Seq{ID: "x", Seq: []byte("acGTACgtacGTn"), Offset: 10}
+	c.Check(spans(sq.SoftMasked()), check.DeepEquals, [][2]int{{10, 12}, {16, 20}, {22, 23}})
+	c.Check(sq.SoftMasked()[0].Feature, check.Equals, MaskFeature)
+
+	t, err := sq.SoftMaskedTree()
+	c.Assert(err, check.IsNil)
+	q, err := interval.New("x", 11, 17, 0, nil)
+	c.Assert(err, check.IsNil)
+	var hits [][2]int
+	for i := range t.Intersect(q, 0) {
+		hits = append(hits, [2]int{i.Start(), i.End()})
+		_, ok := i.Meta.(*feat.Feature)
+		c.Check(ok, check.Equals, true)
+	}
+	c.Check(len(hits), check.Equals, 2)
+
+	// Case is significant in this alphabet, so lower case letters are not masked.
+	meth, err := alphabet.NewGeneric("ACGTm", alphabet.CaseSensitive)
+	c.Assert(err, check.IsNil)
+	c.Check(spans((&Seq{Seq: []byte("ACmGT"), Alphabet: meth}).SoftMasked()), check.IsNil)
+}
+
+func (s *S) TestMask(c *check.C) {
+	sq := &Seq{ID: "x", Seq: []byte("ACGTACGTAC"), Offset: 10, Circular: true}
+	f := feat.FeatureSet{{Start: 8, End: 12}, {Start: 15, End: 16}, {Start: 19, End: 11}}
+
+	m, err := sq.SoftMask(f)
+	c.Assert(err, check.IsNil)
+	c.Check(string(m.Seq), check.Equals, "acGTAcGTAc")
+	c.Check(string(sq.Seq), check.Equals, "ACGTACGTAC")
+	c.Check(spans(m.SoftMasked()), check.DeepEquals, [][2]int{{10, 12}, {15, 16}, {19, 20}})
+
+	m, err = sq.HardMask(f)
+	c.Assert(err, check.IsNil)
+	c.Check(string(m.Seq), check.Equals, "NNGTANGTAN")
+
+	m, err = (&Seq{Seq: []byte("MKVLA"), Alphabet: alphabet.Protein}).HardMask(feat.FeatureSet{{Start: 1, End: 3}})
+	c.Assert(err, check.IsNil)
+	c.Check(string(m.Seq), check.Equals, "MXXLA")
+
+	sq.Circular = false
+	_, err = sq.SoftMask(f)
+	c.Check(err, check.NotNil)
+
+	sq.Inplace = true
+	m, err = sq.SoftMask(f[:2])
+	c.Assert(err, check.IsNil)
+	c.Check(m, check.Equals, sq)
+	c.Check(string(sq.Seq), check.Equals, "acGTAcGTAC")
+}
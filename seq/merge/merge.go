@@ -0,0 +1,173 @@
+// Package for merging overlapping paired-end reads
+package merge
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+)
+
+// A Merger merges read pairs whose mates overlap.
+type Merger struct {
+	MinOverlap         int         // Shortest overlap considered.
+	MaxMismatchDensity float64     // Largest fraction of mismatched overlap positions accepted.
+	MaxQuality         seq.Qsanger // Largest quality given to a consensus base.
+	Stagger            bool        // Allow inserts shorter than the reads, discarding the read-through bases.
+
+	Merged, Unmerged int // Number of pairs merged and not merged.
+}
+
+// Return a new Merger with a MinOverlap of 10, a MaxMismatchDensity of 0.1 and a MaxQuality of 41.
+func New() *Merger {
+	return &Merger{
+		MinOverlap:         10,
+		MaxMismatchDensity: 0.1,
+		MaxQuality:         41,
+	}
+}
+
+// Return the offset of the start of the reverse complemented second mate, b, relative to the
+// first, a, with the lowest mismatch density and the length of the overlap, or ok false if no
+// acceptable overlap is found. Ties are broken in favour of longer overlaps.
+func (self *Merger) overlap(a, b []byte) (offset, length int, ok bool) {
+	lo := 0
+	if self.Stagger {
+		lo = -(len(b) - self.MinOverlap)
+	}
+	best := math.Inf(1)
+	for d := len(a) - self.MinOverlap; d >= lo; d-- {
+		start, end := d, d+len(b)
+		if start < 0 {
+			start = 0
+		}
+		if end > len(a) {
+			end = len(a)
+		}
+		n := end - start
+		if n < self.MinOverlap || n < 1 {
+			continue
+		}
+		var mismatches int
+		for i := start; i < end; i++ {
+			if !equal(a[i], b[i-d]) {
+				mismatches++
+			}
+		}
+		if density := float64(mismatches) / float64(n); density <= self.MaxMismatchDensity &&
+			(density < best || density == best && n > length) {
+			best, offset, length, ok = density, d, n, true
+		}
+	}
+	return
+}
+
+// Return whether the letters x and y are the same unambiguous base.
+func equal(x, y byte) bool {
+	x, y = x&^0x20, y&^0x20 // Upper case.
+	return x == y && x != 'N'
+}
+
+// Return the probability of error of the consensus of bases with error probabilities px and py,
+// where x is chosen for the consensus, as described by Edgar and Flyvbjerg (2015).
+func posterior(px, py float64, agree bool) float64 {
+	if agree {
+		return px * py / 3 / (1 - px - py + 4*px*py/3)
+	}
+	return px * (1 - py/3) / (px + py - 4*px*py/3)
+}
+
+// Return the Qsanger score of the error probability p, limited to max.
+func score(p float64, max seq.Qsanger) seq.Qsanger {
+	if p <= 0 {
+		return max
+	}
+	q := -10 * math.Log10(p)
+	if q >= float64(max) {
+		return max
+	}
+	if q < 0 {
+		return 0
+	}
+	return seq.Qsanger(q + 0.5)
+}
+
+// Merge the read pair r1 and r2, returning the consensus of r1 and the reverse complement of r2
+// with quality scores recalculated from the mates' error probabilities, or nil if the mates do not
+// overlap acceptably. Both reads must have quality scores. The returned sequence takes the ID and
+// Offset of r1; r2 is reverse complemented using Seq.RevComp and so follows the semantics of Inplace.
+func (self *Merger) Merge(r1, r2 *seq.Seq) (m *seq.Seq, err error) {
+	if r1.Quality == nil || r2.Quality == nil || r1.Quality.Len() != r1.Len() || r2.Quality.Len() != r2.Len() {
+		return nil, bio.NewError("Reads must have quality scores.", 0, r1.ID, r2.ID)
+	}
+	var rc *seq.Seq
+	if rc, err = r2.RevComp(); err != nil {
+		return nil, err
+	}
+	a, b := r1.Seq, rc.Seq
+	qa, qb := r1.Quality.Qual, rc.Quality.Qual
+
+	d, _, ok := self.overlap(a, b)
+	if !ok {
+		self.Unmerged++
+		return nil, nil
+	}
+	self.Merged++
+
+	// The merged sequence spans r1 and rc in r1 coordinates, excluding read-through bases
+	// of a staggered pair.
+	start, end := 0, d+len(b)
+	if d >= 0 && end < len(a) {
+		end = len(a)
+	}
+	l := make([]byte, 0, end-start)
+	q := make([]seq.Qsanger, 0, end-start)
+	for i := start; i < end; i++ {
+		inA, inB := i < len(a), i-d >= 0 && i-d < len(b)
+		switch {
+		case inA && !inB:
+			l, q = append(l, a[i]), append(q, qa[i])
+		case inB && !inA:
+			l, q = append(l, b[i-d]), append(q, qb[i-d])
+		default:
+			x, y, px, py := a[i], b[i-d], qa[i].ProbE(), qb[i-d].ProbE()
+			agree := equal(x, y)
+			switch {
+			case x&^0x20 == 'N':
+				x, px, py = y, py, 1
+			case y&^0x20 == 'N':
+				py = 1
+			case !agree && py < px:
+				x, px, py = y, py, px
+			}
+			var e float64
+			if py >= 1 {
+				e = px
+			} else {
+				e = posterior(px, py, agree)
+			}
+			l, q = append(l, x), append(q, score(e, self.MaxQuality))
+		}
+	}
+
+	m = seq.New(r1.ID, l, seq.NewQuality(r1.ID, q))
+	m.Offset, m.Quality.Offset = r1.Offset, r1.Offset
+	m.Alphabet = r1.Alphabet
+	m.Strand = r1.Strand
+
+	return
+}
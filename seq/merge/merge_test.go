@@ -0,0 +1,103 @@
+package merge
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+const insert = "ACGGTCATGCAATTGCCAGTACCGATGCTA"
+
+func read(id, l string, q seq.Qsanger) *seq.Seq {
+	qs := make([]seq.Qsanger, len(l))
+	for i := range qs {
+		qs[i] = q
+	}
+	return seq.New(id, []byte(l), seq.NewQuality(id, qs))
+}
+
+func revComp(l string) string {
+	s, _ := (&seq.Seq{Seq: []byte(l)}).RevComp()
+	return string(s.Seq)
+}
+
+func (s *S) TestMerge(c *check.C) {
+	m := New()
+	r1, r2 := read("pair", insert[:20], 30), read("pair", revComp(insert[10:]), 30)
+	mg, err := m.Merge(r1, r2)
+	c.Assert(err, check.IsNil)
+	c.Assert(mg, check.NotNil)
+	c.Check(string(mg.Seq), check.Equals, insert)
+	c.Check(mg.ID, check.Equals, "pair")
+	for i, q := range mg.Quality.Qual {
+		if i >= 10 && i < 20 {
+			c.Check(q, check.Equals, seq.Qsanger(41))
+		} else {
+			c.Check(q, check.Equals, seq.Qsanger(30))
+		}
+	}
+
+	// A low quality mismatch in read 1 is resolved in favour of read 2.
+	r1.Seq[15] = 'A'
+	r1.Quality.Qual[15] = 10
+	mg, err = m.Merge(r1, r2)
+	c.Assert(err, check.IsNil)
+	c.Assert(mg, check.NotNil)
+	c.Check(string(mg.Seq), check.Equals, insert)
+	c.Check(mg.Quality.Qual[15], check.Equals, seq.Qsanger(20))
+
+	// An N takes the base and quality of the other mate.
+	r1.Seq[15], r1.Quality.Qual[15] = 'N', 2
+	mg, _ = m.Merge(r1, r2)
+	c.Check(string(mg.Seq), check.Equals, insert)
+	c.Check(mg.Quality.Qual[15], check.Equals, seq.Qsanger(30))
+
+	// Two mismatches exceed the mismatch density.
+	r1.Seq[12], r1.Seq[15] = 'A', 'A'
+	mg, err = m.Merge(r1, r2)
+	c.Check(err, check.IsNil)
+	c.Check(mg, check.IsNil)
+	c.Check(m.Merged, check.Equals, 3)
+	c.Check(m.Unmerged, check.Equals, 1)
+
+	_, err = m.Merge(&seq.Seq{Seq: []byte(insert)}, r2)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestMergeStaggered(c *check.C) {
+	m := New()
+	r1 := read("short", insert[:15]+"AGATCGGAAG", 30)
+	r2 := read("short", revComp(insert[:15])+"TTTTTTTTTT", 30)
+	mg, err := m.Merge(r1, r2)
+	c.Check(err, check.IsNil)
+	c.Check(mg, check.IsNil)
+
+	m.Stagger = true
+	mg, err = m.Merge(r1, r2)
+	c.Assert(err, check.IsNil)
+	c.Assert(mg, check.NotNil)
+	c.Check(string(mg.Seq), check.Equals, insert[:15])
+	c.Check(mg.Quality.Len(), check.Equals, 15)
+}
@@ -0,0 +1,311 @@
+// Package to find open reading frames in nucleotide sequences
+package orf
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// Start codon policies.
+const (
+	AnyStart StartPolicy = iota // ORFs begin at any start codon of the genetic code.
+	ATGStart                    // ORFs begin only at ATG.
+	NoStart                     // ORFs run from stop codon to stop codon.
+)
+
+type StartPolicy int8
+
+// Params holds the parameters for an ORF search.
+type Params struct {
+	Code        *seq.GeneticCode // Genetic code used to identify start and stop codons. Standard if nil.
+	MinLength   int              // Minimum ORF length in bases, including the stop codon.
+	Start       StartPolicy
+	Strand      int8 // Strand to search relative to the sequence: 1, -1 or 0 for both.
+	Nested      bool // Also report ORFs beginning at downstream in-frame start codons.
+	Overlapping bool // Report ORFs that overlap longer ORFs in other frames.
+	Partial     bool // Report ORFs of linear sequences that are truncated by the ends of the sequence.
+}
+
+// DefaultParams is used when a nil *Params is passed to Find.
+var DefaultParams = Params{
+	MinLength: 75,
+	Start:     AnyStart,
+}
+
+// Feature type given to reported ORFs.
+var FeatureType = "ORF"
+
+// Find the ORFs in s, returning them as features in order of start position. Feature
+// coordinates are those of s; ORFs of circular sequences spanning the origin have a Start
+// greater than their End. Frame is the phase of the ORF, which is always 0 since ORFs begin
+// with a complete codon; the zero-based reading frame on the ORF's strand is given in
+// Attributes as "frame=n". Meta holds the ORF's translation as a protein *seq.Seq. ORFs are
+// reported on the strand relative to s, taking an unknown strand to be the forward strand, so
+// features on the forward strand of s have its Strand, or 1 if its Strand is 0, and features on
+// the reverse strand have the opposite Strand.
+func Find(s *seq.Seq, params *Params) (orfs feat.FeatureSet, err error) {
+	if params == nil {
+		p := DefaultParams
+		params = &p
+	}
+	if m := s.Moltype(); m != bio.DNA && m != bio.RNA {
+		return nil, bio.NewError("Cannot find ORFs in non-nucleic acid sequence.", 0, s)
+	}
+	if s.Len() == 0 {
+		return nil, nil
+	}
+	code := params.Code
+	if code == nil {
+		code = seq.Standard
+	}
+
+	f := &finder{s: s, params: params, code: code}
+	if params.Strand >= 0 {
+		f.search(s.Seq, 1)
+	}
+	if params.Strand <= 0 {
+		var rc *seq.Seq
+		if rc, err = (&seq.Seq{Seq: s.Seq, Alphabet: s.Alphabet}).RevComp(); err != nil {
+			return nil, err
+		}
+		f.search(rc.Seq, -1)
+	}
+
+	if !params.Overlapping {
+		f.removeOverlaps()
+	}
+
+	for _, o := range f.orfs {
+		var feature *feat.Feature
+		if feature, err = f.feature(o); err != nil {
+			return nil, err
+		}
+		orfs = append(orfs, feature)
+	}
+	sort.Stable(byPosition(orfs))
+
+	return
+}
+
+// An orf is described by its start and length on the searched strand.
+type orf struct {
+	start, length int
+	strand        int8
+	codons        []byte
+}
+
+type byPosition feat.FeatureSet
+
+func (self byPosition) Len() int { return len(self) }
+func (self byPosition) Less(i, j int) bool {
+	if self[i].Start == self[j].Start {
+		return self[i].End < self[j].End
+	}
+	return self[i].Start < self[j].Start
+}
+func (self byPosition) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+type finder struct {
+	s      *seq.Seq
+	params *Params
+	code   *seq.GeneticCode
+	orfs   []orf
+	n      int
+}
+
+// Search one strand of the sequence, recording ORFs with coordinates on that strand.
+//
+// For circular sequences the strand is searched as three concatenated copies and only ORFs
+// beginning in the middle copy and no longer than the sequence are retained, so stops
+// preceding the origin and ORFs spanning it are found.
+func (self *finder) search(strand []byte, dir int8) {
+	n := len(strand)
+	self.n = n
+	t, lo, hi := strand, 0, n
+	if self.s.Circular {
+		t = make([]byte, 0, 3*n)
+		for i := 0; i < 3; i++ {
+			t = append(t, strand...)
+		}
+		lo, hi = n, 2*n
+	}
+
+	for frame := 0; frame < 3; frame++ {
+		var (
+			open      []int
+			sinceStop = -1 // Position following the last stop codon, -1 if none has been seen.
+		)
+		if !self.s.Circular && self.params.Partial {
+			sinceStop = frame
+		}
+		for i := frame; i+3 <= len(t); i += 3 {
+			codon := t[i : i+3]
+			if self.code.IsStop(codon) {
+				if self.params.Start == NoStart && sinceStop >= 0 {
+					open = append(open, sinceStop)
+				}
+				self.report(t, open, i+3, lo, hi, n, dir)
+				open = open[:0]
+				sinceStop = i + 3
+				continue
+			}
+			if self.isStart(codon) && (len(open) == 0 || self.params.Nested) {
+				open = append(open, i)
+			}
+		}
+		if !self.s.Circular && self.params.Partial {
+			end := frame + (len(t)-frame)/3*3
+			if self.params.Start == NoStart && sinceStop >= 0 && sinceStop < end {
+				open = append(open, sinceStop)
+			}
+			self.report(t, open, end, lo, hi, n, dir)
+		}
+	}
+}
+
+// Return the strand of the searched sequence, taking an unknown strand to be the forward strand.
+func (self *finder) strand() int8 {
+	if self.s.Strand < 0 {
+		return -1
+	}
+	return 1
+}
+
+func (self *finder) isStart(codon []byte) bool {
+	switch self.params.Start {
+	case AnyStart:
+		return self.code.IsStart(codon)
+	case ATGStart:
+		return (codon[0]|0x20) == 'a' && (codon[1]|0x20 == 't' || codon[1]|0x20 == 'u') && (codon[2]|0x20) == 'g'
+	}
+	return false
+}
+
+// Record ORFs beginning at each of starts and ending at end.
+func (self *finder) report(t []byte, starts []int, end, lo, hi, n int, dir int8) {
+	for _, start := range starts {
+		length := end - start
+		if start < lo || start >= hi || length < self.params.MinLength || length <= 0 || length > n {
+			continue
+		}
+		self.orfs = append(self.orfs, orf{
+			start:  (start - lo) % n,
+			length: length,
+			strand: dir,
+			codons: append([]byte(nil), t[start:end]...),
+		})
+	}
+}
+
+// Return the ORF's extent on the forward strand as a start and length.
+func (self *finder) extent(o orf) (start, length int) {
+	if o.strand > 0 {
+		return o.start, o.length
+	}
+	start = (self.n - o.start - o.length) % self.n
+	if start < 0 {
+		start += self.n
+	}
+	return start, o.length
+}
+
+// Remove ORFs that overlap a longer ORF in another frame.
+func (self *finder) removeOverlaps() {
+	sort.Stable(byLength(self.orfs))
+	var kept []orf
+	for _, o := range self.orfs {
+		s, l := self.extent(o)
+		overlap := false
+		for _, k := range kept {
+			if k.strand == o.strand && (k.start+k.length)%self.n == (o.start+o.length)%self.n {
+				continue // Nested ORFs share a stop codon.
+			}
+			ks, kl := self.extent(k)
+			if self.overlaps(s, l, ks, kl) {
+				overlap = true
+				break
+			}
+		}
+		if !overlap {
+			kept = append(kept, o)
+		}
+	}
+	self.orfs = kept
+}
+
+func (self *finder) overlaps(s1, l1, s2, l2 int) bool {
+	if !self.s.Circular {
+		return s1 < s2+l2 && s2 < s1+l1
+	}
+	for _, shift := range [...]int{-self.n, 0, self.n} {
+		if s1 < s2+shift+l2 && s2+shift < s1+l1 {
+			return true
+		}
+	}
+	return false
+}
+
+type byLength []orf
+
+func (self byLength) Len() int           { return len(self) }
+func (self byLength) Less(i, j int) bool { return self[i].length > self[j].length }
+func (self byLength) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+
+// Return a feature describing the ORF.
+func (self *finder) feature(o orf) (f *feat.Feature, err error) {
+	start, length := self.extent(o)
+	end := start + length
+	if end > self.n {
+		end -= self.n
+	}
+
+	flags := seq.InitStart
+	if self.params.Start == NoStart {
+		flags = 0
+	}
+	var p *seq.Seq
+	if p, err = (&seq.Seq{ID: self.s.ID, Seq: o.codons, Strand: 1, Alphabet: self.s.Alphabet}).Translate(self.code, 0, flags); err != nil {
+		return
+	}
+
+	f = &feat.Feature{
+		ID:         self.s.ID + ":" + strconv.Itoa(self.s.Offset+start) + ".." + strconv.Itoa(self.s.Offset+end),
+		Location:   self.s.ID,
+		Start:      self.s.Offset + start,
+		End:        self.s.Offset + end,
+		Feature:    FeatureType,
+		Score:      math.NaN(),
+		Frame:      0,
+		Attributes: "frame=" + strconv.Itoa(o.start%3),
+		Strand:     o.strand * self.strand(),
+		Moltype:    self.s.Moltype(),
+		Meta:       p,
+	}
+	p.Strand = f.Strand
+	if o.strand > 0 {
+		p.Offset = f.Start
+	} else {
+		p.Offset = f.End
+	}
+
+	return
+}
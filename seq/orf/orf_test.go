@@ -0,0 +1,115 @@
+package orf
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"fmt"
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/io/featio/gff"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"testing"
+)
+
+// Checkers
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+// Describe ORFs by their extent, strand, phase, reading frame and translation.
+func summary(orfs feat.FeatureSet) (s []string) {
+	for _, f := range orfs {
+		s = append(s, fmt.Sprintf("%d..%d %d %d %s %s", f.Start, f.End, f.Strand, f.Frame, f.Attributes, f.Meta.(*seq.Seq).Seq))
+	}
+	return
+}
+
+var (
+	// A forward ORF at 2..17 and a reverse ORF at 19..28.
+	linear   = []byte("CCATGAAATTTGGGTAACCCTAGGGCATGG")
+	circular = []byte("AAATAACCCCCCCATGAAA")
+)
+
+func (s *S) TestFind(c *check.C) {
+	for _, t := range []struct {
+		params *Params
+		orfs   []string
+	}{
+		{&Params{MinLength: 9}, []string{"2..17 1 0 frame=2 MKFG*", "19..28 -1 0 frame=2 MP*"}},
+		{&Params{MinLength: 12}, []string{"2..17 1 0 frame=2 MKFG*"}},
+		{&Params{MinLength: 9, Strand: 1}, []string{"2..17 1 0 frame=2 MKFG*"}},
+		{&Params{MinLength: 9, Strand: -1}, []string{"19..28 -1 0 frame=2 MP*"}},
+		{&Params{MinLength: 6, Overlapping: true, Nested: true, Partial: true}, []string{
+			"2..17 1 0 frame=2 MKFG*",
+			"9..30 1 0 frame=0 MGNPRAW",
+			"19..28 -1 0 frame=2 MP*",
+		}},
+		{&Params{MinLength: 6, Start: NoStart, Partial: true}, []string{
+			"0..30 -1 0 frame=0 PCPRVTQISW",
+		}},
+	} {
+		orfs, err := Find(&seq.Seq{ID: "s", Seq: linear, Strand: 1}, t.params)
+		c.Assert(err, check.IsNil)
+		c.Check(summary(orfs), check.DeepEquals, t.orfs)
+	}
+}
+
+func (s *S) TestFindStrand(c *check.C) {
+	for _, t := range []struct {
+		strand int8
+		orfs   []string
+	}{
+		{0, []string{"2..17 1 0 frame=2 MKFG*", "19..28 -1 0 frame=2 MP*"}},
+		{-1, []string{"2..17 -1 0 frame=2 MKFG*", "19..28 1 0 frame=2 MP*"}},
+	} {
+		orfs, err := Find(&seq.Seq{ID: "s", Seq: linear, Strand: t.strand}, &Params{MinLength: 9})
+		c.Assert(err, check.IsNil)
+		c.Check(summary(orfs), check.DeepEquals, t.orfs, check.Commentf("Strand %d", t.strand))
+	}
+}
+
+func (s *S) TestFindCircular(c *check.C) {
+	sq := &seq.Seq{ID: "c", Seq: circular, Strand: 1, Circular: true}
+	orfs, err := Find(sq, &Params{MinLength: 6})
+	c.Assert(err, check.IsNil)
+	c.Check(summary(orfs), check.DeepEquals, []string{"13..6 1 0 frame=1 MKK*"})
+
+	sq.Circular = false
+	orfs, err = Find(sq, &Params{MinLength: 6})
+	c.Assert(err, check.IsNil)
+	c.Check(orfs, check.HasLen, 0)
+}
+
+func (s *S) TestFindProtein(c *check.C) {
+	_, err := Find(&seq.Seq{ID: "p", Seq: []byte("MKFG"), Alphabet: alphabet.Protein}, nil)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestWriteGFF(c *check.C) {
+	orfs, err := Find(&seq.Seq{ID: "s", Seq: linear, Strand: 1}, &Params{MinLength: 9})
+	c.Assert(err, check.IsNil)
+	w := gff.NewWriter(nil, 2, 60, false)
+	var lines []string
+	for _, f := range orfs {
+		lines = append(lines, w.Stringify(f))
+	}
+	c.Assert(lines, check.HasLen, 2)
+	c.Check(lines[0], check.Matches, "s\t.*\tORF\t3\t17\t\\.\t\\+\t0\tframe=2.*")
+	c.Check(lines[1], check.Matches, "s\t.*\tORF\t20\t28\t\\.\t-\t0\tframe=2.*")
+}
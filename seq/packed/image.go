@@ -0,0 +1,155 @@
+package packed
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"io"
+)
+
+// An image is laid out as the magic bytes, a header of little endian uint64 values giving the
+// alphabet, flags, offset, length, and the lengths of the ID, ambiguous runs and masked runs,
+// followed by the ID, the runs as start and end pairs, and the packed bases.
+var magic = []byte("BGpk\x00\x01\x00\x00")
+
+const (
+	imageDNA = iota
+	imageRNA
+)
+
+const circularFlag = 1
+
+type header struct {
+	Alphabet, Flags   uint64
+	Offset, Strand    int64
+	Length            uint64
+	IDLen, NLen, MLen uint64
+}
+
+// Return whether b holds a packed sequence image.
+func IsImage(b []byte) bool {
+	return bytes.HasPrefix(b, magic)
+}
+
+// Write a binary image of the Seq to w. The image can be read back by ReadImage.
+func (self *Seq) WriteTo(w io.Writer) (n int64, err error) {
+	h := header{
+		Offset: int64(self.Offset),
+		Strand: int64(self.Strand),
+		Length: uint64(self.length),
+		IDLen:  uint64(len(self.ID)),
+		NLen:   uint64(len(self.ns)),
+		MLen:   uint64(len(self.mask)),
+	}
+	if self.alphabet == alphabet.Alphabet(alphabet.RNA) {
+		h.Alphabet = imageRNA
+	}
+	if self.Circular {
+		h.Flags |= circularFlag
+	}
+
+	b := &bytes.Buffer{}
+	b.Write(magic)
+	binary.Write(b, binary.LittleEndian, h)
+	b.WriteString(self.ID)
+	for _, r := range append(append(runs(nil), self.ns...), self.mask...) {
+		binary.Write(b, binary.LittleEndian, [2]uint64{uint64(r.start), uint64(r.end)})
+	}
+	if n, err = b.WriteTo(w); err != nil {
+		return
+	}
+	c, err := w.Write(self.data)
+
+	return n + int64(c), err
+}
+
+// Return the Seq held in the image b. The bases of the returned Seq share the storage of b, so
+// an image in a memory-mapped file is not copied; b must not be modified while the Seq is in use.
+func ReadImage(b []byte) (s *Seq, err error) {
+	if !IsImage(b) {
+		return nil, bio.NewError("Not a packed sequence image.", 0)
+	}
+	r := bytes.NewReader(b[len(magic):])
+	var h header
+	if err = binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, bio.NewError("Truncated packed sequence image.", 0, err)
+	}
+
+	s = &Seq{
+		Offset:   int(h.Offset),
+		Strand:   int8(h.Strand),
+		Circular: h.Flags&circularFlag != 0,
+		alphabet: alphabet.DNA,
+		length:   int(h.Length),
+	}
+	switch h.Alphabet {
+	case imageDNA:
+	case imageRNA:
+		s.alphabet = alphabet.RNA
+	default:
+		return nil, bio.NewError("Unknown packed sequence image alphabet.", 0, h.Alphabet)
+	}
+
+	// Lengths are checked against the remaining bytes before allocating so that a corrupt
+	// header cannot cause an arbitrarily large allocation.
+	rest := uint64(r.Len())
+	if h.IDLen > rest {
+		return nil, bio.NewError("Truncated packed sequence image.", 0)
+	}
+	rest -= h.IDLen
+	if h.NLen > rest/16 || h.MLen > rest/16-h.NLen {
+		return nil, bio.NewError("Truncated packed sequence image.", 0)
+	}
+	rest -= 16 * (h.NLen + h.MLen)
+	if h.Length > 4*rest {
+		return nil, bio.NewError("Truncated packed sequence image.", 0)
+	}
+
+	id := make([]byte, h.IDLen)
+	if _, err = io.ReadFull(r, id); err != nil {
+		return nil, bio.NewError("Truncated packed sequence image.", 0, err)
+	}
+	s.ID = string(id)
+	rs := make([][2]uint64, h.NLen+h.MLen)
+	if err = binary.Read(r, binary.LittleEndian, rs); err != nil {
+		return nil, bio.NewError("Truncated packed sequence image.", 0, err)
+	}
+	for i, p := range rs {
+		if p[0] >= p[1] || p[1] > h.Length {
+			return nil, bio.NewError("Packed sequence image run out of range.", 0, p[0], p[1])
+		}
+		if uint64(i) < h.NLen {
+			s.ns = append(s.ns, run{int(p[0]), int(p[1])})
+		} else {
+			s.mask = append(s.mask, run{int(p[0]), int(p[1])})
+		}
+	}
+	if !s.ns.sorted() || !s.mask.sorted() {
+		return nil, bio.NewError("Packed sequence image runs not sorted.", 0)
+	}
+
+	start := len(b) - r.Len()
+	end := start + (s.length+3)/4
+	if end > len(b) {
+		return nil, bio.NewError("Truncated packed sequence image.", 0)
+	}
+	s.data = b[start:end:end]
+
+	return
+}
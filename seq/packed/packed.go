@@ -0,0 +1,376 @@
+// Package for two-bit packed nucleotide sequences
+package packed
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/interval"
+	"github.com/kortschak/BioGo/seq"
+)
+
+const (
+	Prepend = seq.Prepend
+	Append  = seq.Append
+)
+
+var emptyString = ""
+
+// A Seq is a nucleotide sequence packed at two bits per base. Bases are coded by their index in a
+// four letter nucleotide alphabet. Positions holding letters outside the alphabet are recorded as
+// sparse runs and read back as N, and runs of lower case letters are recorded as a soft mask.
+// Ambiguity codes other than N and quality scores are not retained.
+type Seq struct {
+	ID       string
+	Offset   int
+	Strand   int8
+	Circular bool
+	Meta     interface{} // No operation on Seq objects implicitly copies or changes the contents of Meta.
+
+	alphabet alphabet.Alphabet
+	data     []byte
+	length   int
+	ns       runs // Runs of positions with letters outside the alphabet.
+	mask     runs // Runs of lower case positions.
+}
+
+// Return a new packed Seq holding the letters of s. The alphabet of the packed Seq is the default
+// DNA or RNA alphabet according to the Moltype of s.
+func Pack(s *seq.Seq) (p *Seq, err error) {
+	a := alphabet.Alphabet(alphabet.DNA)
+	switch s.Moltype() {
+	case bio.DNA:
+	case bio.RNA:
+		a = alphabet.RNA
+	default:
+		return nil, bio.NewError("Cannot pack non-nucleic acid sequence.", 0, s)
+	}
+
+	p = &Seq{
+		ID:       s.ID,
+		Offset:   s.Offset,
+		Strand:   s.Strand,
+		Circular: s.Circular,
+		alphabet: a,
+	}
+	p.data, p.ns, p.mask = pack(a, s.Seq)
+	p.length = len(s.Seq)
+
+	return
+}
+
+// Pack letters l, returning the packed data and the runs of invalid and lower case letters.
+func pack(a alphabet.Alphabet, l []byte) (data []byte, ns, mask runs) {
+	b := builder{data: make([]byte, 0, (len(l)+3)/4)}
+	index := a.LetterIndex()
+	for i, c := range l {
+		code := index[c]
+		if code < 0 {
+			ns = ns.add(i)
+			code = 0
+		}
+		if 'a' <= c && c <= 'z' {
+			mask = mask.add(i)
+		}
+		b.push(byte(code))
+	}
+
+	return b.data, ns, mask
+}
+
+// Return a seq.Seq holding the unpacked letters of the Seq.
+func (self *Seq) Unpack() *seq.Seq {
+	return &seq.Seq{
+		ID:       self.ID,
+		Seq:      self.letters(0, self.length),
+		Offset:   self.Offset,
+		Strand:   self.Strand,
+		Circular: self.Circular,
+		Alphabet: self.alphabet,
+	}
+}
+
+// Return the alphabet used to code the Seq.
+func (self *Seq) Alphabet() alphabet.Alphabet { return self.alphabet }
+
+func (self *Seq) Len() int { return self.length }
+
+func (self *Seq) Start() int { return self.Offset }
+
+func (self *Seq) End() int { return self.Offset + self.length }
+
+// Return the two bit code of the base at index i.
+func (self *Seq) code(i int) byte {
+	return self.data[i>>2] >> (6 - 2*uint(i&3)) & 3
+}
+
+// Return the letter at position pos.
+func (self *Seq) At(pos int) (l byte, err error) {
+	if pos < self.Offset || pos >= self.End() {
+		return 0, bio.NewError("Position out of range.", 0, pos)
+	}
+	return self.letters(pos-self.Offset, pos-self.Offset+1)[0], nil
+}
+
+// Return the unpacked letters from index start to end.
+func (self *Seq) letters(start, end int) (l []byte) {
+	l = make([]byte, end-start)
+	for i := range l {
+		l[i] = self.alphabet.Letter(int(self.code(start + i)))
+	}
+	for _, r := range self.ns.sub(start, end) {
+		for i := r.start; i < r.end; i++ {
+			l[i] = 'n'
+		}
+	}
+	upper := make([]bool, len(l))
+	for i := range upper {
+		upper[i] = true
+	}
+	for _, r := range self.mask.sub(start, end) {
+		for i := r.start; i < r.end; i++ {
+			upper[i] = false
+		}
+	}
+	for i, c := range l {
+		if upper[i] {
+			l[i] = c - 'a' + 'A'
+		}
+	}
+
+	return
+}
+
+// Return runs of positions, in sequence coordinates, of letters outside the alphabet.
+func (self *Seq) Ambiguous() feat.FeatureSet { return self.ns.features(self, "ambiguous") }
+
+// Return runs of positions, in sequence coordinates, of lower case letters.
+func (self *Seq) Masked() feat.FeatureSet { return self.mask.features(self, "masked") }
+
+// Return a new Seq holding bases start to end-start of the receiver.
+func (self *Seq) slice(start, end int) *Seq {
+	b := builder{data: make([]byte, 0, (end-start+3)/4)}
+	b.copy(self, start, end)
+	return &Seq{
+		ID:       self.ID,
+		Strand:   self.Strand,
+		alphabet: self.alphabet,
+		data:     b.data,
+		length:   b.n,
+		ns:       self.ns.sub(start, end),
+		mask:     self.mask.sub(start, end),
+	}
+}
+
+// Return the part of the Seq from start to end in sequence coordinates. If the Seq is circular and
+// start is greater than end, the returned Seq spans the origin.
+func (self *Seq) Trunc(start, end int) (s *Seq, err error) {
+	if start < self.Offset || end < self.Offset ||
+		start > self.End() || end > self.End() {
+		return nil, bio.NewError("Start or end position out of range.", 0, self)
+	}
+
+	if start <= end {
+		s = self.slice(start-self.Offset, end-self.Offset)
+	} else if self.Circular {
+		s = self.slice(start-self.Offset, self.length)
+		s.append(self.slice(0, end-self.Offset))
+	} else {
+		return nil, bio.NewError("Start position greater than end position for non-circular molecule.", 0, self)
+	}
+	s.Offset = start
+
+	return
+}
+
+// Return the reverse complement of the Seq. As for seq.Seq, the Offset of the returned Seq is
+// the End of the receiver and the Strand is reversed.
+func (self *Seq) RevComp() (s *Seq, err error) {
+	b := builder{data: make([]byte, 0, len(self.data))}
+	for i := self.length - 1; i >= 0; i-- {
+		b.push(3 - self.code(i))
+	}
+
+	s = &Seq{
+		ID:       self.ID,
+		Offset:   self.Offset + self.length,
+		Strand:   -self.Strand,
+		Circular: self.Circular,
+		alphabet: self.alphabet,
+		data:     b.data,
+		length:   b.n,
+		ns:       self.ns.reverse(self.length),
+		mask:     self.mask.reverse(self.length),
+	}
+
+	return
+}
+
+// Join s to the Seq, prepending or appending as specified by where. The semantics follow those
+// of seq.Seq's Join.
+func (self *Seq) Join(s *Seq, where int) (j *Seq, err error) {
+	if self.Circular {
+		return nil, bio.NewError("Cannot join circular molecule.", 0, self)
+	}
+	if s.alphabet != self.alphabet {
+		return nil, bio.NewError("Cannot join sequences with different alphabets.", 0, self, s)
+	}
+
+	switch where {
+	case Prepend:
+		j = s.slice(0, s.length)
+		j.ID = s.ID + "+" + self.ID
+		j.append(self)
+		j.Offset = -s.length
+	case Append:
+		j = self.slice(0, self.length)
+		j.ID = self.ID + "+" + s.ID
+		j.append(s)
+	default:
+		return nil, bio.NewError("Invalid join position.", 0, where)
+	}
+	j.Strand = self.Strand
+
+	return
+}
+
+// Append the bases of s to the receiver.
+func (self *Seq) append(s *Seq) {
+	b := builder{data: self.data, n: self.length}
+	b.copy(s, 0, s.length)
+	self.ns = self.ns.join(s.ns, self.length)
+	self.mask = self.mask.join(s.mask, self.length)
+	self.data, self.length = b.data, b.n
+}
+
+// Return a Seq made from the parts of the receiver covered by the features in f, which are in
+// sequence coordinates. Overlapping features are merged.
+func (self *Seq) Stitch(f feat.FeatureSet) (s *Seq, err error) {
+	t := interval.NewTree()
+	var i *interval.Interval
+
+	for _, feature := range f {
+		if i, err = interval.New(emptyString, feature.Start, feature.End, 0, nil); err != nil {
+			return nil, err
+		} else {
+			t.Insert(i)
+		}
+	}
+
+	span, err := interval.New(emptyString, self.Start(), self.End(), 0, nil)
+	if err != nil {
+		panic("Seq.End() < Seq.Start()")
+	}
+	fs, _ := t.Flatten(span, 0, 0)
+
+	s = &Seq{
+		ID:       self.ID,
+		Strand:   self.Strand,
+		alphabet: self.alphabet,
+	}
+	for _, seg := range fs {
+		start, end := seg.Start()-self.Offset, seg.End()-self.Offset
+		if start < 0 {
+			start = 0
+		}
+		if end > self.length {
+			end = self.length
+		}
+		if start < end {
+			s.append(self.slice(start, end))
+		}
+	}
+
+	return
+}
+
+// Call f for each kmer of length k, packed two bits per base in alphabet index order, starting
+// at indices from start to end-k that do not include letters outside the alphabet. Indices are
+// zero-based from the first base of the Seq and k must not be greater than 32.
+func (self *Seq) Kmers(k, start, end int, f func(index int, kmer uint64)) error {
+	return self.kmers(k, start, end, self.ns, f)
+}
+
+// Call f for each kmer as Kmers, but excluding kmers that include soft-masked positions.
+func (self *Seq) UnmaskedKmers(k, start, end int, f func(index int, kmer uint64)) error {
+	return self.kmers(k, start, end, self.ns.union(self.mask), f)
+}
+
+// Call f for each kmer of length k from start to end that does not overlap the skip runs.
+func (self *Seq) kmers(k, start, end int, skip runs, f func(index int, kmer uint64)) error {
+	if k < 1 || k > 32 {
+		return bio.NewError("k out of range", 0, k)
+	}
+	if start < 0 || end > self.length || start > end {
+		return bio.NewError("Start or end position out of range.", 0, start, end)
+	}
+
+	var (
+		mask = ^uint64(0) >> (64 - 2*uint(k))
+		kmer uint64
+		next = start // Index of the first base of the next potentially valid kmer.
+		ns   = skip.sub(start, end)
+		r    = 0
+	)
+	for i := start; i < end; i++ {
+		if r < len(ns) && i-start == ns[r].start {
+			i = ns[r].end + start - 1
+			next = i + 1
+			r++
+			continue
+		}
+		kmer = (kmer<<2 | uint64(self.code(i))) & mask
+		if i-next+1 >= k {
+			f(i-k+1, kmer)
+		}
+	}
+
+	return nil
+}
+
+func (self *Seq) String() string {
+	return string(self.letters(0, self.length))
+}
+
+// A builder packs two bit codes into a byte slice.
+type builder struct {
+	data []byte
+	n    int
+}
+
+func (self *builder) push(c byte) {
+	if self.n&3 == 0 {
+		self.data = append(self.data, 0)
+	}
+	self.data[self.n>>2] |= c << (6 - 2*uint(self.n&3))
+	self.n++
+}
+
+// Copy the codes of s from index start to end.
+func (self *builder) copy(s *Seq, start, end int) {
+	if self.n&3 == 0 && start&3 == 0 {
+		// Aligned bulk copy of whole bytes.
+		whole := (end - start) &^ 3
+		self.data = append(self.data, s.data[start>>2:(start+whole)>>2]...)
+		self.n += whole
+		start += whole
+	}
+	for i := start; i < end; i++ {
+		self.push(s.code(i))
+	}
+}
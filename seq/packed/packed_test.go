@@ -0,0 +1,240 @@
+package packed
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func (s *S) TestPack(c *check.C) {
+	for _, t := range []struct {
+		in, out string
+		a       alphabet.Alphabet
+	}{
+		{"", "", nil},
+		{"ACGT", "ACGT", nil},
+		{"ACGTacgtNNnnACGTRYA", "ACGTacgtNNnnACGTNNA", nil},
+		{"acguACGUnN", "acguACGUnN", alphabet.RNA},
+	} {
+		p, err := Pack(&seq.Seq{ID: "x", Seq: []byte(t.in), Offset: 5, Alphabet: t.a})
+		c.Assert(err, check.IsNil)
+		c.Check(p.Len(), check.Equals, len(t.in))
+		c.Check(p.String(), check.Equals, t.out)
+		u := p.Unpack()
+		c.Check(string(u.Seq), check.Equals, t.out)
+		c.Check(u.Offset, check.Equals, 5)
+		c.Check(u.ID, check.Equals, "x")
+	}
+	_, err := Pack(&seq.Seq{Seq: []byte("MKV"), Alphabet: alphabet.Protein})
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestRuns(c *check.C) {
+	p, err := Pack(&seq.Seq{ID: "x", Seq: []byte("ACnNACgtaC"), Offset: 10})
+	c.Assert(err, check.IsNil)
+	var r [][2]int
+	for _, f := range p.Ambiguous() {
+		r = append(r, [2]int{f.Start, f.End})
+	}
+	c.Check(r, check.DeepEquals, [][2]int{{12, 14}})
+	r = r[:0]
+	for _, f := range p.Masked() {
+		r = append(r, [2]int{f.Start, f.End})
+	}
+	c.Check(r, check.DeepEquals, [][2]int{{12, 13}, {16, 19}})
+	l, err := p.At(13)
+	c.Check(err, check.IsNil)
+	c.Check(l, check.Equals, byte('N'))
+	_, err = p.At(20)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestTrunc(c *check.C) {
+	in := &seq.Seq{ID: "x", Seq: []byte("ACGTacgtNNACGTACGGTA"), Offset: 2}
+	p, err := Pack(in)
+	c.Assert(err, check.IsNil)
+	for start := in.Start(); start <= in.End(); start++ {
+		for end := start; end <= in.End(); end++ {
+			t, err := p.Trunc(start, end)
+			c.Assert(err, check.IsNil)
+			u, err := in.Trunc(start, end)
+			c.Assert(err, check.IsNil)
+			c.Check(t.String(), check.Equals, string(u.Seq))
+			c.Check(t.Offset, check.Equals, u.Offset)
+		}
+	}
+	_, err = p.Trunc(10, 5)
+	c.Check(err, check.NotNil)
+
+	p.Circular = true
+	t, err := p.Trunc(17, 6)
+	c.Assert(err, check.IsNil)
+	c.Check(t.String(), check.Equals, "CGGTAACGT")
+	c.Check(t.Offset, check.Equals, 17)
+}
+
+func (s *S) TestRevComp(c *check.C) {
+	in := &seq.Seq{ID: "x", Seq: []byte("ACGTTacgNNAGGc"), Offset: 3, Strand: 1}
+	p, err := Pack(in)
+	c.Assert(err, check.IsNil)
+	r, err := p.RevComp()
+	c.Assert(err, check.IsNil)
+	u, err := in.RevComp()
+	c.Assert(err, check.IsNil)
+	c.Check(r.String(), check.Equals, string(u.Seq))
+	c.Check(r.Offset, check.Equals, u.Offset)
+	c.Check(r.Strand, check.Equals, u.Strand)
+	rr, err := r.RevComp()
+	c.Assert(err, check.IsNil)
+	c.Check(rr.String(), check.Equals, string(in.Seq))
+}
+
+func (s *S) TestJoin(c *check.C) {
+	a := &seq.Seq{ID: "a", Seq: []byte("ACGTnAC")}
+	b := &seq.Seq{ID: "b", Seq: []byte("ttNNGGA")}
+	pa, _ := Pack(a)
+	pb, _ := Pack(b)
+	for _, where := range []int{Prepend, Append} {
+		j, err := pa.Join(pb, where)
+		c.Assert(err, check.IsNil)
+		u, err := a.Join(b, where)
+		c.Assert(err, check.IsNil)
+		c.Check(j.String(), check.Equals, string(u.Seq))
+		c.Check(j.ID, check.Equals, u.ID)
+		c.Check(j.Offset, check.Equals, u.Offset)
+	}
+	c.Check(len(pa.Masked()), check.Equals, 1)
+	pa.Circular = true
+	_, err := pa.Join(pb, Append)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestStitch(c *check.C) {
+	in := &seq.Seq{ID: "x", Seq: []byte("AAAAccccGGGGnnnnTTTT"), Offset: 10}
+	p, err := Pack(in)
+	c.Assert(err, check.IsNil)
+	f := feat.FeatureSet{
+		{Start: 8, End: 12},
+		{Start: 14, End: 18},
+		{Start: 16, End: 20},
+		{Start: 26, End: 28},
+	}
+	t, err := p.Stitch(f)
+	c.Assert(err, check.IsNil)
+	u, err := in.Stitch(f)
+	c.Assert(err, check.IsNil)
+	c.Check(t.String(), check.Equals, string(u.Seq))
+	c.Check(t.String(), check.Equals, "AAccccGGTT")
+}
+
+func (s *S) TestKmers(c *check.C) {
+	p, err := Pack(&seq.Seq{Seq: []byte("ACGTNacgtaN")})
+	c.Assert(err, check.IsNil)
+	type hit struct {
+		i    int
+		kmer uint64
+	}
+	var h []hit
+	err = p.Kmers(3, 0, p.Len(), func(i int, kmer uint64) { h = append(h, hit{i, kmer}) })
+	c.Check(err, check.IsNil)
+	c.Check(h, check.DeepEquals, []hit{
+		{0, 0<<4 | 1<<2 | 2}, {1, 1<<4 | 2<<2 | 3},
+		{5, 0<<4 | 1<<2 | 2}, {6, 1<<4 | 2<<2 | 3}, {7, 2<<4 | 3<<2 | 0},
+	})
+	h = h[:0]
+	err = p.Kmers(3, 2, 9, func(i int, kmer uint64) { h = append(h, hit{i, kmer}) })
+	c.Check(err, check.IsNil)
+	c.Check(h, check.DeepEquals, []hit{{5, 0<<4 | 1<<2 | 2}, {6, 1<<4 | 2<<2 | 3}})
+	c.Check(p.Kmers(33, 0, p.Len(), func(int, uint64) {}), check.NotNil)
+
+	p, err = Pack(&seq.Seq{Seq: []byte("ACGTacGTNACgTTTA")})
+	c.Assert(err, check.IsNil)
+	var idx []int
+	err = p.UnmaskedKmers(2, 0, p.Len(), func(i int, _ uint64) { idx = append(idx, i) })
+	c.Check(err, check.IsNil)
+	c.Check(idx, check.DeepEquals, []int{0, 1, 2, 6, 9, 12, 13, 14})
+}
+
+func (s *S) TestImage(c *check.C) {
+	for _, in := range []*seq.Seq{
+		{ID: "x", Seq: []byte("ACGTacgtNNACGTACGGTAc"), Offset: 7, Strand: -1, Circular: true},
+		{ID: "", Seq: []byte("acguN"), Alphabet: alphabet.RNA},
+		{ID: "empty"},
+	} {
+		p, err := Pack(in)
+		c.Assert(err, check.IsNil)
+		b := &bytes.Buffer{}
+		n, err := p.WriteTo(b)
+		c.Check(err, check.IsNil)
+		c.Check(n, check.Equals, int64(b.Len()))
+		c.Check(IsImage(b.Bytes()), check.Equals, true)
+		r, err := ReadImage(b.Bytes())
+		c.Assert(err, check.IsNil)
+		c.Check(r, check.DeepEquals, p)
+		_, err = ReadImage(b.Bytes()[:b.Len()-1])
+		if p.Len() > 0 {
+			c.Check(err, check.NotNil)
+		}
+	}
+	_, err := ReadImage([]byte("ACGT"))
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestImageCorrupt(c *check.C) {
+	p, err := Pack(&seq.Seq{ID: "x", Seq: []byte("ANNANNACGT")})
+	c.Assert(err, check.IsNil)
+	b := &bytes.Buffer{}
+	_, err = p.WriteTo(b)
+	c.Assert(err, check.IsNil)
+
+	const (
+		length = 8 + 8*4 // Header offsets from the start of the image.
+		idLen  = 8 + 8*5
+		nLen   = 8 + 8*6
+		mLen   = 8 + 8*7
+		ns     = 8 + 8*8 + 1 // The ns runs follow the one byte ID.
+	)
+	for _, t := range []struct {
+		at  int
+		val uint64
+	}{
+		{idLen, 1 << 62},
+		{nLen, 1 << 60},
+		{mLen, ^uint64(0)},
+		{length, 1 << 40},
+		{ns + 8, 100}, // First run ends beyond the sequence.
+		{ns, 3},       // First run is empty.
+		{ns + 16, 2},  // Second run overlaps the first.
+	} {
+		im := append([]byte(nil), b.Bytes()...)
+		binary.LittleEndian.PutUint64(im[t.at:], t.val)
+		_, err = ReadImage(im)
+		c.Check(err, check.NotNil, check.Commentf("offset %d value %d", t.at, t.val))
+	}
+}
@@ -0,0 +1,131 @@
+package packed
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+)
+
+// A run is a zero-based half-open range of indices.
+type run struct {
+	start, end int
+}
+
+// Sorted, non-overlapping and non-adjacent runs of indices.
+type runs []run
+
+// Add index i, which must not be less than any index already held, to the runs.
+func (self runs) add(i int) runs {
+	if n := len(self); n > 0 && self[n-1].end == i {
+		self[n-1].end++
+		return self
+	}
+	return append(self, run{i, i + 1})
+}
+
+// Return whether the runs are in order and do not overlap.
+func (self runs) sorted() bool {
+	for i := 1; i < len(self); i++ {
+		if self[i].start < self[i-1].end {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the parts of the runs between indices start and end, shifted to be relative to start.
+func (self runs) sub(start, end int) (s runs) {
+	for _, r := range self {
+		if r.end <= start {
+			continue
+		}
+		if r.start >= end {
+			break
+		}
+		if r.start < start {
+			r.start = start
+		}
+		if r.end > end {
+			r.end = end
+		}
+		s = append(s, run{r.start - start, r.end - start})
+	}
+	return
+}
+
+// Return the runs of a reversed sequence of length n.
+func (self runs) reverse(n int) (s runs) {
+	if len(self) == 0 {
+		return nil
+	}
+	s = make(runs, len(self))
+	for i, r := range self {
+		s[len(self)-1-i] = run{n - r.end, n - r.start}
+	}
+	return
+}
+
+// Return the union of the runs and r.
+func (self runs) union(r runs) (u runs) {
+	for i, j := 0, 0; i < len(self) || j < len(r); {
+		var n run
+		if j == len(r) || (i < len(self) && self[i].start < r[j].start) {
+			n, i = self[i], i+1
+		} else {
+			n, j = r[j], j+1
+		}
+		if l := len(u); l > 0 && u[l-1].end >= n.start {
+			if n.end > u[l-1].end {
+				u[l-1].end = n.end
+			}
+			continue
+		}
+		u = append(u, n)
+	}
+	return
+}
+
+// Return the runs followed by r shifted by offset, merging adjacent runs at the junction.
+func (self runs) join(r runs, offset int) runs {
+	for _, o := range r {
+		o.start += offset
+		o.end += offset
+		if n := len(self); n > 0 && self[n-1].end == o.start {
+			self[n-1].end = o.end
+			continue
+		}
+		self = append(self, o)
+	}
+	return self
+}
+
+// Return the runs as features of type name in the coordinates of s.
+func (self runs) features(s *Seq, name string) (f feat.FeatureSet) {
+	for _, r := range self {
+		f = append(f, &feat.Feature{
+			Location: s.ID,
+			Start:    s.Offset + r.start,
+			End:      s.Offset + r.end,
+			Feature:  name,
+			Score:    math.NaN(),
+			Strand:   s.Strand,
+			Moltype:  seq.MoltypeOf(s.alphabet),
+		})
+	}
+	return
+}
@@ -0,0 +1,349 @@
+package primer
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"fmt"
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/index/kmerindex"
+	"github.com/kortschak/BioGo/seq"
+	"sort"
+	"strconv"
+)
+
+// PCRParams holds the parameters used for in-silico PCR.
+type PCRParams struct {
+	MaxMismatch            float64             // Largest total mismatch weight allowed for a primer binding site.
+	Weight                 func(i int) float64 // Weight of a mismatch i bases from the 3' end of a primer. DefaultWeight if nil.
+	MinProduct, MaxProduct int                 // Product length range.
+}
+
+// DefaultPCRParams is used when a nil *PCRParams is passed to Amplify or AmplifyIndexed.
+var DefaultPCRParams = PCRParams{
+	MaxMismatch: 2,
+	MinProduct:  1,
+	MaxProduct:  5000,
+}
+
+// DefaultWeight weights mismatches at the 3' terminal base by 4 and within the following four
+// bases by 2, with mismatches elsewhere weighted by 1.
+func DefaultWeight(i int) float64 {
+	switch {
+	case i == 0:
+		return 4
+	case i < 5:
+		return 2
+	}
+	return 1
+}
+
+// Feature type given to PCR products.
+var ProductType = "amplicon"
+
+// A probe is a primer coded as base sets in the orientation it is matched against the top strand.
+type probe struct {
+	codes   []byte
+	reverse bool // The probe is the reverse complement of the primer.
+}
+
+func newProbe(p []byte, reverse bool) (pr probe, err error) {
+	if len(p) == 0 {
+		return pr, bio.NewError("Empty primer.", 0)
+	}
+	pr = probe{codes: make([]byte, len(p)), reverse: reverse}
+	for i, l := range p {
+		m := alphabet.BaseSet(l)
+		if m == 0 {
+			return probe{}, bio.NewError("Invalid letter in primer.", 0, string(p))
+		}
+		if reverse {
+			pr.codes[len(p)-i-1] = alphabet.ComplementSet(m)
+		} else {
+			pr.codes[i] = m
+		}
+	}
+	return
+}
+
+// Return the distance of probe index k from the 3' end of the primer.
+func (self probe) fromThreePrime(k int) int {
+	if self.reverse {
+		return k
+	}
+	return len(self.codes) - k - 1
+}
+
+// Return the total mismatch weight of the probe at index i of the letters l and whether it is
+// within the allowed maximum. Template letters match if they are a base in the probe's set.
+func (self probe) match(l []byte, i int, p *PCRParams) (w float64, ok bool) {
+	if i < 0 || i+len(self.codes) > len(l) {
+		return 0, false
+	}
+	for k, m := range self.codes {
+		if b := alphabet.BaseSet(l[i+k]); b == 0 || b&^m != 0 {
+			if w += p.Weight(self.fromThreePrime(k)); w > p.MaxMismatch {
+				return w, false
+			}
+		}
+	}
+	return w, true
+}
+
+// Return the kmers of length k, spelled with the letters of alphabet a, matched by the 3' end of
+// the probe and the offset of the kmer from the start of the probe. Degenerate bases are expanded.
+func (self probe) seeds(k int, a alphabet.Alphabet) (kmers []string, offset int, err error) {
+	if k > len(self.codes) {
+		return nil, 0, bio.NewError("Primer shorter than index kmer length.", 0, k)
+	}
+	if !self.reverse {
+		offset = len(self.codes) - k
+	}
+	var letters [4]byte
+	for i := 0; i < a.Len(); i++ {
+		l := a.Letter(i)
+		for b := uint(0); b < 4; b++ {
+			if alphabet.BaseSet(l) == 1<<b {
+				letters[b] = l
+			}
+		}
+	}
+	kmers = []string{""}
+	for _, m := range self.codes[offset : offset+k] {
+		var next []string
+		for b := uint(0); b < 4; b++ {
+			if m&(1<<b) == 0 {
+				continue
+			}
+			if letters[b] == 0 {
+				return nil, 0, bio.NewError("Index alphabet cannot represent primer base.", 0, a)
+			}
+			for _, kmer := range kmers {
+				next = append(next, kmer+string(letters[b]))
+			}
+		}
+		kmers = next
+	}
+	return
+}
+
+// A hit is the position and mismatch weight of a primer binding site.
+type hit struct {
+	pos int
+	w   float64
+}
+
+// Return the binding sites of the probe in l, scanning every position.
+func (self probe) scan(l []byte, p *PCRParams) (hits []hit) {
+	for i := 0; i+len(self.codes) <= len(l); i++ {
+		if w, ok := self.match(l, i, p); ok {
+			hits = append(hits, hit{i, w})
+		}
+	}
+	return
+}
+
+// Return the binding sites of the probe in the sequence of index, verifying seed hits.
+func (self probe) lookUp(index *kmerindex.Index, p *PCRParams) (hits []hit, err error) {
+	kmers, offset, err := self.seeds(index.GetK(), index.GetSeq().GetAlphabet())
+	if err != nil {
+		return nil, err
+	}
+	l := index.GetSeq().Seq
+	for _, kmer := range kmers {
+		var pos []int
+		if pos, err = index.GetPositionsString(kmer); err != nil {
+			return nil, err
+		}
+		for _, i := range pos {
+			if w, ok := self.match(l, i-offset, p); ok {
+				hits = append(hits, hit{i - offset, w})
+			}
+		}
+	}
+	sort.Sort(byPos(hits))
+	return
+}
+
+type byPos []hit
+
+func (self byPos) Len() int           { return len(self) }
+func (self byPos) Less(i, j int) bool { return self[i].pos < self[j].pos }
+func (self byPos) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+
+// Return the products formed between the top strand binding sites of one primer, top, and the
+// bottom strand binding sites of the other, bottom, of length n.
+func products(s *seq.Seq, top, bottom []hit, n int, strand int8, p *PCRParams) (f feat.FeatureSet) {
+	for _, t := range top {
+		for _, b := range bottom {
+			if b.pos < t.pos {
+				continue
+			}
+			length := b.pos + n - t.pos
+			if length < p.MinProduct || length > p.MaxProduct {
+				continue
+			}
+			start, end := s.Offset+t.pos, s.Offset+t.pos+length
+			f = append(f, &feat.Feature{
+				ID:         s.ID + ":" + strconv.Itoa(start) + ".." + strconv.Itoa(end),
+				Location:   s.ID,
+				Start:      start,
+				End:        end,
+				Feature:    ProductType,
+				Score:      t.w + b.w,
+				Attributes: fmt.Sprintf("mismatch_weights=%v,%v", t.w, b.w),
+				Strand:     strand * strandOf(s),
+				Frame:      -1,
+				Moltype:    s.Moltype(),
+			})
+		}
+	}
+	return
+}
+
+// Return the strand of s, taking an unknown strand to be the forward strand.
+func strandOf(s *seq.Seq) int8 {
+	if s.Strand < 0 {
+		return -1
+	}
+	return 1
+}
+
+// An amplifier finds the binding sites of a primer pair.
+type amplifier struct {
+	fwd, rev, fwdRC, revRC probe
+	params                 *PCRParams
+}
+
+func newAmplifier(forward, reverse []byte, p *PCRParams) (a *amplifier, err error) {
+	if p == nil {
+		d := DefaultPCRParams
+		p = &d
+	}
+	if p.Weight == nil {
+		d := *p
+		d.Weight = DefaultWeight
+		p = &d
+	}
+	a = &amplifier{params: p}
+	for _, pr := range []struct {
+		p       *probe
+		primer  []byte
+		reverse bool
+	}{
+		{&a.fwd, forward, false},
+		{&a.rev, reverse, false},
+		{&a.fwdRC, forward, true},
+		{&a.revRC, reverse, true},
+	} {
+		if *pr.p, err = newProbe(pr.primer, pr.reverse); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// Return the products given the binding sites of each probe in s.
+func (self *amplifier) products(s *seq.Seq, sites func(probe) ([]hit, error)) (f feat.FeatureSet, err error) {
+	var fwd, rev, fwdRC, revRC []hit
+	for _, h := range []struct {
+		hits *[]hit
+		p    probe
+	}{{&fwd, self.fwd}, {&rev, self.rev}, {&fwdRC, self.fwdRC}, {&revRC, self.revRC}} {
+		if *h.hits, err = sites(h.p); err != nil {
+			return nil, err
+		}
+	}
+	f = append(products(s, fwd, revRC, len(self.rev.codes), 1, self.params),
+		products(s, rev, fwdRC, len(self.fwd.codes), -1, self.params)...)
+	return
+}
+
+type byStart feat.FeatureSet
+
+func (self byStart) Len() int { return len(self) }
+func (self byStart) Less(i, j int) bool {
+	if self[i].Start == self[j].Start {
+		return self[i].End < self[j].End
+	}
+	return self[i].Start < self[j].Start
+}
+func (self byStart) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+// Return the products amplified from each of the templates by the primer pair forward and reverse,
+// given 5'-3' and possibly including IUPAC ambiguity codes. Products are returned as features in
+// the coordinates of their template, ordered by template and position. A product has the Strand
+// of its template, taking an unknown strand as forward, if the forward primer binds the top strand
+// and the opposite Strand otherwise.
+// The Score of a product is the sum of the mismatch weights of its primers. Templates are treated
+// as linear. If p is nil, DefaultPCRParams are used.
+func Amplify(forward, reverse []byte, templates []*seq.Seq, p *PCRParams) (f feat.FeatureSet, err error) {
+	a, err := newAmplifier(forward, reverse, p)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range templates {
+		if m := s.Moltype(); m != bio.DNA && m != bio.RNA {
+			return nil, bio.NewError("Cannot amplify non-nucleic acid sequence.", 0, s)
+		}
+		var pf feat.FeatureSet
+		if pf, err = a.products(s, func(pr probe) ([]hit, error) { return pr.scan(s.Seq, a.params), nil }); err != nil {
+			return nil, err
+		}
+		sort.Sort(byStart(pf))
+		f = append(f, pf...)
+	}
+	return
+}
+
+// Return the products amplified from the sequences of the built indexes by the primer pair, as
+// described for Amplify. Binding sites are seeded by exact matches of the 3'-most k bases of each
+// primer to kmers of the index, so mismatches within those bases are not found.
+func AmplifyIndexed(forward, reverse []byte, indexes []*kmerindex.Index, p *PCRParams) (f feat.FeatureSet, err error) {
+	a, err := newAmplifier(forward, reverse, p)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		s := index.GetSeq()
+		if s == nil {
+			return nil, bio.NewError("Index does not hold a seq.Seq.", 0, index)
+		}
+		var pf feat.FeatureSet
+		if pf, err = a.products(s, func(pr probe) ([]hit, error) { return pr.lookUp(index, a.params) }); err != nil {
+			return nil, err
+		}
+		sort.Sort(byStart(pf))
+		f = append(f, pf...)
+	}
+	return
+}
+
+// Return the sequence of the product f amplified from template s, reverse complemented if the
+// product's Strand is opposite to that of s.
+func Product(s *seq.Seq, f *feat.Feature) (p *seq.Seq, err error) {
+	if p, err = s.Trunc(f.Start, f.End); err != nil {
+		return nil, err
+	}
+	if f.Strand != strandOf(s) {
+		if p, err = p.RevComp(); err != nil {
+			return nil, err
+		}
+	}
+	p.ID = f.ID
+	return
+}
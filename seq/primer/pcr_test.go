@@ -0,0 +1,128 @@
+package primer
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/index/kmerindex"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"math/rand"
+	"strings"
+)
+
+func template(r *rand.Rand, n int, inserts map[int]string) []byte {
+	l := make([]byte, n)
+	for i := range l {
+		l[i] = "ACGT"[r.Intn(4)]
+	}
+	for p, s := range inserts {
+		copy(l[p:], s)
+	}
+	return l
+}
+
+func (s *S) TestAmplify(c *check.C) {
+	const (
+		fwd = "AGCGGATAACAATTTCACACAGGA"
+		rev = "GTAAAACGACGGCCAGT"
+	)
+	r := rand.New(rand.NewSource(1))
+	fwdRC, revRC := string(revComp([]byte(fwd))), string(revComp([]byte(rev)))
+	templates := []*seq.Seq{
+		{ID: "plus", Offset: 1000, Seq: template(r, 1000, map[int]string{100: fwd, 400: revRC})},
+		{ID: "minus", Seq: template(r, 1000, map[int]string{50: rev, 300: fwdRC})},
+		{ID: "none", Seq: template(r, 1000, nil)},
+	}
+
+	type product struct {
+		loc        string
+		start, end int
+		strand     int8
+		score      float64
+	}
+	get := func(f feat.FeatureSet) (p []product) {
+		for _, ft := range f {
+			p = append(p, product{ft.Location, ft.Start, ft.End, ft.Strand, ft.Score})
+		}
+		return
+	}
+	want := []product{{"plus", 1100, 1417, 1, 0}, {"minus", 50, 324, -1, 0}}
+
+	f, err := Amplify([]byte(fwd), []byte(rev), templates, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(get(f), check.DeepEquals, want)
+	for i, ft := range f {
+		p, err := Product(templates[i], ft)
+		c.Assert(err, check.IsNil)
+		c.Check(strings.HasPrefix(p.String(), fwd), check.Equals, true)
+		c.Check(strings.HasSuffix(p.String(), revRC), check.Equals, true)
+	}
+
+	var indexes []*kmerindex.Index
+	for _, t := range templates {
+		i, err := kmerindex.New(8, t)
+		c.Assert(err, check.IsNil)
+		i.Build()
+		indexes = append(indexes, i)
+	}
+	f, err = AmplifyIndexed([]byte(fwd), []byte(rev), indexes, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(get(f), check.DeepEquals, want)
+
+	// A 5' mismatch is tolerated, a 3' terminal mismatch is not.
+	f, err = Amplify([]byte("T"+fwd[1:]), []byte(rev), templates[:1], nil)
+	c.Assert(err, check.IsNil)
+	c.Check(get(f), check.DeepEquals, []product{{"plus", 1100, 1417, 1, 1}})
+	f, err = Amplify([]byte(fwd[:len(fwd)-1]+"T"), []byte(rev), templates[:1], nil)
+	c.Assert(err, check.IsNil)
+	c.Check(len(f), check.Equals, 0)
+
+	// Degenerate primers match and are expanded for index seeding.
+	deg := []byte(rev)
+	deg[len(deg)-2] = 'N'
+	f, err = AmplifyIndexed([]byte(fwd), deg, indexes, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(get(f), check.DeepEquals, want)
+
+	// Seeds are spelled in the alphabet of RNA indexes.
+	var rna []*seq.Seq
+	indexes = indexes[:0]
+	for _, t := range templates {
+		r := &seq.Seq{ID: t.ID, Offset: t.Offset, Seq: []byte(strings.Replace(string(t.Seq), "T", "U", -1)), Alphabet: alphabet.RNA}
+		rna = append(rna, r)
+		i, err := kmerindex.New(8, r)
+		c.Assert(err, check.IsNil)
+		i.Build()
+		indexes = append(indexes, i)
+	}
+	f, err = Amplify([]byte(fwd), []byte(rev), rna, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(get(f), check.DeepEquals, want)
+	f, err = AmplifyIndexed([]byte(fwd), deg, indexes, nil)
+	c.Assert(err, check.IsNil)
+	c.Check(get(f), check.DeepEquals, want)
+
+	p := DefaultPCRParams
+	p.MaxProduct = 300
+	f, err = Amplify([]byte(fwd), []byte(rev), templates, &p)
+	c.Assert(err, check.IsNil)
+	c.Check(get(f), check.DeepEquals, want[1:])
+
+	_, err = Amplify([]byte("ACGTX"), []byte(rev), templates, nil)
+	c.Check(err, check.NotNil)
+}
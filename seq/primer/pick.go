@@ -0,0 +1,204 @@
+package primer
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+	"sort"
+)
+
+// Params holds the constraints used to select primer pairs.
+type Params struct {
+	MinLength, MaxLength   int     // Primer length range.
+	MinTm, OptTm, MaxTm    float64 // Primer Tm range and optimum in °C.
+	MaxTmDifference        float64 // Largest Tm difference between primers of a pair.
+	MinGC, MaxGC           float64 // Primer GC fraction range.
+	GCClamp                int     // Minimum number of G or C among the five 3'-most bases.
+	MinProduct, MaxProduct int     // Product length range.
+	MaxDimer               float64 // Most negative free energy allowed for self and pair dimers in kcal/mol.
+	MaxHairpin             float64 // Most negative free energy allowed for hairpins in kcal/mol.
+	MaxPairs               int     // Maximum number of pairs to return, all if zero.
+	Conditions             *Conditions
+}
+
+// DefaultParams is used when a nil *Params is passed to Pick.
+var DefaultParams = Params{
+	MinLength:       18,
+	MaxLength:       25,
+	MinTm:           57,
+	OptTm:           60,
+	MaxTm:           63,
+	MaxTmDifference: 3,
+	MinGC:           0.4,
+	MaxGC:           0.6,
+	GCClamp:         1,
+	MinProduct:      100,
+	MaxProduct:      1000,
+	MaxDimer:        -9,
+	MaxHairpin:      -3,
+	MaxPairs:        5,
+}
+
+// A Primer describes a candidate primer.
+type Primer struct {
+	Seq        []byte // Primer sequence 5'-3'.
+	Start, End int    // Binding site on the top strand in sequence coordinates.
+	Strand     int8   // 1 for forward primers and -1 for reverse primers.
+	Tm         float64
+	GC         float64
+	SelfDimer  Structure
+	Hairpin    Structure
+}
+
+// A Pair is a primer pair amplifying a product of length Product. Penalty is the sum of the
+// deviations of the primer Tms from the optimum and of the difference between them.
+type Pair struct {
+	Forward, Reverse *Primer
+	Product          int
+	Dimer            Structure
+	Penalty          float64
+}
+
+// Return the number of G and C bases among the n 3'-most bases of p.
+func clamp(p []byte, n int) (gc int) {
+	if n > len(p) {
+		n = len(p)
+	}
+	for _, l := range p[len(p)-n:] {
+		if b := alphabet.BaseIndex(l); b == 1 || b == 2 {
+			gc++
+		}
+	}
+	return
+}
+
+// Return the primers binding within the letters l of alphabet a starting at offset that satisfy p.
+// Reverse primers are complemented using the pairing of a.
+func (self *Params) candidates(l []byte, a alphabet.Alphabet, offset int, strand int8) (primers []*Primer, err error) {
+	for i := range l {
+		for n := self.MinLength; n <= self.MaxLength && i+n <= len(l); n++ {
+			sq := l[i : i+n]
+			if strand < 0 {
+				var rc *seq.Seq
+				if rc, err = (&seq.Seq{Seq: sq, Alphabet: a}).RevComp(); err != nil {
+					return nil, err
+				}
+				sq = rc.Seq
+			} else {
+				sq = append([]byte(nil), sq...)
+			}
+			gc := GC(sq)
+			if gc < self.MinGC || gc > self.MaxGC || clamp(sq, 5) < self.GCClamp {
+				continue
+			}
+			tm, err := Tm(sq, self.Conditions)
+			if err != nil || tm < self.MinTm || tm > self.MaxTm {
+				continue
+			}
+			d, _ := SelfDimer(sq, self.Conditions)
+			if d.DeltaG < self.MaxDimer {
+				continue
+			}
+			h, _ := Hairpin(sq, self.Conditions)
+			if h.DeltaG < self.MaxHairpin {
+				continue
+			}
+			primers = append(primers, &Primer{
+				Seq:       sq,
+				Start:     offset + i,
+				End:       offset + i + n,
+				Strand:    strand,
+				Tm:        tm,
+				GC:        gc,
+				SelfDimer: d,
+				Hairpin:   h,
+			})
+		}
+	}
+
+	return
+}
+
+type byPenalty []Pair
+
+func (self byPenalty) Len() int { return len(self) }
+func (self byPenalty) Less(i, j int) bool {
+	if self[i].Penalty == self[j].Penalty {
+		return self[i].Forward.Start < self[j].Forward.Start
+	}
+	return self[i].Penalty < self[j].Penalty
+}
+func (self byPenalty) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+// Return primer pairs satisfying p that bind within the region of s from start to end, given in
+// sequence coordinates, in order of increasing penalty. If p is nil, DefaultParams are used.
+func Pick(s *seq.Seq, start, end int, p *Params) (pairs []Pair, err error) {
+	if p == nil {
+		d := DefaultParams
+		p = &d
+	}
+	if m := s.Moltype(); m != bio.DNA && m != bio.RNA {
+		return nil, bio.NewError("Cannot pick primers in non-nucleic acid sequence.", 0, s)
+	}
+	if start < s.Start() || end > s.End() || start > end {
+		return nil, bio.NewError("Start or end position out of range.", 0, start, end)
+	}
+	if p.MinLength < 2 || p.MinLength > p.MaxLength {
+		return nil, bio.NewError("Invalid primer length range.", 0, p.MinLength, p.MaxLength)
+	}
+
+	l := s.Seq[start-s.Offset : end-s.Offset]
+	var forward, reverse []*Primer
+	if forward, err = p.candidates(l, s.GetAlphabet(), start, 1); err != nil {
+		return nil, err
+	}
+	if reverse, err = p.candidates(l, s.GetAlphabet(), start, -1); err != nil {
+		return nil, err
+	}
+
+	var candidates []Pair
+	for _, f := range forward {
+		for _, r := range reverse {
+			product := r.End - f.Start
+			if r.Start < f.Start || product < p.MinProduct || product > p.MaxProduct ||
+				math.Abs(f.Tm-r.Tm) > p.MaxTmDifference {
+				continue
+			}
+			candidates = append(candidates, Pair{
+				Forward: f,
+				Reverse: r,
+				Product: product,
+				Penalty: math.Abs(f.Tm-p.OptTm) + math.Abs(r.Tm-p.OptTm) + math.Abs(f.Tm-r.Tm),
+			})
+		}
+	}
+	sort.Stable(byPenalty(candidates))
+
+	for _, c := range candidates {
+		if p.MaxPairs > 0 && len(pairs) == p.MaxPairs {
+			break
+		}
+		if c.Dimer, _ = Dimer(c.Forward.Seq, c.Reverse.Seq, p.Conditions); c.Dimer.DeltaG < p.MaxDimer {
+			continue
+		}
+		pairs = append(pairs, c)
+	}
+
+	return
+}
@@ -0,0 +1,135 @@
+package primer
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func revComp(l []byte) []byte {
+	r, err := (&seq.Seq{Seq: l}).RevComp()
+	if err != nil {
+		panic(err)
+	}
+	return r.Seq
+}
+
+func (s *S) TestTm(c *check.C) {
+	for _, t := range []struct {
+		seq   string
+		cond  *Conditions
+		tm, g float64
+	}{
+		{"AGCGGATAACAATTTCACACAGGA", nil, 60.803746, -24.359458},
+		{"gtaaaacgacggccagt", nil, 54.692567, -18.425635},
+		{"CGCGAATTCGCG", nil, 48.600385, -14.009939},
+		{"GTAAAACGACGGCCAGT", &Conditions{Na: 50, Oligo: 250}, 51.671564, math.NaN()},
+	} {
+		tm, err := Tm([]byte(t.seq), t.cond)
+		c.Check(err, check.IsNil)
+		c.Check(math.Abs(tm-t.tm) < 1e-6, check.Equals, true, check.Commentf("%s: %f", t.seq, tm))
+		if !math.IsNaN(t.g) {
+			g, err := DeltaG([]byte(t.seq), t.cond)
+			c.Check(err, check.IsNil)
+			c.Check(math.Abs(g-t.g) < 1e-6, check.Equals, true, check.Commentf("%s: %f", t.seq, g))
+		}
+	}
+	_, err := Tm([]byte("ACGTNACGT"), nil)
+	c.Check(err, check.NotNil)
+	_, err = Tm([]byte("A"), nil)
+	c.Check(err, check.NotNil)
+	c.Check(GC([]byte("GCgcAT")), check.Equals, 4./6)
+}
+
+func (s *S) TestStructure(c *check.C) {
+	h, err := Hairpin([]byte("GGGGAAAACCCC"), nil)
+	c.Assert(err, check.IsNil)
+	c.Check(h.I, check.Equals, 0)
+	c.Check(h.J, check.Equals, 11)
+	c.Check(h.Pairs, check.Equals, 4)
+	c.Check(math.Abs(h.DeltaG - -1.3650) < 1e-3, check.Equals, true, check.Commentf("%f", h.DeltaG))
+
+	h, err = Hairpin([]byte("GGGAAAC"), nil)
+	c.Check(err, check.IsNil)
+	c.Check(h, check.Equals, Structure{})
+
+	d, err := SelfDimer([]byte("AAGAATTCTT"), nil)
+	c.Assert(err, check.IsNil)
+	c.Check(d.Pairs, check.Equals, 10)
+	c.Check(d.I, check.Equals, 0)
+	c.Check(d.J, check.Equals, 9)
+	c.Check(d.DeltaG < 0, check.Equals, true)
+
+	d, err = Dimer([]byte("CCCCAAAAAA"), []byte("TTTTTTGTTT"), nil)
+	c.Assert(err, check.IsNil)
+	c.Check(d.I, check.Equals, 3)
+	c.Check(d.J, check.Equals, 6)
+	c.Check(d.Pairs, check.Equals, 7)
+
+	d, err = SelfDimer([]byte("AAAAAA"), nil)
+	c.Check(err, check.IsNil)
+	c.Check(d, check.Equals, Structure{})
+}
+
+func (s *S) TestPick(c *check.C) {
+	r := rand.New(rand.NewSource(1))
+	l := make([]byte, 800)
+	for i := range l {
+		l[i] = "ACGT"[r.Intn(4)]
+	}
+	sq := &seq.Seq{ID: "template", Seq: l, Offset: 1000}
+
+	p := DefaultParams
+	p.MinProduct, p.MaxProduct = 150, 400
+	p.MaxPairs = 10
+	pairs, err := Pick(sq, 1100, 1700, &p)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(pairs) > 0, check.Equals, true)
+	c.Check(len(pairs) <= p.MaxPairs, check.Equals, true)
+	for i, pr := range pairs {
+		if i > 0 {
+			c.Check(pr.Penalty >= pairs[i-1].Penalty, check.Equals, true)
+		}
+		f, rv := pr.Forward, pr.Reverse
+		c.Check(f.Start >= 1100 && rv.End <= 1700, check.Equals, true)
+		c.Check(pr.Product, check.Equals, rv.End-f.Start)
+		c.Check(pr.Product >= p.MinProduct && pr.Product <= p.MaxProduct, check.Equals, true)
+		c.Check(string(f.Seq), check.Equals, string(l[f.Start-1000:f.End-1000]))
+		c.Check(string(rv.Seq), check.Equals, string(revComp(l[rv.Start-1000:rv.End-1000])))
+		c.Check(math.Abs(f.Tm-rv.Tm) <= p.MaxTmDifference, check.Equals, true)
+		for _, pm := range []*Primer{f, rv} {
+			c.Check(pm.Tm >= p.MinTm && pm.Tm <= p.MaxTm, check.Equals, true)
+			c.Check(pm.GC >= p.MinGC && pm.GC <= p.MaxGC, check.Equals, true)
+			c.Check(clamp(pm.Seq, 5) >= p.GCClamp, check.Equals, true)
+			c.Check(pm.Hairpin.DeltaG >= p.MaxHairpin, check.Equals, true)
+		}
+		c.Check(pr.Dimer.DeltaG >= p.MaxDimer, check.Equals, true)
+	}
+
+	_, err = Pick(sq, 900, 1700, nil)
+	c.Check(err, check.NotNil)
+}
@@ -0,0 +1,129 @@
+package primer
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import "math"
+
+// MinLoop is the smallest hairpin loop considered.
+var MinLoop = 3
+
+// A Structure describes a secondary structure formed by one or two oligonucleotides as an
+// uninterrupted run of base pairs.
+type Structure struct {
+	DeltaG float64 // Estimated free energy in kcal/mol at 37°C, 0 if no structure was found.
+	I      int     // Index of the 5'-most paired base of the first strand.
+	J      int     // Index of the partner of base I, in the second strand for dimers.
+	Pairs  int     // Number of base pairs.
+}
+
+// Hairpin loop free energies in kcal/mol for loops of 3 to 9 bases (SantaLucia and Hicks, 2004).
+var loops = [...]float64{3: 3.5, 4: 3.5, 5: 3.3, 6: 4.0, 7: 4.2, 8: 4.3, 9: 4.5}
+
+// Return the free energy in kcal/mol of a hairpin loop of n bases.
+func loop(n int) float64 {
+	if n < len(loops) {
+		return loops[n]
+	}
+	return loops[len(loops)-1] + 2.44*R*T37*math.Log(float64(n)/float64(len(loops)-1))/1000
+}
+
+// Return the free energy of the stacks of the n base pairs starting with a[i] paired with b[j],
+// the pairs proceeding toward the 3' end of a and 5' end of b. The energy includes the salt
+// correction for the conditions c.
+func stem(a []int, i, n int, c *Conditions) (t thermo) {
+	for k := 1; k < n; k++ {
+		st := stack[a[i+k-1]][a[i+k]]
+		t.h += st.h
+		t.s += st.s
+	}
+	t.s += 0.368 * float64(n-1) * math.Log(c.sodium())
+	return
+}
+
+// Return the most stable structure formed by a pairing with b without gaps or mismatches.
+// If c is nil, DefaultConditions are used.
+func Dimer(a, b []byte, c *Conditions) (s Structure, err error) {
+	if c == nil {
+		c = &DefaultConditions
+	}
+	var ab, bb []int
+	if ab, err = bases(a); err != nil {
+		return
+	}
+	if bb, err = bases(b); err != nil {
+		return
+	}
+
+	pairs := func(i, j int) bool { return 0 <= i && i < len(ab) && 0 <= j && j < len(bb) && ab[i] == 3-bb[j] }
+	for i := range ab {
+		for j := range bb {
+			if !pairs(i, j) || pairs(i-1, j+1) {
+				continue
+			}
+			n := 1
+			for pairs(i+n, j-n) {
+				n++
+			}
+			if n < 2 {
+				continue
+			}
+			t := stem(ab, i, n, c)
+			t.h += initiation(ab[i]).h + initiation(ab[i+n-1]).h
+			t.s += initiation(ab[i]).s + initiation(ab[i+n-1]).s
+			if g := t.g(T37); g < s.DeltaG {
+				s = Structure{DeltaG: g, I: i, J: j, Pairs: n}
+			}
+		}
+	}
+
+	return
+}
+
+// Return the most stable dimer formed by p with itself. If c is nil, DefaultConditions are used.
+func SelfDimer(p []byte, c *Conditions) (Structure, error) { return Dimer(p, p, c) }
+
+// Return the most stable hairpin formed by p with a stem of uninterrupted base pairs and a loop of
+// at least MinLoop bases. If c is nil, DefaultConditions are used.
+func Hairpin(p []byte, c *Conditions) (s Structure, err error) {
+	if c == nil {
+		c = &DefaultConditions
+	}
+	var b []int
+	if b, err = bases(p); err != nil {
+		return
+	}
+
+	for i := range b {
+		for j := len(b) - 1; j-i > MinLoop; j-- {
+			if b[i] != 3-b[j] || i > 0 && j+1 < len(b) && b[i-1] == 3-b[j+1] {
+				continue
+			}
+			for n := 1; j-i-2*n+1 >= MinLoop && b[i+n-1] == 3-b[j-n+1]; n++ {
+				if n < 2 {
+					continue
+				}
+				// Longer stems with the same start are considered in turn so that the most
+				// stable trade-off between stem and loop is found.
+				g := stem(b, i, n, c).g(T37) + loop(j-i-2*n+1)
+				if g < s.DeltaG {
+					s = Structure{DeltaG: g, I: i, J: j, Pairs: n}
+				}
+			}
+		}
+	}
+
+	return
+}
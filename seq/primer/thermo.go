@@ -0,0 +1,172 @@
+// Package for primer thermodynamics, primer pair design and in-silico PCR
+package primer
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"math"
+)
+
+const (
+	R      = 1.9872 // Gas constant in cal/K/mol.
+	Kelvin = 273.15 // 0°C in Kelvin.
+	T37    = 310.15 // 37°C in Kelvin.
+)
+
+// Conditions holds the reaction conditions used for thermodynamic calculations.
+type Conditions struct {
+	Na    float64 // Monovalent cation concentration in mM.
+	Mg    float64 // Mg2+ concentration in mM.
+	DNTP  float64 // Total dNTP concentration in mM.
+	Oligo float64 // Oligonucleotide concentration in nM.
+}
+
+// DefaultConditions is used when a nil *Conditions is passed to a function.
+var DefaultConditions = Conditions{
+	Na:    50,
+	Mg:    1.5,
+	DNTP:  0.6,
+	Oligo: 50,
+}
+
+// Return the monovalent cation concentration in M equivalent to the conditions. Free Mg2+,
+// that is not chelated by dNTPs, is converted using the relation of von Ahsen et al. (2001),
+// [Na+]eq = [Na+] + 120*sqrt([Mg2+]-[dNTP]).
+func (self *Conditions) sodium() float64 {
+	na := self.Na
+	if free := self.Mg - self.DNTP; free > 0 {
+		na += 120 * math.Sqrt(free)
+	}
+	return na / 1000
+}
+
+// A thermo holds enthalpy in kcal/mol and entropy in cal/K/mol.
+type thermo struct{ h, s float64 }
+
+// Return the free energy in kcal/mol at temperature t in Kelvin.
+func (self thermo) g(t float64) float64 { return self.h - t*self.s/1000 }
+
+// SantaLucia (1998) unified nearest-neighbour parameters indexed by the bases of the top strand
+// dinucleotide in A, C, G, T order.
+var stack = [4][4]thermo{
+	{{-7.9, -22.2}, {-8.4, -22.4}, {-7.8, -21.0}, {-7.2, -20.4}},  // AA AC AG AT
+	{{-8.5, -22.7}, {-8.0, -19.9}, {-10.6, -27.2}, {-7.8, -21.0}}, // CA CC CG CT
+	{{-8.2, -22.2}, {-9.8, -24.4}, {-8.0, -19.9}, {-8.4, -22.4}},  // GA GC GG GT
+	{{-7.2, -21.3}, {-8.2, -22.2}, {-8.5, -22.7}, {-7.9, -22.2}},  // TA TC TG TT
+}
+
+var (
+	initGC   = thermo{0.1, -2.8} // Initiation with a terminal G·C pair.
+	initAT   = thermo{2.3, 4.1}  // Initiation with a terminal A·T pair.
+	symmetry = thermo{0, -1.4}   // Symmetry correction for self-complementary duplexes.
+)
+
+// Return the initiation parameters for a terminal base b.
+func initiation(b int) thermo {
+	if b == 1 || b == 2 {
+		return initGC
+	}
+	return initAT
+}
+
+// Return the base indices of p or an error if p contains letters other than A, C, G, T or U.
+func bases(p []byte) (b []int, err error) {
+	b = make([]int, len(p))
+	for i, l := range p {
+		if b[i] = alphabet.BaseIndex(l); b[i] < 0 {
+			return nil, bio.NewError("Invalid base in oligonucleotide.", 0, string(p))
+		}
+	}
+	return
+}
+
+// Return whether the bases b form a self-complementary sequence.
+func selfComplementary(b []int) bool {
+	for i, j := 0, len(b)-1; i <= j; i, j = i+1, j-1 {
+		if b[i] != 3-b[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the nearest-neighbour enthalpy and entropy of the perfect duplex formed by the bases b with
+// their complement under conditions c. Entropy is corrected for salt as described by SantaLucia (1998).
+func duplex(b []int, c *Conditions) (t thermo) {
+	t.h, t.s = initiation(b[0]).h+initiation(b[len(b)-1]).h, initiation(b[0]).s+initiation(b[len(b)-1]).s
+	for i := 1; i < len(b); i++ {
+		st := stack[b[i-1]][b[i]]
+		t.h += st.h
+		t.s += st.s
+	}
+	if selfComplementary(b) {
+		t.s += symmetry.s
+	}
+	t.s += 0.368 * float64(len(b)-1) * math.Log(c.sodium())
+	return
+}
+
+// Return the melting temperature in °C of the duplex formed by the oligonucleotide p with its
+// perfect complement, calculated by the nearest-neighbour method with the unified parameters of
+// SantaLucia (1998) and salt and Mg2+ corrections. If c is nil, DefaultConditions are used.
+func Tm(p []byte, c *Conditions) (tm float64, err error) {
+	if c == nil {
+		c = &DefaultConditions
+	}
+	if len(p) < 2 {
+		return 0, bio.NewError("Oligonucleotide too short.", 0, string(p))
+	}
+	var b []int
+	if b, err = bases(p); err != nil {
+		return
+	}
+	t := duplex(b, c)
+	x := 4.
+	if selfComplementary(b) {
+		x = 1
+	}
+
+	return t.h*1000/(t.s+R*math.Log(c.Oligo*1e-9/x)) - Kelvin, nil
+}
+
+// Return the free energy in kcal/mol at 37°C of the duplex formed by the oligonucleotide p with
+// its perfect complement. If c is nil, DefaultConditions are used.
+func DeltaG(p []byte, c *Conditions) (g float64, err error) {
+	if c == nil {
+		c = &DefaultConditions
+	}
+	if len(p) < 2 {
+		return 0, bio.NewError("Oligonucleotide too short.", 0, string(p))
+	}
+	var b []int
+	if b, err = bases(p); err != nil {
+		return
+	}
+	return duplex(b, c).g(T37), nil
+}
+
+// Return the GC fraction of p.
+func GC(p []byte) float64 {
+	var gc int
+	for _, l := range p {
+		if b := alphabet.BaseIndex(l); b == 1 || b == 2 {
+			gc++
+		}
+	}
+	return float64(gc) / float64(len(p))
+}
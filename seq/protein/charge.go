@@ -0,0 +1,103 @@
+package protein
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/seq"
+	"math"
+)
+
+// PKa holds the pKa values of the terminal groups and ionisable side chains of a protein.
+type PKa struct {
+	NTerm, CTerm float64
+	Positive     Table // Side chains that are positively charged when protonated.
+	Negative     Table // Side chains that are negatively charged when deprotonated.
+}
+
+// pKa values used by EMBOSS iep.
+var EMBOSS = PKa{
+	NTerm:    8.6,
+	CTerm:    3.6,
+	Positive: Table{'K': 10.8, 'R': 12.5, 'H': 6.5},
+	Negative: Table{'D': 3.9, 'E': 4.1, 'C': 8.5, 'Y': 10.1},
+}
+
+// pKa values given by Lehninger, Principles of Biochemistry.
+var Lehninger = PKa{
+	NTerm:    9.69,
+	CTerm:    2.34,
+	Positive: Table{'K': 10.5, 'R': 12.4, 'H': 6.0},
+	Negative: Table{'D': 3.86, 'E': 4.25, 'C': 8.33, 'Y': 10.07},
+}
+
+// Return the net charge of s at the given pH using pk, or EMBOSS if pk is nil.
+func Charge(s *seq.Seq, pH float64, pk *PKa) (q float64, err error) {
+	if err = checkProtein(s); err != nil {
+		return
+	}
+	if pk == nil {
+		pk = &EMBOSS
+	}
+	return charge(s.Seq, pH, pk), nil
+}
+
+func charge(p []byte, pH float64, pk *PKa) (q float64) {
+	positive := func(pKa float64) float64 { return 1 / (1 + math.Pow(10, pH-pKa)) }
+	negative := func(pKa float64) float64 { return -1 / (1 + math.Pow(10, pKa-pH)) }
+
+	n := 0
+	for _, l := range p {
+		if ignored(l) {
+			continue
+		}
+		n++
+		if v, ok := pk.Positive.value(l); ok {
+			q += positive(v)
+		} else if v, ok := pk.Negative.value(l); ok {
+			q += negative(v)
+		}
+	}
+	if n > 0 {
+		q += positive(pk.NTerm) + negative(pk.CTerm)
+	}
+
+	return
+}
+
+// Tolerance of the pH returned by IsoelectricPoint.
+var PHTolerance = 1e-4
+
+// Return the isoelectric point of s, the pH at which its net charge is zero, using pk, or EMBOSS
+// if pk is nil. The isoelectric point is found by bisection between pH 0 and 14.
+func IsoelectricPoint(s *seq.Seq, pk *PKa) (pI float64, err error) {
+	if err = checkProtein(s); err != nil {
+		return
+	}
+	if pk == nil {
+		pk = &EMBOSS
+	}
+	lo, hi := 0., 14.
+	for hi-lo > PHTolerance {
+		mid := (lo + hi) / 2
+		if charge(s.Seq, mid, pk) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return (lo + hi) / 2, nil
+}
@@ -0,0 +1,103 @@
+package protein
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/seq"
+	"math"
+)
+
+// Hydropathy scale of Kyte and Doolittle (1982).
+var KyteDoolittle = Table{
+	'A': 1.8, 'R': -4.5, 'N': -3.5, 'D': -3.5, 'C': 2.5, 'Q': -3.5, 'E': -3.5,
+	'G': -0.4, 'H': -3.2, 'I': 4.5, 'L': 3.8, 'K': -3.9, 'M': 1.9, 'F': 2.8,
+	'P': -1.6, 'S': -0.8, 'T': -0.7, 'W': -0.9, 'Y': -1.3, 'V': 4.2,
+}
+
+// Hydrophilicity scale of Hopp and Woods (1981).
+var HoppWoods = Table{
+	'A': -0.5, 'R': 3.0, 'N': 0.2, 'D': 3.0, 'C': -1.0, 'Q': 0.2, 'E': 3.0,
+	'G': 0.0, 'H': -0.5, 'I': -1.8, 'L': -1.8, 'K': 3.0, 'M': -1.3, 'F': -2.5,
+	'P': 0.0, 'S': 0.3, 'T': -0.4, 'W': -3.4, 'Y': -2.3, 'V': -1.5,
+}
+
+// Normalised consensus hydrophobicity scale of Eisenberg et al. (1984).
+var Eisenberg = Table{
+	'A': 0.62, 'R': -2.53, 'N': -0.78, 'D': -0.90, 'C': 0.29, 'Q': -0.85, 'E': -0.74,
+	'G': 0.48, 'H': -0.40, 'I': 1.38, 'L': 1.06, 'K': -1.50, 'M': 0.64, 'F': 1.19,
+	'P': 0.12, 'S': -0.18, 'T': -0.05, 'W': 0.81, 'Y': 0.26, 'V': 1.08,
+}
+
+// Return the mean scale value of the residues of p that are in scale, or NaN if there are none.
+func mean(p []byte, scale Table) float64 {
+	var (
+		sum float64
+		n   int
+	)
+	for _, l := range p {
+		if v, ok := scale.value(l); ok {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return sum / float64(n)
+}
+
+// Return the grand average of hydropathy of s using scale, or KyteDoolittle if scale is nil.
+// Residues not in the scale are ignored.
+func GRAVY(s *seq.Seq, scale Table) (gravy float64, err error) {
+	if err = checkProtein(s); err != nil {
+		return
+	}
+	if scale == nil {
+		scale = KyteDoolittle
+	}
+	return mean(s.Seq, scale), nil
+}
+
+// A Window holds the hydropathy of the region of sequence from Start to End.
+type Window struct {
+	Start, End int
+	Value      float64
+}
+
+// Return the hydropathy profile of s as the mean scale value over each window of size residues,
+// stepping by one residue. Residues not in scale, or KyteDoolittle if scale is nil, are ignored.
+// Window coordinates are those of s.
+func Profile(s *seq.Seq, scale Table, size int) (w []Window, err error) {
+	if err = checkProtein(s); err != nil {
+		return
+	}
+	if size < 1 {
+		return nil, bio.NewError("Window size must be positive.", 0, size)
+	}
+	if scale == nil {
+		scale = KyteDoolittle
+	}
+	for i := 0; i+size <= s.Len(); i++ {
+		w = append(w, Window{
+			Start: s.Offset + i,
+			End:   s.Offset + i + size,
+			Value: mean(s.Seq[i:i+size], scale),
+		})
+	}
+
+	return
+}
@@ -0,0 +1,151 @@
+// Package for calculating physicochemical properties of protein sequences
+package protein
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/seq"
+)
+
+// A Table holds a value for each of a set of residues keyed by upper case one letter code.
+type Table map[byte]float64
+
+// Return the value for residue l, which may be lower case.
+func (self Table) value(l byte) (v float64, ok bool) {
+	if l >= 'a' && l <= 'z' {
+		l -= 'a' - 'A'
+	}
+	v, ok = self[l]
+	return
+}
+
+// Return whether l is a gap or stop and so ignored in calculations.
+func ignored(l byte) bool {
+	return l == '-' || l == '.' || l == '*'
+}
+
+// Return an error if s is not a protein sequence.
+func checkProtein(s *seq.Seq) error {
+	if s.Moltype() != bio.Protein {
+		return bio.NewError("Not a protein sequence.", 1, s)
+	}
+	return nil
+}
+
+// Masses holds residue masses, the mass of the polypeptide less a water for each peptide
+// bond, and the mass of the water added to give the mass of the intact chain.
+type Masses struct {
+	Residues Table
+	Water    float64
+}
+
+// Average residue masses in Daltons.
+var Average = Masses{
+	Residues: Table{
+		'A': 71.0788, 'R': 156.1875, 'N': 114.1038, 'D': 115.0886, 'C': 103.1388,
+		'E': 129.1155, 'Q': 128.1307, 'G': 57.0519, 'H': 137.1411, 'I': 113.1594,
+		'L': 113.1594, 'K': 128.1741, 'M': 131.1926, 'F': 147.1766, 'P': 97.1167,
+		'S': 87.0782, 'T': 101.1051, 'W': 186.2132, 'Y': 163.1760, 'V': 99.1326,
+		'U': 150.0388, 'O': 237.3018,
+	},
+	Water: 18.01524,
+}
+
+// Monoisotopic residue masses in Daltons.
+var Monoisotopic = Masses{
+	Residues: Table{
+		'A': 71.03711, 'R': 156.10111, 'N': 114.04293, 'D': 115.02694, 'C': 103.00919,
+		'E': 129.04259, 'Q': 128.05858, 'G': 57.02146, 'H': 137.05891, 'I': 113.08406,
+		'L': 113.08406, 'K': 128.09496, 'M': 131.04049, 'F': 147.06841, 'P': 97.05276,
+		'S': 87.03203, 'T': 101.04768, 'W': 186.07931, 'Y': 163.06333, 'V': 99.06841,
+		'U': 150.95364, 'O': 237.14773,
+	},
+	Water: 18.01056,
+}
+
+// Return the mass of the protein s using the masses in m, or Average if m is nil. Gaps and stops
+// are ignored and an error is returned for residues without a mass.
+func Mass(s *seq.Seq, m *Masses) (mass float64, err error) {
+	if err = checkProtein(s); err != nil {
+		return
+	}
+	if m == nil {
+		m = &Average
+	}
+	n := 0
+	for i, l := range s.Seq {
+		if ignored(l) {
+			continue
+		}
+		w, ok := m.Residues.value(l)
+		if !ok {
+			return 0, bio.NewError("No mass for residue.", 0, string(l), s.Offset+i)
+		}
+		mass += w
+		n++
+	}
+	if n > 0 {
+		mass += m.Water
+	}
+
+	return
+}
+
+// Return the number of each residue in s keyed by upper case one letter code. Gaps and
+// stops are not counted.
+func Composition(s *seq.Seq) (counts map[byte]int, err error) {
+	if err = checkProtein(s); err != nil {
+		return
+	}
+	counts = make(map[byte]int)
+	for _, l := range s.Seq {
+		if ignored(l) {
+			continue
+		}
+		if l >= 'a' && l <= 'z' {
+			l -= 'a' - 'A'
+		}
+		counts[l]++
+	}
+
+	return
+}
+
+// Extinction holds the molar extinction coefficients at 280 nm of the absorbing residues.
+type Extinction struct {
+	W, Y    float64
+	Cystine float64 // Coefficient for each pair of cysteines forming a disulphide bond.
+}
+
+// Extinction coefficients in M^-1 cm^-1 in water given by Pace et al. (1995).
+var Pace = Extinction{W: 5500, Y: 1490, Cystine: 125}
+
+// Return the molar extinction coefficients of s at 280 nm using e, or Pace if e is nil,
+// assuming all cysteines form cystines and assuming all cysteines are reduced.
+func ExtinctionCoefficient(s *seq.Seq, e *Extinction) (cystines, reduced float64, err error) {
+	var counts map[byte]int
+	if counts, err = Composition(s); err != nil {
+		return
+	}
+	if e == nil {
+		e = &Pace
+	}
+	reduced = float64(counts['W'])*e.W + float64(counts['Y'])*e.Y
+	cystines = reduced + float64(counts['C']/2)*e.Cystine
+
+	return
+}
@@ -0,0 +1,142 @@
+package protein
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"math"
+	"testing"
+)
+
+// Helpers
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func protein(p string) *seq.Seq {
+	s := seq.New("test", []byte(p), nil)
+	s.Alphabet = alphabet.Protein
+	return s
+}
+
+func (s *S) TestNotProtein(c *check.C) {
+	d := seq.New("test", []byte("ACGT"), nil)
+	_, err := Mass(d, nil)
+	c.Check(err, check.NotNil)
+	_, err = GRAVY(d, nil)
+	c.Check(err, check.NotNil)
+	_, err = IsoelectricPoint(d, nil)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestMass(c *check.C) {
+	for i, t := range []struct {
+		p    string
+		m    *Masses
+		mass float64
+	}{
+		{"GA", nil, 146.14594},
+		{"ga", &Average, 146.14594},
+		{"G-A*", &Monoisotopic, 146.06913},
+		{"", nil, 0},
+	} {
+		m, err := Mass(protein(t.p), t.m)
+		c.Check(err, check.Equals, nil)
+		c.Check(math.Abs(m-t.mass) < 1e-6, check.Equals, true, check.Commentf("Test %d: %f", i, m))
+	}
+	_, err := Mass(protein("GAX"), nil)
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestComposition(c *check.C) {
+	counts, err := Composition(protein("AAKw*-"))
+	c.Check(err, check.Equals, nil)
+	c.Check(counts, check.DeepEquals, map[byte]int{'A': 2, 'K': 1, 'W': 1})
+}
+
+func (s *S) TestExtinction(c *check.C) {
+	cystines, reduced, err := ExtinctionCoefficient(protein("WYCCCA"), nil)
+	c.Check(err, check.Equals, nil)
+	c.Check(cystines, check.Equals, 7115.)
+	c.Check(reduced, check.Equals, 6990.)
+	cystines, reduced, err = ExtinctionCoefficient(protein("WW"), &Extinction{W: 1, Y: 2, Cystine: 3})
+	c.Check(err, check.Equals, nil)
+	c.Check(cystines, check.Equals, 2.)
+	c.Check(reduced, check.Equals, 2.)
+}
+
+func (s *S) TestCharge(c *check.C) {
+	q, err := Charge(protein("KKKK"), 7, nil)
+	c.Check(err, check.Equals, nil)
+	c.Check(q > 3 && q < 5, check.Equals, true, check.Commentf("%f", q))
+	q, err = Charge(protein("DDDD"), 7, &Lehninger)
+	c.Check(err, check.Equals, nil)
+	c.Check(q < -3 && q > -5, check.Equals, true, check.Commentf("%f", q))
+	q, err = Charge(protein("G"), 1, nil)
+	c.Check(err, check.Equals, nil)
+	c.Check(q > 0.9, check.Equals, true, check.Commentf("%f", q))
+	q, err = Charge(protein(""), 7, nil)
+	c.Check(q, check.Equals, 0.)
+}
+
+func (s *S) TestIsoelectricPoint(c *check.C) {
+	for i, t := range []struct {
+		p      string
+		lo, hi float64
+	}{
+		{"DDDDE", 2, 4},
+		{"KKRKK", 10, 13},
+		{"G", 5.5, 6.5}, // (8.6+3.6)/2
+		{"MKWVTFISLLFLFSSAYSRGVFRR", 11, 13},
+	} {
+		p := protein(t.p)
+		pI, err := IsoelectricPoint(p, nil)
+		c.Check(err, check.Equals, nil)
+		c.Check(pI > t.lo && pI < t.hi, check.Equals, true, check.Commentf("Test %d: %f", i, pI))
+		q, _ := Charge(p, pI, nil)
+		c.Check(math.Abs(q) < 1e-3, check.Equals, true, check.Commentf("Test %d: %f", i, q))
+	}
+	pI, _ := IsoelectricPoint(protein("G"), nil)
+	c.Check(math.Abs(pI-6.1) < 1e-3, check.Equals, true, check.Commentf("%f", pI))
+}
+
+func (s *S) TestGRAVY(c *check.C) {
+	g, err := GRAVY(protein("AR"), nil)
+	c.Check(err, check.Equals, nil)
+	c.Check(math.Abs(g+1.35) < 1e-9, check.Equals, true)
+	g, err = GRAVY(protein("arX"), HoppWoods)
+	c.Check(err, check.Equals, nil)
+	c.Check(math.Abs(g-1.25) < 1e-9, check.Equals, true)
+	g, err = GRAVY(protein("X"), nil)
+	c.Check(math.IsNaN(g), check.Equals, true)
+}
+
+func (s *S) TestProfile(c *check.C) {
+	p := protein("AARR")
+	p.Offset = 10
+	w, err := Profile(p, nil, 2)
+	c.Check(err, check.Equals, nil)
+	c.Check(w, check.DeepEquals, []Window{{10, 12, 1.8}, {11, 13, -1.35}, {12, 14, -4.5}})
+	w, err = Profile(p, Eisenberg, 5)
+	c.Check(err, check.Equals, nil)
+	c.Check(w, check.HasLen, 0)
+	_, err = Profile(p, nil, 0)
+	c.Check(err, check.NotNil)
+}
@@ -78,7 +78,7 @@ func (self *Quality) Trunc(start, end int) (q *Quality, err error) {
 		if self.Inplace {
 			tq = append(self.Qual[start-self.Offset:], self.Qual[:end-self.Offset]...) // not quite inplace for this op
 		} else {
-			tq = make([]Qsanger, len(self.Qual)-start-self.Offset, len(self.Qual)+end-start)
+			tq = make([]Qsanger, len(self.Qual)-(start-self.Offset), len(self.Qual)+end-start)
 			copy(tq, self.Qual[start-self.Offset:])
 			tq = append(tq, self.Qual[:end-self.Offset]...)
 		}
@@ -89,6 +89,7 @@ func (self *Quality) Trunc(start, end int) (q *Quality, err error) {
 	if self.Inplace {
 		q = self
 		q.Qual = tq
+		q.Offset = start
 		q.Circular = false
 	} else {
 		q = &Quality{
@@ -221,6 +222,32 @@ func (self *Quality) stitch(fs []*interval.Interval) (tq []Qsanger) {
 	return
 }
 
+// Rotate Quality so that it begins at origin, given in sequence coordinates. The Offset of the
+// Quality is retained and the returned Quality is circular.
+func (self *Quality) Rotate(origin int) (q *Quality, err error) {
+	if origin < self.Offset || origin > self.End() {
+		return nil, bio.NewError("Origin out of range.", 0, origin)
+	}
+
+	o := origin - self.Offset
+	rq := append(append(make([]Qsanger, 0, len(self.Qual)), self.Qual[o:]...), self.Qual[:o]...)
+	if self.Inplace {
+		copy(self.Qual, rq)
+		q = self
+		q.Circular = true
+	} else {
+		q = &Quality{
+			ID:       self.ID,
+			Qual:     rq,
+			Offset:   self.Offset,
+			Strand:   self.Strand,
+			Circular: true,
+		}
+	}
+
+	return
+}
+
 // Return the quality as a Sanger quality string
 func (self *Quality) String() string {
 	qs := make([]byte, 0, len(self.Qual))
@@ -0,0 +1,118 @@
+package restriction
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import "github.com/kortschak/BioGo/bio"
+
+// Site definitions of commonly used enzymes in the style of REBASE.
+var definitions = [...]struct{ name, site string }{
+	{"AatII", "GACGT^C"},
+	{"AccI", "GT^MKAC"},
+	{"AflII", "C^TTAAG"},
+	{"AgeI", "A^CCGGT"},
+	{"AluI", "AG^CT"},
+	{"ApaI", "GGGCC^C"},
+	{"ApaLI", "G^TGCAC"},
+	{"AscI", "GG^CGCGCC"},
+	{"AvaI", "C^YCGRG"},
+	{"AvrII", "C^CTAGG"},
+	{"BamHI", "G^GATCC"},
+	{"BanI", "G^GYRCC"},
+	{"BbsI", "GAAGAC(2/6)"},
+	{"BclI", "T^GATCA"},
+	{"BglII", "A^GATCT"},
+	{"BsaI", "GGTCTC(1/5)"},
+	{"BsmBI", "CGTCTC(1/5)"},
+	{"BspEI", "T^CCGGA"},
+	{"BsrGI", "T^GTACA"},
+	{"BstXI", "CCANNNNN^NTGG"},
+	{"ClaI", "AT^CGAT"},
+	{"DpnII", "^GATC"},
+	{"DraI", "TTT^AAA"},
+	{"EagI", "C^GGCCG"},
+	{"EcoRI", "G^AATTC"},
+	{"EcoRV", "GAT^ATC"},
+	{"FokI", "GGATG(9/13)"},
+	{"HaeIII", "GG^CC"},
+	{"HhaI", "GCG^C"},
+	{"HincII", "GTY^RAC"},
+	{"HindIII", "A^AGCTT"},
+	{"HinfI", "G^ANTC"},
+	{"HpaI", "GTT^AAC"},
+	{"HpaII", "C^CGG"},
+	{"KpnI", "GGTAC^C"},
+	{"MboI", "^GATC"},
+	{"MfeI", "C^AATTG"},
+	{"MluI", "A^CGCGT"},
+	{"MseI", "T^TAA"},
+	{"MspI", "C^CGG"},
+	{"NaeI", "GCC^GGC"},
+	{"NcoI", "C^CATGG"},
+	{"NdeI", "CA^TATG"},
+	{"NheI", "G^CTAGC"},
+	{"NlaIII", "CATG^"},
+	{"NotI", "GC^GGCCGC"},
+	{"NruI", "TCG^CGA"},
+	{"NsiI", "ATGCA^T"},
+	{"PacI", "TTAAT^TAA"},
+	{"PmeI", "GTTT^AAAC"},
+	{"PstI", "CTGCA^G"},
+	{"PvuI", "CGAT^CG"},
+	{"PvuII", "CAG^CTG"},
+	{"RsaI", "GT^AC"},
+	{"SacI", "GAGCT^C"},
+	{"SacII", "CCGC^GG"},
+	{"SalI", "G^TCGAC"},
+	{"SapI", "GCTCTTC(1/4)"},
+	{"Sau3AI", "^GATC"},
+	{"ScaI", "AGT^ACT"},
+	{"SfiI", "GGCCNNNN^NGGCC"},
+	{"SmaI", "CCC^GGG"},
+	{"SpeI", "A^CTAGT"},
+	{"SphI", "GCATG^C"},
+	{"SspI", "AAT^ATT"},
+	{"StuI", "AGG^CCT"},
+	{"StyI", "C^CWWGG"},
+	{"TaqI", "T^CGA"},
+	{"XbaI", "T^CTAGA"},
+	{"XhoI", "C^TCGAG"},
+	{"XmaI", "C^CCGGG"},
+}
+
+// Enzymes is the built-in catalogue of restriction enzymes keyed by name.
+var Enzymes = func() (e map[string]*Enzyme) {
+	e = make(map[string]*Enzyme, len(definitions))
+	for _, d := range definitions {
+		enzyme, err := Parse(d.name, d.site)
+		if err != nil {
+			panic(err)
+		}
+		e[d.name] = enzyme
+	}
+	return
+}()
+
+// Return the enzymes of the catalogue with the given names. An error is returned if a name is not found.
+func Lookup(names ...string) (e []*Enzyme, err error) {
+	for _, n := range names {
+		enzyme, ok := Enzymes[n]
+		if !ok {
+			return nil, bio.NewError("Unknown enzyme.", 0, n)
+		}
+		e = append(e, enzyme)
+	}
+	return
+}
@@ -0,0 +1,370 @@
+// Package for restriction enzyme site mapping and digestion
+package restriction
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/seq"
+	"github.com/kortschak/BioGo/util"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// End types produced by a restriction enzyme.
+const (
+	Blunt      EndType = iota
+	FivePrime          // 5' overhang.
+	ThreePrime         // 3' overhang.
+)
+
+type EndType int8
+
+// An Enzyme describes the recognition site and cleavage positions of a restriction enzyme.
+// Cut positions are given as the number of bases from the first base of the recognition site
+// on the top strand; the position of the cut on the bottom strand is expressed in top strand
+// coordinates. Enzymes cutting on both sides of their site are not supported.
+type Enzyme struct {
+	Name          string
+	Site          string // Recognition site 5'-3' using IUPAC nucleotide codes.
+	Cut           int    // Top strand cut.
+	ComplementCut int    // Bottom strand cut.
+
+	pattern, rcPattern []byte
+	palindromic        bool
+}
+
+// Return a new Enzyme with the given recognition site and cut positions.
+func NewEnzyme(name, site string, cut, complementCut int) (e *Enzyme, err error) {
+	if len(site) == 0 {
+		return nil, bio.NewError("Empty recognition site.", 0, name)
+	}
+	e = &Enzyme{
+		Name:          name,
+		Site:          strings.ToUpper(site),
+		Cut:           cut,
+		ComplementCut: complementCut,
+		pattern:       make([]byte, len(site)),
+		rcPattern:     make([]byte, len(site)),
+	}
+	for i := range site {
+		m := alphabet.BaseSet(site[i])
+		if m == 0 {
+			return nil, bio.NewError("Invalid letter in recognition site.", 0, name, site)
+		}
+		e.pattern[i], e.rcPattern[len(site)-i-1] = m, alphabet.ComplementSet(m)
+	}
+	e.palindromic = string(e.pattern) == string(e.rcPattern) && cut+complementCut == len(site)
+
+	return
+}
+
+// Return a new Enzyme described by a REBASE style site definition, either a site with the top
+// strand cut marked by a caret, as in "G^AATTC", where the bottom strand cut is symmetrical, or a
+// site followed by the top and bottom strand cut positions relative to its end, as in
+// "GGTCTC(1/5)".
+func Parse(name, definition string) (e *Enzyme, err error) {
+	if i := strings.Index(definition, "("); i >= 0 {
+		site := definition[:i]
+		cuts := strings.Split(strings.TrimSuffix(definition[i+1:], ")"), "/")
+		if len(cuts) != 2 || !strings.HasSuffix(definition, ")") {
+			return nil, bio.NewError("Invalid cut definition.", 0, name, definition)
+		}
+		var top, bottom int
+		if top, err = strconv.Atoi(cuts[0]); err != nil {
+			return nil, bio.NewError("Invalid top strand cut.", 0, name, definition)
+		}
+		if bottom, err = strconv.Atoi(cuts[1]); err != nil {
+			return nil, bio.NewError("Invalid bottom strand cut.", 0, name, definition)
+		}
+		return NewEnzyme(name, site, len(site)+top, len(site)+bottom)
+	}
+	if i := strings.Index(definition, "^"); i >= 0 {
+		site := definition[:i] + definition[i+1:]
+		return NewEnzyme(name, site, i, len(site)-i)
+	}
+
+	return nil, bio.NewError("No cut position in definition.", 0, name, definition)
+}
+
+// Return the length of the single stranded overhang left by the enzyme. The length is positive
+// for 5' overhangs and negative for 3' overhangs.
+func (self *Enzyme) Overhang() int { return self.ComplementCut - self.Cut }
+
+// Return the type of end left by the enzyme.
+func (self *Enzyme) End() EndType {
+	switch o := self.Overhang(); {
+	case o > 0:
+		return FivePrime
+	case o < 0:
+		return ThreePrime
+	}
+	return Blunt
+}
+
+func (self *Enzyme) String() string { return self.Name }
+
+// Return whether the sequence letters l match the pattern p. Ambiguous letters in l match only
+// if all the bases they represent match.
+func matches(p []byte, l []byte, i int) bool {
+	for j, m := range p {
+		b := alphabet.BaseSet(l[(i+j)%len(l)])
+		if b == 0 || b&^m != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// A Site is a recognition site of an enzyme found in a sequence. Positions are in sequence
+// coordinates; for circular sequences cut positions are reduced into the sequence.
+type Site struct {
+	Enzyme        *Enzyme
+	Position      int    // Start of the recognition site on the top strand.
+	Strand        int8   // Strand the site is read from: 1 for the top strand and -1 for the bottom.
+	Cut           int    // Top strand cut.
+	ComplementCut int    // Bottom strand cut in top strand coordinates.
+	Overhang      []byte // Top strand letters of the single stranded overhang, nil if blunt or not cut.
+	cuts          bool   // Whether the cut lies within the sequence.
+}
+
+// Return whether the ends left by cleavage at sites a and b can be ligated in some orientation.
+func Compatible(a, b *Site) bool {
+	if !a.cuts || !b.cuts || a.Enzyme.End() != b.Enzyme.End() || len(a.Overhang) != len(b.Overhang) {
+		return false
+	}
+	if strings.EqualFold(string(a.Overhang), string(b.Overhang)) {
+		return true
+	}
+	for i, l := range a.Overhang {
+		if alphabet.BaseSet(l) != alphabet.ComplementSet(alphabet.BaseSet(b.Overhang[len(b.Overhang)-i-1])) {
+			return false
+		}
+	}
+	return true
+}
+
+type byPosition []*Site
+
+func (self byPosition) Len() int { return len(self) }
+func (self byPosition) Less(i, j int) bool {
+	if self[i].Position == self[j].Position {
+		return self[i].Strand > self[j].Strand
+	}
+	return self[i].Position < self[j].Position
+}
+func (self byPosition) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+// Return the recognition sites of the enzyme in s ordered by position. Sites of circular sequences
+// may span the origin. Palindromic sites are reported once, on the top strand.
+func (self *Enzyme) Sites(s *seq.Seq) (sites []*Site, err error) {
+	if m := s.Moltype(); m != bio.DNA && m != bio.RNA {
+		return nil, bio.NewError("Cannot find restriction sites in non-nucleic acid sequence.", 0, s)
+	}
+	n, k := s.Len(), len(self.pattern)
+	last := n - k
+	if s.Circular && k <= n {
+		last = n - 1
+	}
+
+	for i := 0; i <= last; i++ {
+		if matches(self.pattern, s.Seq, i) {
+			sites = append(sites, self.site(s, i, 1, i+self.Cut, i+self.ComplementCut))
+		}
+		if !self.palindromic && matches(self.rcPattern, s.Seq, i) {
+			sites = append(sites, self.site(s, i, -1, i+k-self.ComplementCut, i+k-self.Cut))
+		}
+	}
+	sort.Sort(byPosition(sites))
+
+	return
+}
+
+// Return a Site at index i of s with the given top and bottom strand cuts as indices into s.
+func (self *Enzyme) site(s *seq.Seq, i int, strand int8, top, bottom int) *Site {
+	n := s.Len()
+	st := &Site{Enzyme: self, Position: s.Offset + i, Strand: strand}
+	lo, hi := top, bottom
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	if s.Circular {
+		st.cuts = hi-lo < n
+		top, bottom = mod(top, n), mod(bottom, n)
+	} else {
+		st.cuts = lo > 0 && hi < n
+	}
+	st.Cut, st.ComplementCut = s.Offset+top, s.Offset+bottom
+	if st.cuts && lo < hi {
+		st.Overhang = make([]byte, hi-lo)
+		for j := range st.Overhang {
+			st.Overhang[j] = s.Seq[mod(lo+j, n)]
+		}
+	}
+
+	return st
+}
+
+func mod(i, n int) int {
+	if i %= n; i < 0 {
+		i += n
+	}
+	return i
+}
+
+// Return the sites in s of all the given enzymes ordered by position.
+func Map(s *seq.Seq, enzymes ...*Enzyme) (sites []*Site, err error) {
+	for _, e := range enzymes {
+		var es []*Site
+		if es, err = e.Sites(s); err != nil {
+			return nil, err
+		}
+		sites = append(sites, es...)
+	}
+	sort.Stable(byPosition(sites))
+
+	return
+}
+
+// A Fragment is a product of a restriction digest. The Seq holds the top strand letters spanned
+// by either strand of the fragment, so single stranded overhangs are included. Left and Right
+// are the sites that produced the ends of the fragment, or nil for the ends of a linear sequence.
+type Fragment struct {
+	*seq.Seq
+	Left, Right *Site
+}
+
+// Return the length of the overhang at the left end of the fragment as described for
+// Enzyme.Overhang, the first Abs(length) letters of the fragment being single stranded.
+func (self Fragment) LeftOverhang() int {
+	if self.Left == nil {
+		return 0
+	}
+	return self.Left.Enzyme.Overhang()
+}
+
+// Return the length of the overhang at the right end of the fragment as described for
+// Enzyme.Overhang, the last Abs(length) letters of the fragment being single stranded.
+func (self Fragment) RightOverhang() int {
+	if self.Right == nil {
+		return 0
+	}
+	return self.Right.Enzyme.Overhang()
+}
+
+type byCut []*Site
+
+func (self byCut) Len() int           { return len(self) }
+func (self byCut) Less(i, j int) bool { return self[i].Cut < self[j].Cut }
+func (self byCut) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+
+// Digest s with the given enzymes, returning the fragments in order along the sequence. For a
+// circular sequence the first fragment begins at the first cut following the origin and the
+// last fragment spans the origin. A sequence that is not cut is returned as the only fragment. Sites
+// whose cuts lie outside a linear sequence are ignored and an error is returned if the cuts of
+// two sites overlap.
+func Digest(s *seq.Seq, enzymes ...*Enzyme) (fragments []Fragment, err error) {
+	var sites []*Site
+	if sites, err = Map(s, enzymes...); err != nil {
+		return
+	}
+	cuts := sites[:0]
+	for _, st := range sites {
+		if st.cuts {
+			cuts = append(cuts, st)
+		}
+	}
+	sort.Stable(byCut(cuts))
+	for i := 1; i < len(cuts); i++ { // Remove cuts made identically by more than one site.
+		if cuts[i].Cut == cuts[i-1].Cut && cuts[i].ComplementCut == cuts[i-1].ComplementCut {
+			cuts = append(cuts[:i], cuts[i+1:]...)
+			i--
+		}
+	}
+
+	if len(cuts) == 0 {
+		return []Fragment{{Seq: s}}, nil
+	}
+
+	// Indices of the extent of the fragment ends to the left and right of a cut.
+	n := s.Len()
+	left := func(st *Site) int { return st.Cut - s.Offset + util.Min(0, st.Enzyme.Overhang()) }
+	right := func(st *Site) int { return st.Cut - s.Offset + util.Max(0, st.Enzyme.Overhang()) }
+
+	var bounds []bound
+	if !s.Circular {
+		bounds = append(bounds, bound{0, right(cuts[0]), nil, cuts[0]})
+	}
+	for i, st := range cuts {
+		switch {
+		case i+1 < len(cuts):
+			bounds = append(bounds, bound{left(st), right(cuts[i+1]), st, cuts[i+1]})
+			if right(st) > left(cuts[i+1]) {
+				return nil, bio.NewError("Overlapping cut sites.", 0, st, cuts[i+1])
+			}
+		case s.Circular:
+			bounds = append(bounds, bound{left(st), right(cuts[0]) + n, st, cuts[0]})
+			if right(st) > left(cuts[0])+n {
+				return nil, bio.NewError("Overlapping cut sites.", 0, st, cuts[0])
+			}
+		default:
+			bounds = append(bounds, bound{left(st), n, st, nil})
+		}
+	}
+
+	for _, b := range bounds {
+		var f *seq.Seq
+		if f, err = fragment(s, b.start, b.end); err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, Fragment{Seq: f, Left: b.left, Right: b.right})
+	}
+
+	return
+}
+
+// A bound describes the extent of a fragment as indices into the digested sequence and the sites
+// producing its ends.
+type bound struct {
+	start, end  int
+	left, right *Site
+}
+
+// Return the letters of s from index start to end, wrapping around the origin of a circular
+// sequence. Features and quality are carried for fragments no longer than s.
+func fragment(s *seq.Seq, start, end int) (f *seq.Seq, err error) {
+	n := s.Len()
+	if !s.Circular {
+		return s.Trunc(s.Offset+start, s.Offset+end)
+	}
+	if end-start < n {
+		return s.Trunc(s.Offset+mod(start, n), s.Offset+mod(end, n))
+	}
+
+	l := make([]byte, end-start)
+	for i := range l {
+		l[i] = s.Seq[mod(start+i, n)]
+	}
+	return &seq.Seq{
+		ID:       s.ID,
+		Seq:      l,
+		Offset:   s.Offset + mod(start, n),
+		Strand:   s.Strand,
+		Alphabet: s.Alphabet,
+	}, nil
+}
@@ -0,0 +1,142 @@
+package restriction
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func (s *S) TestParse(c *check.C) {
+	for _, t := range []struct {
+		def                string
+		cut, complementCut int
+		end                EndType
+	}{
+		{"G^AATTC", 1, 5, FivePrime},
+		{"CTGCA^G", 5, 1, ThreePrime},
+		{"GAT^ATC", 3, 3, Blunt},
+		{"GGTCTC(1/5)", 7, 11, FivePrime},
+		{"SfiI", 8, 5, ThreePrime},
+		{"^GATC", 0, 4, FivePrime},
+	} {
+		e, ok := Enzymes[t.def]
+		if !ok {
+			var err error
+			e, err = Parse("test", t.def)
+			c.Assert(err, check.IsNil)
+		}
+		c.Check(e.Cut, check.Equals, t.cut, check.Commentf("%s", t.def))
+		c.Check(e.ComplementCut, check.Equals, t.complementCut, check.Commentf("%s", t.def))
+		c.Check(e.End(), check.Equals, t.end, check.Commentf("%s", t.def))
+	}
+	for _, def := range []string{"GAATTC", "GGTCTC(1/x)", "GGTCTC(1/5", "GZA^TTC", "^"} {
+		_, err := Parse("bad", def)
+		c.Check(err, check.NotNil, check.Commentf("%s", def))
+	}
+	c.Check(Enzymes["EcoRI"].palindromic, check.Equals, true)
+	c.Check(Enzymes["BsaI"].palindromic, check.Equals, false)
+	c.Check(Enzymes["BstXI"].Site, check.Equals, "CCANNNNNNTGG")
+
+	_, err := Lookup("EcoRI", "NoSuchI")
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestSites(c *check.C) {
+	sites, err := Enzymes["HincII"].Sites(&seq.Seq{Seq: []byte("GTCGACaagttaacAAGTNAAC"), Offset: 10})
+	c.Assert(err, check.IsNil)
+	c.Assert(len(sites), check.Equals, 2)
+	c.Check(sites[0].Position, check.Equals, 10)
+	c.Check(sites[1].Position, check.Equals, 18)
+	c.Check(sites[1].Cut, check.Equals, 21)
+	c.Check(sites[1].Overhang, check.IsNil)
+
+	// BsaI site on the bottom strand cutting upstream on the top strand.
+	sites, err = Enzymes["BsaI"].Sites(&seq.Seq{Seq: []byte("AACCCCAAGAGACCAA")})
+	c.Assert(err, check.IsNil)
+	c.Assert(len(sites), check.Equals, 1)
+	c.Check(sites[0].Position, check.Equals, 8)
+	c.Check(sites[0].Strand, check.Equals, int8(-1))
+	c.Check(sites[0].Cut, check.Equals, 3)
+	c.Check(sites[0].ComplementCut, check.Equals, 7)
+	c.Check(string(sites[0].Overhang), check.Equals, "CCCA")
+
+	_, err = Enzymes["EcoRI"].Sites(&seq.Seq{Seq: []byte("MKVLAAG"), Alphabet: alphabet.Protein})
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestDigest(c *check.C) {
+	type frag struct {
+		seq         string
+		offset      int
+		left, right int
+	}
+	for _, t := range []struct {
+		seq      string
+		circular bool
+		enzymes  []string
+		frags    []frag
+	}{
+		{"AAAGAATTCAAA", false, []string{"EcoRI"}, []frag{{"AAAGAATT", 0, 0, 4}, {"AATTCAAA", 4, 4, 0}}},
+		{"AACTGCAGAA", false, []string{"PstI"}, []frag{{"AACTGCA", 0, 0, -4}, {"TGCAGAA", 3, -4, 0}}},
+		{"AAGAATTCAAAAGGATCCAA", false, []string{"BamHI", "EcoRI"}, []frag{
+			{"AAGAATT", 0, 0, 4}, {"AATTCAAAAGGATC", 3, 4, 4}, {"GATCCAA", 13, 4, 0},
+		}},
+		{"AAGGATCCAA", false, []string{"BamHI", "DpnII"}, []frag{{"AAGGATC", 0, 0, 4}, {"GATCCAA", 3, 4, 0}}},
+		{"AAAAAAGGTCTCA", false, []string{"BsaI"}, []frag{{"AAAAAAGGTCTCA", 0, 0, 0}}},
+		{"ATTCAAAAGA", true, []string{"EcoRI"}, []frag{{"AATTCAAAAGAATT", 9, 4, 4}}},
+		{"ATTCAAAAGA", true, []string{"HindIII"}, []frag{{"ATTCAAAAGA", 0, 0, 0}}},
+		{"GATATCAAAAGGATCCAA", true, []string{"BamHI", "EcoRV"}, []frag{
+			{"ATCAAAAGGATC", 3, 0, 4}, {"GATCCAAGAT", 11, 4, 0},
+		}},
+	} {
+		sq := &seq.Seq{ID: "test", Seq: []byte(t.seq), Circular: t.circular}
+		e, err := Lookup(t.enzymes...)
+		c.Assert(err, check.IsNil)
+		f, err := Digest(sq, e...)
+		c.Assert(err, check.IsNil)
+		var got []frag
+		for _, fr := range f {
+			got = append(got, frag{string(fr.Seq.Seq), fr.Offset, fr.LeftOverhang(), fr.RightOverhang()})
+		}
+		c.Check(got, check.DeepEquals, t.frags, check.Commentf("%s %v", t.seq, t.enzymes))
+	}
+
+	_, err := Digest(&seq.Seq{Seq: []byte("AAGGGCCCAA")}, Enzymes["ApaI"], Enzymes["HaeIII"])
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestCompatible(c *check.C) {
+	sq := &seq.Seq{Seq: []byte("AAGGATCCAAAGATCTAAGAATTCAAGATATCAACCCGGGAA")}
+	sites, err := Map(sq, Enzymes["BamHI"], Enzymes["BglII"], Enzymes["EcoRI"], Enzymes["EcoRV"], Enzymes["SmaI"])
+	c.Assert(err, check.IsNil)
+	c.Assert(len(sites), check.Equals, 5)
+	bam, bgl, eco, ecov, sma := sites[0], sites[1], sites[2], sites[3], sites[4]
+	c.Check(bgl.Enzyme.Name, check.Equals, "BglII")
+	c.Check(Compatible(bam, bgl), check.Equals, true)
+	c.Check(Compatible(bam, eco), check.Equals, false)
+	c.Check(Compatible(ecov, sma), check.Equals, true)
+	c.Check(Compatible(eco, sma), check.Equals, false)
+}
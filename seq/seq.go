@@ -17,10 +17,12 @@ package seq
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/alphabet"
 	"github.com/kortschak/BioGo/bio"
 	"github.com/kortschak/BioGo/feat"
 	"github.com/kortschak/BioGo/interval"
 	"github.com/kortschak/BioGo/util"
+	"unicode"
 )
 
 const (
@@ -29,12 +31,8 @@ const (
 )
 
 var (
-	complement = [...]map[byte]byte{
-		{'a': 't', 'c': 'g', 'g': 'c', 't': 'a', 'n': 'n',
-			'A': 'T', 'C': 'G', 'G': 'C', 'T': 'A', 'N': 'N'}, // DNA rules
-		{'a': 'u', 'c': 'g', 'g': 'c', 'u': 'a', 'n': 'n',
-			'A': 'U', 'C': 'G', 'G': 'C', 'U': 'A', 'N': 'N'}, // RNA rules
-	}
+	DefaultAlphabet      alphabet.Alphabet = alphabet.DNA          // Alphabet used to index the letters of Seqs with a nil Alphabet.
+	DefaultValidAlphabet alphabet.Alphabet = alphabet.DNAredundant // Alphabet used to validate Seqs with a nil Alphabet.
 
 	emptyString = ""
 )
@@ -45,8 +43,9 @@ type Seq struct {
 	Offset   int
 	Strand   int8
 	Circular bool
-	Moltype  bio.Moltype
+	Alphabet alphabet.Alphabet // If nil, DefaultAlphabet is used, and DefaultValidAlphabet for validation.
 	Quality  *Quality
+	Features feat.FeatureSet // Annotation in sequence coordinates, transformed by operations on the Seq.
 	Inplace  bool
 	Meta     interface{} // No operation on Seq objects implicitly copies or changes the contents of Meta.
 }
@@ -58,7 +57,6 @@ func New(id string, seq []byte, qual *Quality) *Seq {
 		Offset:   0,
 		Strand:   1,
 		Circular: false,
-		Moltype:  0,
 		Quality:  qual,
 		Inplace:  false,
 	}
@@ -70,6 +68,83 @@ func (self *Seq) WorkInplace(b bool) *Seq {
 	return self
 }
 
+// Return the Moltype described by an alphabet, or bio.Undefined if it is not a DNA, RNA or
+// protein alphabet.
+func MoltypeOf(a alphabet.Alphabet) bio.Moltype {
+	switch a.(type) {
+	case *alphabet.Deoxyribonucleic:
+		return bio.DNA
+	case *alphabet.Ribonucleic:
+		return bio.RNA
+	case *alphabet.Peptide:
+		return bio.Protein
+	}
+	return bio.Undefined
+}
+
+// Return the default alphabet for a Moltype, or nil if the Moltype is not DNA, RNA or protein.
+func AlphabetOf(m bio.Moltype) alphabet.Alphabet {
+	switch m {
+	case bio.DNA:
+		return alphabet.DNA
+	case bio.RNA:
+		return alphabet.RNA
+	case bio.Protein:
+		return alphabet.Protein
+	}
+	return nil
+}
+
+// Set the Alphabet of the Seq and check that the Seq conforms to the alphabet. An error is
+// returned with the position of the first invalid letter if it does not.
+func (self *Seq) SetAlphabet(a alphabet.Alphabet) (err error) {
+	self.Alphabet = a
+	if ok, pos := self.Validate(); !ok {
+		return bio.NewError("Invalid letter in sequence.", 0, self.ID, pos)
+	}
+	return
+}
+
+// Return the Alphabet of the Seq, or DefaultAlphabet if Alphabet is nil.
+func (self *Seq) GetAlphabet() alphabet.Alphabet {
+	if self.Alphabet != nil {
+		return self.Alphabet
+	}
+	return DefaultAlphabet
+}
+
+// Return the Moltype described by the Seq's alphabet.
+func (self *Seq) Moltype() bio.Moltype {
+	return MoltypeOf(self.GetAlphabet())
+}
+
+// Check that the Seq conforms to its alphabet, or DefaultValidAlphabet if Alphabet is nil,
+// returning false and the position of the first invalid letter if invalid and true and a
+// negative int if valid.
+func (self *Seq) Validate() (valid bool, pos int) {
+	if self.Alphabet == nil {
+		return DefaultValidAlphabet.AllValid(self.Seq)
+	}
+	return self.Alphabet.AllValid(self.Seq)
+}
+
+// Return a complement table for the Seq's alphabet. Letters without a complement in the
+// alphabet's pairing are complemented to themselves.
+func (self *Seq) complementTable() (t []byte, err error) {
+	c, ok := self.GetAlphabet().(alphabet.Complementable)
+	if !ok {
+		return nil, bio.NewError("Alphabet is not complementable.", 0, self)
+	}
+	t = c.ComplementTable()
+	for i, l := range t {
+		if l > unicode.MaxASCII {
+			t[i] = byte(i)
+		}
+	}
+
+	return
+}
+
 func (self *Seq) Len() int {
 	return len(self.Seq)
 }
@@ -94,17 +169,24 @@ func (self *Seq) Trunc(start, end int) (s *Seq, err error) {
 		return nil, bio.NewError("Start or end position out of range.", 0, self)
 	}
 
+	var fs feat.FeatureSet
 	if start <= end {
+		fs = self.remapFeatures([]segment{{start - self.Offset, end - self.Offset, 0}}, end-start, false, false)
 		if self.Inplace {
 			ts = self.Seq[start-self.Offset : end-self.Offset]
 		} else {
 			ts = append([]byte{}, self.Seq[start-self.Offset:end-self.Offset]...)
 		}
 	} else if self.Circular {
+		tail := len(self.Seq) - (start - self.Offset)
+		fs = self.remapFeatures([]segment{
+			{start - self.Offset, len(self.Seq), 0},
+			{0, end - self.Offset, tail},
+		}, tail+end-self.Offset, false, false)
 		if self.Inplace {
 			ts = append(self.Seq[start-self.Offset:], self.Seq[:end-self.Offset]...) // not quite inplace for this op
 		} else {
-			ts = make([]byte, len(self.Seq)-start-self.Offset, len(self.Seq)+end-start)
+			ts = make([]byte, tail, len(self.Seq)+end-start)
 			copy(ts, self.Seq[start-self.Offset:])
 			ts = append(ts, self.Seq[:end-self.Offset]...)
 		}
@@ -123,6 +205,7 @@ func (self *Seq) Trunc(start, end int) (s *Seq, err error) {
 	if self.Inplace {
 		s = self
 		s.Seq = ts
+		s.Offset = start
 		s.Circular = false
 		s.Quality = q
 	} else {
@@ -132,14 +215,17 @@ func (self *Seq) Trunc(start, end int) (s *Seq, err error) {
 			Offset:   start,
 			Strand:   self.Strand,
 			Circular: false,
-			Moltype:  self.Moltype,
+			Alphabet: self.Alphabet,
 			Quality:  q,
 		}
 	}
+	s.Features = offsetFeatures(fs, s.Offset)
 
 	return
 }
 
+// Reverse complement the Seq using the pairing of its alphabet. IUPAC ambiguity codes and gaps
+// are complemented by the default nucleic acid alphabets.
 func (self *Seq) RevComp() (s *Seq, err error) {
 	var rs []byte
 	if self.Inplace {
@@ -151,17 +237,21 @@ func (self *Seq) RevComp() (s *Seq, err error) {
 		rs = make([]byte, len(self.Seq))
 	}
 
-	if self.Moltype == bio.DNA || self.Moltype == bio.RNA {
-		i, j := 0, len(self.Seq)-1
-		for ; i < j; i, j = i+1, j-1 {
-			rs[i], rs[j] = complement[self.Moltype][self.Seq[j]], complement[self.Moltype][self.Seq[i]]
-		}
-		if i == j {
-			rs[i] = complement[self.Moltype][self.Seq[i]]
-		}
-	} else {
+	if self.Moltype() == bio.Protein {
 		return nil, bio.NewError("Cannot reverse-complement protein.", 0, self)
 	}
+	complement, err := self.complementTable()
+	if err != nil {
+		return nil, err
+	}
+	fs := self.remapFeatures([]segment{{0, len(self.Seq), 0}}, len(self.Seq), true, self.Circular)
+	i, j := 0, len(self.Seq)-1
+	for ; i < j; i, j = i+1, j-1 {
+		rs[i], rs[j] = complement[self.Seq[j]], complement[self.Seq[i]]
+	}
+	if i == j {
+		rs[i] = complement[self.Seq[i]]
+	}
 
 	var q *Quality
 	if self.Quality != nil {
@@ -178,10 +268,11 @@ func (self *Seq) RevComp() (s *Seq, err error) {
 			Offset:   self.Offset + len(self.Seq),
 			Strand:   -self.Strand,
 			Circular: self.Circular,
-			Moltype:  self.Moltype,
+			Alphabet: self.Alphabet,
 			Quality:  q,
 		}
 	}
+	s.Features = offsetFeatures(fs, s.Offset)
 
 	return
 }
@@ -200,13 +291,19 @@ func (self *Seq) Join(s *Seq, where int) (j *Seq, err error) {
 		return nil, bio.NewError("Inplace operation on Quality with non-Inplace operation on parent Seq.", 0, self)
 	}
 
+	n := len(self.Seq) + len(s.Seq)
+	var fs feat.FeatureSet
 	switch where {
 	case Prepend:
+		fs = append(s.remapFeatures([]segment{{0, len(s.Seq), 0}}, n, false, false),
+			self.remapFeatures([]segment{{0, len(self.Seq), len(s.Seq)}}, n, false, false)...)
 		ID = s.ID + "+" + self.ID
 		ts = make([]byte, len(s.Seq), len(s.Seq)+len(self.Seq))
 		copy(ts, s.Seq)
 		ts = append(ts, self.Seq...)
 	case Append:
+		fs = append(self.remapFeatures([]segment{{0, len(self.Seq), 0}}, n, false, false),
+			s.remapFeatures([]segment{{0, len(s.Seq), len(self.Seq)}}, n, false, false)...)
 		ID = self.ID + "+" + s.ID
 		if self.Inplace {
 			ts = append(self.Seq, s.Seq...)
@@ -231,16 +328,17 @@ func (self *Seq) Join(s *Seq, where int) (j *Seq, err error) {
 		j.Quality = q // self.Quality will become nil if either sequence lacks Quality
 	} else {
 		j = &Seq{
-			ID:      ID,
-			Seq:     ts,
-			Strand:  self.Strand,
-			Moltype: self.Moltype,
-			Quality: q,
+			ID:       ID,
+			Seq:      ts,
+			Strand:   self.Strand,
+			Alphabet: self.Alphabet,
+			Quality:  q,
 		}
 	}
 	if where == Prepend {
 		j.Offset -= s.Len()
 	}
+	j.Features = offsetFeatures(fs, j.Offset)
 
 	return
 }
@@ -267,6 +365,17 @@ func (self *Seq) Stitch(f feat.FeatureSet) (s *Seq, err error) {
 	}
 	fs, _ := t.Flatten(span, 0, 0)
 
+	var (
+		segs []segment
+		n    int
+	)
+	for _, seg := range fs {
+		start, end := util.Max(seg.Start()-self.Offset, 0), util.Min(seg.End()-self.Offset, len(self.Seq))
+		segs = append(segs, segment{start, end, n})
+		n += end - start
+	}
+	features := self.remapFeatures(segs, n, false, false)
+
 	if self.Inplace {
 		self.Seq = self.stitch(fs)
 		self.Offset = 0
@@ -298,10 +407,11 @@ func (self *Seq) Stitch(f feat.FeatureSet) (s *Seq, err error) {
 			Offset:   0,
 			Strand:   self.Strand,
 			Circular: false,
-			Moltype:  self.Moltype,
+			Alphabet: self.Alphabet,
 			Quality:  q,
 		}
 	}
+	s.Features = features
 
 	return
 }
@@ -314,6 +424,64 @@ func (self *Seq) stitch(f []*interval.Interval) (ts []byte) {
 	return
 }
 
+// Rotate a circular Seq so that it begins at origin, given in sequence coordinates. The Offset of
+// the Seq is retained, so positions are renumbered from the new origin. Features and Quality are
+// rotated with the Seq; features spanning the new origin are described with a Start greater than
+// their End.
+func (self *Seq) Rotate(origin int) (s *Seq, err error) {
+	if !self.Circular {
+		return nil, bio.NewError("Cannot rotate linear molecule.", 0, self)
+	}
+	if origin < self.Offset || origin > self.End() {
+		return nil, bio.NewError("Origin out of range.", 0, origin)
+	}
+	if !self.Inplace && self.Quality != nil && self.Quality.Inplace {
+		return nil, bio.NewError("Inplace operation on Quality with non-Inplace operation on parent Seq.", 0, self)
+	}
+
+	o := origin - self.Offset
+	fs := self.remapFeatures([]segment{{o, len(self.Seq), 0}, {0, o, len(self.Seq) - o}}, len(self.Seq), false, true)
+
+	var q *Quality
+	if self.Quality != nil {
+		if q, err = self.Quality.Rotate(origin); err != nil {
+			return nil, bio.NewError("Quality.Rotate() returned error", 0, err)
+		}
+	}
+
+	if self.Inplace {
+		rotate(self.Seq, o)
+		s = self
+		s.Quality = q
+	} else {
+		s = &Seq{
+			ID:       self.ID,
+			Seq:      append(append(make([]byte, 0, len(self.Seq)), self.Seq[o:]...), self.Seq[:o]...),
+			Offset:   self.Offset,
+			Strand:   self.Strand,
+			Circular: true,
+			Alphabet: self.Alphabet,
+			Quality:  q,
+		}
+	}
+	s.Features = offsetFeatures(fs, s.Offset)
+
+	return
+}
+
+// Rotate b left by n in place.
+func rotate(b []byte, n int) {
+	reverse(b[:n])
+	reverse(b[n:])
+	reverse(b)
+}
+
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
 var defaultStringFunc = func(s *Seq) string { return string(s.Seq) }
 
 var StringFunc = defaultStringFunc
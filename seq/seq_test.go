@@ -16,6 +16,7 @@ package seq
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
 import (
+	"github.com/kortschak/BioGo/feat"
 	check "launchpad.net/gocheck"
 	"testing"
 )
@@ -29,3 +30,42 @@ var _ = check.Suite(&S{})
 
 func (s *S) TestSeqXXX(c *check.C) {
 }
+
+func (s *S) TestRotate(c *check.C) {
+	sq := &Seq{
+		ID:       "p",
+		Seq:      []byte("AACCGGTT"),
+		Offset:   1,
+		Circular: true,
+		Quality:  &Quality{Qual: []Qsanger{0, 1, 2, 3, 4, 5, 6, 7}, Offset: 1, Circular: true},
+		Features: feat.FeatureSet{{Start: 3, End: 6, Frame: -1}, {Start: 8, End: 2, Frame: -1}},
+	}
+	r, err := sq.Rotate(4)
+	c.Assert(err, check.IsNil)
+	c.Check(string(r.Seq), check.Equals, "CGGTTAAC")
+	c.Check(r.Offset, check.Equals, 1)
+	c.Check(r.Quality.Qual, check.DeepEquals, []Qsanger{3, 4, 5, 6, 7, 0, 1, 2})
+	c.Check(string(sq.Seq), check.Equals, "AACCGGTT")
+	var spans [][2]int
+	for _, f := range r.Features {
+		spans = append(spans, [2]int{f.Start, f.End})
+	}
+	c.Check(spans, check.DeepEquals, [][2]int{{8, 3}, {5, 7}})
+
+	t, err := r.Trunc(r.Features[0].Start, r.Features[0].End)
+	c.Check(err, check.IsNil)
+	c.Check(string(t.Seq), check.Equals, "CCG")
+
+	sq.Inplace = true
+	sq.Quality.Inplace = true
+	r, err = sq.Rotate(9)
+	c.Assert(err, check.IsNil)
+	c.Check(r, check.Equals, sq)
+	c.Check(string(sq.Seq), check.Equals, "AACCGGTT")
+	r, err = sq.Rotate(2)
+	c.Assert(err, check.IsNil)
+	c.Check(string(sq.Seq), check.Equals, "ACCGGTTA")
+
+	_, err = (&Seq{Seq: []byte("ACGT")}).Rotate(1)
+	c.Check(err, check.NotNil)
+}
@@ -0,0 +1,118 @@
+package seq
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	"strconv"
+)
+
+// Translation options.
+const (
+	InitStart  = 1 << iota // Translate a start codon in the first position as methionine.
+	StopAtStop             // End translation before the first stop codon.
+)
+
+// Translate the Seq using the genetic code, starting at the zero-based frame. Frames 0, 1 and
+// 2 translate the Seq as given and frames -1, -2 and -3 translate its reverse complement
+// starting at offsets 0, 1 and 2 from its end. Stop codons are translated as '*'. Translation
+// is modified by the InitStart and StopAtStop flags.
+//
+// The returned protein Seq is described in nucleotide coordinates of the Seq: for forward
+// frames Offset is the position of the first base of the first codon and residue i is encoded
+// by the bases from Offset+3i to Offset+3i+3. For reverse frames the Strand is reversed and,
+// following RevComp, Offset is the end of the first codon, so residue i is encoded by the bases
+// from Offset-3i-3 to Offset-3i.
+func (self *Seq) Translate(code *GeneticCode, frame int, flags int) (p *Seq, err error) {
+	if m := self.Moltype(); m != bio.DNA && m != bio.RNA {
+		return nil, bio.NewError("Cannot translate non-nucleic acid sequence.", 0, self)
+	}
+	if frame < -3 || frame > 2 {
+		return nil, bio.NewError("Frame out of range.", 0, frame)
+	}
+	if code == nil {
+		code = Standard
+	}
+
+	var (
+		reverse    = frame < 0
+		skip       = frame
+		codon      = make([]byte, 3)
+		complement []byte
+	)
+	if reverse {
+		skip = -frame - 1
+		if complement, err = self.complementTable(); err != nil {
+			return nil, err
+		}
+	}
+
+	var aa []byte
+	for i := skip; i+3 <= len(self.Seq); i += 3 {
+		if reverse {
+			for j := range codon {
+				codon[j] = complement[self.Seq[len(self.Seq)-1-i-j]]
+			}
+		} else {
+			copy(codon, self.Seq[i:i+3])
+		}
+
+		a := code.Translate(codon)
+		if i == skip && flags&InitStart != 0 && code.IsStart(codon) {
+			a = 'M'
+		}
+		if a == '*' && flags&StopAtStop != 0 {
+			break
+		}
+		aa = append(aa, a)
+	}
+
+	p = &Seq{
+		ID:       self.ID,
+		Seq:      aa,
+		Offset:   self.Offset + skip,
+		Strand:   self.Strand,
+		Alphabet: alphabet.Protein,
+	}
+	if reverse {
+		p.Offset = self.End() - skip
+		p.Strand = -self.Strand
+	}
+
+	return
+}
+
+// Return the translations of the Seq in all six reading frames, in the frame order 0, 1, 2,
+// -1, -2, -3 used by Translate. The frame is appended to the ID of each translation, numbered
+// from one as +1 to +3 and -1 to -3.
+func (self *Seq) SixFrame(code *GeneticCode, flags int) (p []*Seq, err error) {
+	p = make([]*Seq, 0, 6)
+	for _, frame := range [...]int{0, 1, 2, -1, -2, -3} {
+		var t *Seq
+		if t, err = self.Translate(code, frame, flags); err != nil {
+			return nil, err
+		}
+		if frame >= 0 {
+			t.ID += "/+" + strconv.Itoa(frame+1)
+		} else {
+			t.ID += "/" + strconv.Itoa(frame)
+		}
+		p = append(p, t)
+	}
+
+	return
+}
@@ -0,0 +1,108 @@
+package seq
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/alphabet"
+	"github.com/kortschak/BioGo/bio"
+	check "launchpad.net/gocheck"
+	"sort"
+)
+
+func (s *S) TestGeneticCodes(c *check.C) {
+	var ids []int
+	for id, code := range GeneticCodes {
+		c.Check(code.ID, check.Equals, id)
+		c.Check(len(code.AminoAcids), check.Equals, 64, check.Commentf("code %d", id))
+		c.Check(len(code.Starts), check.Equals, 64, check.Commentf("code %d", id))
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	c.Check(ids, check.DeepEquals, []int{1, 2, 3, 4, 5, 6, 9, 10, 11, 12, 13, 14, 16, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33})
+
+	for _, t := range []struct {
+		code  *GeneticCode
+		codon string
+		aa    byte
+		start bool
+		stop  bool
+	}{
+		{Standard, "ATG", 'M', true, false},
+		{Standard, "aug", 'M', true, false},
+		{Standard, "TTG", 'L', true, false},
+		{Standard, "TGA", '*', false, true},
+		{Standard, "TAR", '*', false, true},
+		{Standard, "TRA", '*', false, true},
+		{Standard, "TGR", 'X', false, false},
+		{Standard, "GCN", 'A', false, false},
+		{Standard, "YTR", 'L', false, false},
+		{Standard, "MGR", 'R', false, false},
+		{Standard, "GC-", 'X', false, false},
+		{GeneticCodes[2], "TGA", 'W', false, false},
+		{GeneticCodes[2], "AGA", '*', false, true},
+		{GeneticCodes[2], "ATA", 'M', true, false},
+		{GeneticCodes[11], "GTG", 'V', true, false},
+		{GeneticCodes[32], "TAG", 'W', false, false},
+		{GeneticCodes[32], "TAA", '*', false, true},
+	} {
+		codon := []byte(t.codon)
+		c.Check(t.code.Translate(codon), check.Equals, t.aa, check.Commentf("%d %s", t.code.ID, t.codon))
+		c.Check(t.code.IsStart(codon), check.Equals, t.start, check.Commentf("%d %s", t.code.ID, t.codon))
+		c.Check(t.code.IsStop(codon), check.Equals, t.stop, check.Commentf("%d %s", t.code.ID, t.codon))
+	}
+}
+
+func (s *S) TestTranslate(c *check.C) {
+	sq := &Seq{ID: "s", Seq: []byte("GTGGCCTAAGCNTGA"), Offset: 10, Strand: 1}
+	for _, t := range []struct {
+		frame, flags int
+		aa           string
+		offset       int
+		strand       int8
+	}{
+		{0, 0, "VA*A*", 10, 1},
+		{0, InitStart, "VA*A*", 10, 1},
+		{0, StopAtStop, "VA", 10, 1},
+		{1, 0, "WPKX", 11, 1},
+		{2, 0, "GLSX", 12, 1},
+		{-1, 0, "SXLGH", 25, -1},
+		{-2, StopAtStop, "XA", 24, -1},
+		{-3, StopAtStop, "XLRP", 23, -1},
+	} {
+		p, err := sq.Translate(nil, t.frame, t.flags)
+		c.Assert(err, check.IsNil)
+		c.Check(string(p.Seq), check.Equals, t.aa, check.Commentf("frame %d", t.frame))
+		c.Check(p.Offset, check.Equals, t.offset, check.Commentf("frame %d", t.frame))
+		c.Check(p.Strand, check.Equals, t.strand, check.Commentf("frame %d", t.frame))
+		c.Check(p.Moltype(), check.Equals, bio.Protein)
+	}
+
+	p, err := sq.Translate(GeneticCodes[11], 0, InitStart)
+	c.Assert(err, check.IsNil)
+	c.Check(string(p.Seq), check.Equals, "MA*A*")
+
+	_, err = sq.Translate(nil, 3, 0)
+	c.Check(err, check.NotNil)
+	_, err = (&Seq{Seq: []byte("MAL"), Alphabet: alphabet.Protein}).Translate(nil, 0, 0)
+	c.Check(err, check.NotNil)
+
+	frames, err := sq.SixFrame(nil, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(frames), check.Equals, 6)
+	c.Check(frames[1].ID, check.Equals, "s/+2")
+	c.Check(frames[4].ID, check.Equals, "s/-2")
+	c.Check(string(frames[4].Seq), check.Equals, "XA*A")
+}
@@ -0,0 +1,291 @@
+// Package for quality-based trimming and filtering of sequence reads
+package trim
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/io/seqio"
+	"github.com/kortschak/BioGo/seq"
+)
+
+// A Trimmer determines the part of a sequence to retain. Start and end are in sequence coordinates.
+type Trimmer interface {
+	Trim(*seq.Seq) (start, end int, err error)
+}
+
+// A Filter determines whether a sequence should be retained.
+type Filter interface {
+	Pass(*seq.Seq) bool
+}
+
+// Return the result of applying each of the trimmers to s in turn. Trimming uses Seq.Trunc, so
+// the quality of s is trimmed with the sequence and the semantics of Inplace are followed.
+func Trim(s *seq.Seq, trimmers ...Trimmer) (t *seq.Seq, err error) {
+	t = s
+	for _, tr := range trimmers {
+		var start, end int
+		if start, end, err = tr.Trim(t); err != nil {
+			return nil, err
+		}
+		if start == t.Start() && end == t.End() {
+			continue
+		}
+		if start > end {
+			start = end
+		}
+		if t, err = t.Trunc(start, end); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// Return whether s passes all the filters.
+func Pass(s *seq.Seq, filters ...Filter) bool {
+	for _, f := range filters {
+		if !f.Pass(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the quality scores of s or an error if s has none.
+func quality(s *seq.Seq) ([]seq.Qsanger, error) {
+	if s.Quality == nil || len(s.Quality.Qual) != len(s.Seq) {
+		return nil, bio.NewError("Sequence has no quality scores.", 0, s.ID)
+	}
+	return s.Quality.Qual, nil
+}
+
+// Leading removes bases from the 5' end with quality below its value.
+type Leading seq.Qsanger
+
+func (self Leading) Trim(s *seq.Seq) (start, end int, err error) {
+	q, err := quality(s)
+	if err != nil {
+		return
+	}
+	for start = 0; start < len(q) && q[start] < seq.Qsanger(self); start++ {
+	}
+	return s.Offset + start, s.End(), nil
+}
+
+// Trailing removes bases from the 3' end with quality below its value.
+type Trailing seq.Qsanger
+
+func (self Trailing) Trim(s *seq.Seq) (start, end int, err error) {
+	q, err := quality(s)
+	if err != nil {
+		return
+	}
+	for end = len(q); end > 0 && q[end-1] < seq.Qsanger(self); end-- {
+	}
+	return s.Offset, s.Offset + end, nil
+}
+
+// SlidingWindow scans from the 5' end and removes the 3' end of the sequence from the start of the
+// first window of Size bases with a mean quality below Quality.
+type SlidingWindow struct {
+	Size    int
+	Quality float64
+}
+
+func (self SlidingWindow) Trim(s *seq.Seq) (start, end int, err error) {
+	q, err := quality(s)
+	if err != nil {
+		return
+	}
+	if self.Size < 1 {
+		return 0, 0, bio.NewError("Window size must be positive.", 0, self.Size)
+	}
+	size := self.Size
+	if size > len(q) {
+		size = len(q)
+	}
+	var sum int
+	for i := 0; i < size; i++ {
+		sum += int(q[i])
+	}
+	for end = 0; end+size <= len(q); end++ {
+		if end > 0 {
+			sum += int(q[end+size-1]) - int(q[end-1])
+		}
+		if float64(sum) < self.Quality*float64(size) {
+			return s.Offset, s.Offset + end, nil
+		}
+		if size == 0 {
+			break
+		}
+	}
+	return s.Offset, s.End(), nil
+}
+
+// Mott retains the highest scoring segment of the sequence, where each base scores Limit less
+// its probability of error, as described by the modified Mott algorithm used by phred.
+type Mott struct {
+	Limit float64
+}
+
+func (self Mott) Trim(s *seq.Seq) (start, end int, err error) {
+	q, err := quality(s)
+	if err != nil {
+		return
+	}
+	var (
+		sum, best float64
+		from      int
+	)
+	for i, v := range q {
+		if sum <= 0 {
+			sum, from = 0, i
+		}
+		if sum += self.Limit - v.ProbE(); sum > best {
+			best, start, end = sum, from, i+1
+		}
+	}
+	return s.Offset + start, s.Offset + end, nil
+}
+
+// BWA removes the 3' end of the sequence as described for the -q option of bwa aln, trimming to
+// the position that maximises the sum of the differences between the threshold and the quality of
+// the removed bases.
+type BWA seq.Qsanger
+
+func (self BWA) Trim(s *seq.Seq) (start, end int, err error) {
+	q, err := quality(s)
+	if err != nil {
+		return
+	}
+	var sum, best int
+	end = len(q)
+	for i := len(q) - 1; i >= 0; i-- {
+		if sum += int(self) - int(q[i]); sum < 0 {
+			break
+		}
+		if sum > best {
+			best, end = sum, i
+		}
+	}
+	return s.Offset, s.Offset + end, nil
+}
+
+// MinLength passes sequences at least as long as its value.
+type MinLength int
+
+func (self MinLength) Pass(s *seq.Seq) bool { return s.Len() >= int(self) }
+
+// MaxExpectedErrors passes sequences with a sum of base error probabilities no greater than its
+// value. Sequences without quality scores do not pass.
+type MaxExpectedErrors float64
+
+func (self MaxExpectedErrors) Pass(s *seq.Seq) bool {
+	q, err := quality(s)
+	if err != nil {
+		return false
+	}
+	var e float64
+	for _, v := range q {
+		e += v.ProbE()
+	}
+	return e <= float64(self)
+}
+
+// MinMeanQuality passes non-empty sequences with a mean quality score at least its value.
+// Sequences without quality scores do not pass.
+type MinMeanQuality float64
+
+func (self MinMeanQuality) Pass(s *seq.Seq) bool {
+	q, err := quality(s)
+	if err != nil || len(q) == 0 {
+		return false
+	}
+	var sum int
+	for _, v := range q {
+		sum += int(v)
+	}
+	return float64(sum)/float64(len(q)) >= float64(self)
+}
+
+// Return the number of N letters in s.
+func countN(s *seq.Seq) (n int) {
+	for _, l := range s.Seq {
+		if l == 'N' || l == 'n' {
+			n++
+		}
+	}
+	return
+}
+
+// MaxN passes sequences with no more N letters than its value.
+type MaxN int
+
+func (self MaxN) Pass(s *seq.Seq) bool { return countN(s) <= int(self) }
+
+// MaxNFraction passes sequences with a fraction of N letters no greater than its value.
+type MaxNFraction float64
+
+func (self MaxNFraction) Pass(s *seq.Seq) bool {
+	return s.Len() == 0 || float64(countN(s))/float64(s.Len()) <= float64(self)
+}
+
+// A Reader trims and filters the sequences read from an underlying reader, so it can be used in
+// place of a seqio.Reader in a streaming pipeline.
+type Reader struct {
+	r        seqio.Reader
+	Trimmers []Trimmer
+	Filters  []Filter
+	In, Out  int // Number of sequences read from the underlying reader and returned.
+}
+
+// Return a new Reader applying the trimmers and then the filters to sequences read from r.
+func NewReader(r seqio.Reader, trimmers []Trimmer, filters []Filter) *Reader {
+	return &Reader{
+		r:        r,
+		Trimmers: trimmers,
+		Filters:  filters,
+	}
+}
+
+// Read the next sequence that passes the filters after trimming.
+func (self *Reader) Read() (s *seq.Seq, err error) {
+	for {
+		if s, err = self.r.Read(); err != nil {
+			return nil, err
+		}
+		self.In++
+		if s, err = Trim(s, self.Trimmers...); err != nil {
+			return nil, err
+		}
+		if Pass(s, self.Filters...) {
+			self.Out++
+			return
+		}
+	}
+}
+
+// Rewind the underlying reader and reset the counts.
+func (self *Reader) Rewind() (err error) {
+	if err = self.r.Rewind(); err == nil {
+		self.In, self.Out = 0, 0
+	}
+	return
+}
+
+// Close the underlying reader.
+func (self *Reader) Close() error { return self.r.Close() }
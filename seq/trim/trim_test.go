@@ -0,0 +1,140 @@
+package trim
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/io/seqio/fastq"
+	"github.com/kortschak/BioGo/seq"
+	"io"
+	check "launchpad.net/gocheck"
+	"strings"
+	"testing"
+)
+
+// Tests
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func read(l string, q []seq.Qsanger, offset int) *seq.Seq {
+	s := seq.New("read", []byte(l), seq.NewQuality("read", q))
+	s.Offset, s.Quality.Offset = offset, offset
+	return s
+}
+
+var qual = []seq.Qsanger{5, 10, 30, 30, 30, 30, 30, 12, 8, 2}
+
+func (s *S) TestTrimmers(c *check.C) {
+	for _, t := range []struct {
+		trimmer    Trimmer
+		q          []seq.Qsanger
+		start, end int
+	}{
+		{Leading(20), qual, 2, 10},
+		{Trailing(20), qual, 0, 7},
+		{Leading(40), qual, 10, 10},
+		{SlidingWindow{3, 20}, []seq.Qsanger{30, 30, 30, 30, 20, 10, 10, 30, 30, 30}, 0, 4},
+		{SlidingWindow{3, 10}, []seq.Qsanger{30, 30, 30, 30, 20, 10, 10, 30, 30, 30}, 0, 10},
+		{SlidingWindow{20, 20}, qual, 0, 0},
+		{Mott{0.05}, qual, 2, 7},
+		{Mott{0.001}, qual, 0, 0},
+		{BWA(20), qual, 0, 7},
+		{BWA(0), qual, 0, 10},
+	} {
+		start, end, err := t.trimmer.Trim(read("ACGTACGTAC", t.q, 100))
+		c.Check(err, check.IsNil)
+		c.Check(start, check.Equals, 100+t.start, check.Commentf("%#v", t.trimmer))
+		c.Check(end, check.Equals, 100+t.end, check.Commentf("%#v", t.trimmer))
+	}
+
+	_, _, err := Leading(20).Trim(&seq.Seq{Seq: []byte("ACGT")})
+	c.Check(err, check.NotNil)
+	_, _, err = SlidingWindow{0, 20}.Trim(read("ACGTACGTAC", qual, 0))
+	c.Check(err, check.NotNil)
+}
+
+func (s *S) TestTrim(c *check.C) {
+	r := read("ACGTACGTAC", qual, 0)
+	t, err := Trim(r, Leading(20), Trailing(20))
+	c.Assert(err, check.IsNil)
+	c.Check(string(t.Seq), check.Equals, "GTACG")
+	c.Check(t.Quality.Qual, check.DeepEquals, []seq.Qsanger{30, 30, 30, 30, 30})
+	c.Check(t.Start(), check.Equals, 2)
+	c.Check(t.Quality.Start(), check.Equals, 2)
+	c.Check(string(r.Seq), check.Equals, "ACGTACGTAC")
+
+	t, err = Trim(r, Leading(40))
+	c.Assert(err, check.IsNil)
+	c.Check(t.Len(), check.Equals, 0)
+	c.Check(t.Quality.Len(), check.Equals, 0)
+}
+
+func (s *S) TestFilters(c *check.C) {
+	r := read("ACGTACGTAC", qual, 0)
+	t, _ := Trim(r, Mott{0.05})
+	for _, f := range []struct {
+		filter Filter
+		s      *seq.Seq
+		pass   bool
+	}{
+		{MinLength(10), r, true},
+		{MinLength(10), t, false},
+		{MaxExpectedErrors(1), r, false},
+		{MaxExpectedErrors(1), t, true},
+		{MaxExpectedErrors(1), &seq.Seq{Seq: []byte("ACGT")}, false},
+		{MinMeanQuality(20), r, false},
+		{MinMeanQuality(20), t, true},
+		{MaxN(1), &seq.Seq{Seq: []byte("ANnA")}, false},
+		{MaxN(2), &seq.Seq{Seq: []byte("ANnA")}, true},
+		{MaxNFraction(0.5), &seq.Seq{Seq: []byte("ANnA")}, true},
+		{MaxNFraction(0.25), &seq.Seq{Seq: []byte("ANnA")}, false},
+	} {
+		c.Check(f.filter.Pass(f.s), check.Equals, f.pass, check.Commentf("%#v %s", f.filter, f.s))
+	}
+	c.Check(Pass(t, MinLength(5), MinMeanQuality(20)), check.Equals, true)
+	c.Check(Pass(t, MinLength(6), MinMeanQuality(20)), check.Equals, false)
+}
+
+type closer struct{ io.Reader }
+
+func (closer) Close() error { return nil }
+
+func (s *S) TestReader(c *check.C) {
+	in := strings.Join([]string{
+		"@good", "ACGTACGT", "+", "IIIIIIII",
+		"@short", "ACGTACGT", "+", "IIII####",
+		"@ns", "NNNNACGT", "+", "IIIIIIII",
+		"@trimmed", "ACGTACGTAC", "+", "IIIIIIII##",
+		"",
+	}, "\n")
+	r := NewReader(fastq.NewReader(closer{strings.NewReader(in)}), []Trimmer{Trailing(20)}, []Filter{MinLength(5), MaxN(2)})
+	var ids, seqs []string
+	for {
+		s, err := r.Read()
+		if err != nil {
+			c.Check(err, check.Equals, io.EOF)
+			break
+		}
+		ids, seqs = append(ids, s.ID), append(seqs, string(s.Seq))
+		c.Check(s.Quality.Len(), check.Equals, s.Len())
+	}
+	c.Check(ids, check.DeepEquals, []string{"good", "trimmed"})
+	c.Check(seqs, check.DeepEquals, []string{"ACGTACGT", "ACGTACGT"})
+	c.Check(r.In, check.Equals, 4)
+	c.Check(r.Out, check.Equals, 2)
+}
@@ -0,0 +1,183 @@
+// Package for extraction of unique molecular identifiers and collapsing of duplicate reads
+package umi
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/bio"
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/seq"
+	"sort"
+	"strings"
+)
+
+// Separator placed between the read name and its UMI.
+var Separator = "_"
+
+// Extract the UMI from the start of s described by pattern, where 'N' marks a UMI base and any
+// other letter a discarded spacer base. The UMI is appended to the first word of the ID of the
+// returned sequence following Separator and the bases covered by pattern are removed using
+// Seq.Trunc, so quality is trimmed with the sequence and the semantics of Inplace are followed.
+func Extract(s *seq.Seq, pattern string) (t *seq.Seq, umi string, err error) {
+	if len(pattern) == 0 || s.Len() < len(pattern) {
+		return nil, "", bio.NewError("Sequence shorter than UMI pattern.", 0, s.ID, pattern)
+	}
+	u := make([]byte, 0, len(pattern))
+	for i := range pattern {
+		if pattern[i] == 'N' {
+			u = append(u, s.Seq[i])
+		}
+	}
+	umi = string(u)
+
+	id := s.ID
+	if t, err = s.Trunc(s.Offset+len(pattern), s.End()); err != nil {
+		return nil, "", err
+	}
+	if i := strings.IndexAny(id, " \t"); i >= 0 {
+		t.ID = id[:i] + Separator + umi + id[i:]
+	} else {
+		t.ID = id + Separator + umi
+	}
+	if t.Quality != nil {
+		t.Quality.ID = t.ID
+	}
+
+	return
+}
+
+// Return the UMI held in the first word of id following the last Separator.
+func Of(id string) string {
+	if i := strings.IndexAny(id, " \t"); i >= 0 {
+		id = id[:i]
+	}
+	return id[strings.LastIndex(id, Separator)+len(Separator):]
+}
+
+type node struct {
+	umi   string
+	count int
+}
+
+type byCount []node
+
+func (self byCount) Len() int { return len(self) }
+func (self byCount) Less(i, j int) bool {
+	if self[i].count == self[j].count {
+		return self[i].umi < self[j].umi
+	}
+	return self[i].count > self[j].count
+}
+func (self byCount) Swap(i, j int) { self[i], self[j] = self[j], self[i] }
+
+// Return whether a and b are of equal length and differ at exactly one position.
+func adjacent(a, b string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	d := 0
+	for i := 0; i < len(a) && d < 2; i++ {
+		if a[i] != b[i] {
+			d++
+		}
+	}
+	return d == 1
+}
+
+// Cluster the UMIs with the given counts by the directional adjacency method of Smith et al.
+// (2017). A UMI a is connected to a UMI b differing at one position if count(a) >= 2*count(b)-1,
+// and each cluster is the set of UMIs reached from its most abundant UMI, which is the first
+// UMI of the cluster. Clusters are returned in order of decreasing representative count.
+func Cluster(counts map[string]int) (clusters [][]string) {
+	nodes := make([]node, 0, len(counts))
+	for u, c := range counts {
+		nodes = append(nodes, node{u, c})
+	}
+	sort.Sort(byCount(nodes))
+
+	seen := make([]bool, len(nodes))
+	for i := range nodes {
+		if seen[i] {
+			continue
+		}
+		seen[i] = true
+		cluster := []string{nodes[i].umi}
+		for queue := []int{i}; len(queue) > 0; queue = queue[1:] {
+			a := nodes[queue[0]]
+			for j, b := range nodes {
+				if !seen[j] && adjacent(a.umi, b.umi) && a.count >= 2*b.count-1 {
+					seen[j] = true
+					cluster = append(cluster, b.umi)
+					queue = append(queue, j)
+				}
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	return
+}
+
+// A key identifies a mapping position.
+type key struct {
+	location string
+	pos      int
+	strand   int8
+}
+
+// Return the mapping position of the 5' end of a read.
+func keyOf(f *feat.Feature) key {
+	if f.Strand < 0 {
+		return key{f.Location, f.End, f.Strand}
+	}
+	return key{f.Location, f.Start, f.Strand}
+}
+
+// Dedup collapses duplicate reads, given as mapped features with the read's UMI in its ID as
+// added by Extract. Reads are grouped by the Location, Strand and 5' position of their mapping,
+// and the UMIs at each position are clustered with Cluster. One read is returned for each cluster,
+// the highest scoring read carrying the cluster's representative UMI, and the first such read
+// if scores are equal. Reads are returned in order of the first appearance of their position.
+func Dedup(reads feat.FeatureSet) (unique feat.FeatureSet) {
+	var (
+		order  []key
+		groups = make(map[key]feat.FeatureSet)
+	)
+	for _, r := range reads {
+		k := keyOf(r)
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], r)
+	}
+
+	for _, k := range order {
+		counts := make(map[string]int)
+		best := make(map[string]*feat.Feature)
+		for _, r := range groups[k] {
+			u := Of(r.ID)
+			counts[u]++
+			if b, ok := best[u]; !ok || r.Score > b.Score {
+				best[u] = r
+			}
+		}
+		for _, c := range Cluster(counts) {
+			unique = append(unique, best[c[0]])
+		}
+	}
+
+	return
+}
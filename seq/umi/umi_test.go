@@ -0,0 +1,103 @@
+package umi
+
+// Copyright ©2012 Dan Kortschak <dan.kortschak@adelaide.edu.au>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+import (
+	"github.com/kortschak/BioGo/feat"
+	"github.com/kortschak/BioGo/seq"
+	check "launchpad.net/gocheck"
+	"testing"
+)
+
+// Helpers
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func (s *S) TestExtract(c *check.C) {
+	r := seq.New("read1 1:N:0:1", []byte("ACGTTGCATTTTGGGG"), seq.NewQuality("read1 1:N:0:1", []seq.Qsanger{
+		1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16,
+	}))
+	t, u, err := Extract(r, "NNNNXXNN")
+	c.Assert(err, check.Equals, nil)
+	c.Check(u, check.Equals, "ACGTCA")
+	c.Check(t.ID, check.Equals, "read1_ACGTCA 1:N:0:1")
+	c.Check(string(t.Seq), check.Equals, "TTTTGGGG")
+	c.Check(t.Quality.Qual, check.DeepEquals, []seq.Qsanger{9, 10, 11, 12, 13, 14, 15, 16})
+	c.Check(Of(t.ID), check.Equals, "ACGTCA")
+
+	r = seq.New("read2", []byte("ACG"), nil)
+	_, _, err = Extract(r, "NNNN")
+	c.Check(err, check.NotNil)
+	r = seq.New("read2", []byte("ACGTA"), nil)
+	t, u, err = Extract(r, "NNNN")
+	c.Assert(err, check.Equals, nil)
+	c.Check(t.ID, check.Equals, "read2_ACGT")
+	c.Check(string(t.Seq), check.Equals, "A")
+}
+
+func (s *S) TestCluster(c *check.C) {
+	for i, t := range []struct {
+		counts   map[string]int
+		clusters [][]string
+	}{
+		{
+			map[string]int{"AAAA": 10, "AAAT": 3, "AATT": 3, "GGGG": 2},
+			[][]string{{"AAAA", "AAAT"}, {"AATT"}, {"GGGG"}},
+		},
+		{
+			map[string]int{"AAAA": 10, "AAAT": 5, "AATT": 2},
+			[][]string{{"AAAA", "AAAT", "AATT"}},
+		},
+		{
+			map[string]int{"AAAA": 3, "AAAT": 3},
+			[][]string{{"AAAA"}, {"AAAT"}},
+		},
+		{
+			map[string]int{"AAAA": 1, "AAAT": 1},
+			[][]string{{"AAAA", "AAAT"}},
+		},
+		{
+			map[string]int{"AAAA": 4, "AAA": 1},
+			[][]string{{"AAAA"}, {"AAA"}},
+		},
+	} {
+		c.Check(Cluster(t.counts), check.DeepEquals, t.clusters, check.Commentf("Test %d", i))
+	}
+}
+
+func (s *S) TestDedup(c *check.C) {
+	read := func(id, loc string, start, end int, strand int8, score float64) *feat.Feature {
+		return &feat.Feature{ID: id, Location: loc, Start: start, End: end, Strand: strand, Score: score}
+	}
+	reads := feat.FeatureSet{
+		read("r1_AAAA", "chr1", 100, 150, 1, 30),
+		read("r2_AAAA", "chr1", 100, 140, 1, 40),
+		read("r3_AAAT", "chr1", 100, 150, 1, 60),
+		read("r4_AAAA", "chr1", 100, 150, 1, 20),
+		read("r5_GGGG", "chr1", 100, 150, 1, 10),
+		read("r6_AAAA", "chr1", 90, 150, -1, 10),
+		read("r7_AAAT", "chr1", 80, 150, -1, 10),
+		read("r8_AAAA", "chr2", 100, 150, 1, 10),
+	}
+	var ids []string
+	for _, f := range Dedup(reads) {
+		ids = append(ids, f.ID)
+	}
+	c.Check(ids, check.DeepEquals, []string{"r2_AAAA", "r5_GGGG", "r6_AAAA", "r8_AAAA"})
+}
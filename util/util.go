@@ -15,25 +15,3 @@ package util
 //
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
-
-import ()
-
-// A CTL hold a code to letter lookup table.
-// TODO: Replace this with the functionality provided by alphabet.
-type CTL struct {
-	ValueToCode [256]int
-}
-
-// Inititialise and return a CTL based on a map m.
-func NewCTL(m map[int]int) (t *CTL) {
-	t = &CTL{}
-	for i := 0; i < 256; i++ {
-		if code, present := m[i]; present {
-			t.ValueToCode[i] = code
-		} else {
-			t.ValueToCode[i] = -1
-		}
-	}
-
-	return
-}